@@ -0,0 +1,105 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind of error, check it with errors.Is
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnavailable  = errors.New("unavailable")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	// ErrPreconditionFailed is error of data is not of the version expected by request
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is error of a kind, Message can be shown to client
+type Error struct {
+	Kind    error
+	Message string
+	// Code is machine readable code of the error, optional
+	Code string
+	// Field and Value of request cause the error, optional
+	Field string
+	Value interface{}
+	// Err is the cause, it is not shown to client
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is report the error is of kind target
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithField return copy of the error with field and value of request cause it
+func (e *Error) WithField(field string, value interface{}) *Error {
+	err := *e
+	err.Field = field
+	err.Value = value
+	return &err
+}
+
+// WithCode return copy of the error with machine readable code
+func (e *Error) WithCode(code string) *Error {
+	err := *e
+	err.Code = code
+	return &err
+}
+
+// Wrap return copy of the error with cause err
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+func newError(kind error, format string, a ...interface{}) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, a...)}
+}
+
+// NotFound create error of data is not exist
+func NotFound(format string, a ...interface{}) *Error {
+	return newError(ErrNotFound, format, a...)
+}
+
+// Conflict create error of request is conflict with current state of data
+func Conflict(format string, a ...interface{}) *Error {
+	return newError(ErrConflict, format, a...)
+}
+
+// Validation create error of request is invalid
+func Validation(format string, a ...interface{}) *Error {
+	return newError(ErrValidation, format, a...)
+}
+
+// Unauthorized create error of request is not authenticated
+func Unauthorized(format string, a ...interface{}) *Error {
+	return newError(ErrUnauthorized, format, a...)
+}
+
+// Forbidden create error of authenticated request is not allowed
+func Forbidden(format string, a ...interface{}) *Error {
+	return newError(ErrForbidden, format, a...)
+}
+
+// PreconditionFailed create error of data is changed since the version expected by request
+func PreconditionFailed(format string, a ...interface{}) *Error {
+	return newError(ErrPreconditionFailed, format, a...)
+}
+
+// Unavailable create error of storage or other dependency is failed
+func Unavailable(err error) *Error {
+	return &Error{Kind: ErrUnavailable, Message: err.Error(), Err: err}
+}
@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound returned by Get when key is not exist or expired
+var ErrNotFound = errors.New("cache: key not found")
+
+// Cache store encoded value by key, it is safe for concurrent use
+type Cache interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+	// DeletePrefix delete all keys start with prefix
+	DeletePrefix(prefix string) error
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jellydator/ttlcache/v2"
+)
+
+// memoryCache is cache in memory of this instance
+type memoryCache struct {
+	cache *ttlcache.Cache
+}
+
+func NewCacheMemory() *memoryCache {
+	return &memoryCache{ttlcache.NewCache()}
+}
+
+func (m *memoryCache) Get(key string) ([]byte, error) {
+	value, err := m.cache.Get(key)
+	if err == ttlcache.ErrNotFound {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]byte), nil
+}
+
+func (m *memoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	return m.cache.SetWithTTL(key, value, ttl)
+}
+
+func (m *memoryCache) Delete(keys ...string) error {
+	for _, key := range keys {
+		err := m.cache.Remove(key)
+		if err != nil && err != ttlcache.ErrNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryCache) DeletePrefix(prefix string) error {
+	for _, key := range m.cache.GetKeys() {
+		if strings.HasPrefix(key, prefix) {
+			err := m.Delete(key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package cache
+
+import "time"
+
+// noopCache never store anything, every Get is a miss
+type noopCache struct{}
+
+func NewCacheNoop() *noopCache {
+	return &noopCache{}
+}
+
+func (noopCache) Get(key string) ([]byte, error) {
+	return nil, ErrNotFound
+}
+
+func (noopCache) Set(key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (noopCache) Delete(keys ...string) error {
+	return nil
+}
+
+func (noopCache) DeletePrefix(prefix string) error {
+	return nil
+}
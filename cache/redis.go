@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidateChannel is channel of redis to publish deleted keys to other instances
+const InvalidateChannel = "todo-list:cache:invalidate"
+
+// prefixSetKey is prefix of set in redis of keys start with a prefix
+const prefixSetKey = "todo-list:cache:prefix:"
+
+// prefixSeparators end prefixes of keys tracked in sets, so DeletePrefix of them delete
+// the members instead of scanning every key of redis
+const prefixSeparators = ":@"
+
+// localTTL is max time a value is kept in memory of this instance, it limit
+// stale data when an invalidation from other instance is missed
+const localTTL = time.Minute
+
+// invalidation is message published to InvalidateChannel
+type invalidation struct {
+	// Sender is id of instance publish the message
+	Sender string   `json:"sender"`
+	Keys   []string `json:"keys,omitempty"`
+	Prefix string   `json:"prefix,omitempty"`
+}
+
+// redisCache is cache shared by all instances in redis, or any server speak RESP.
+// Value is kept in memory of this instance too, and removed from all instances
+// by invalidation published in InvalidateChannel. Keys are tracked in a set of every
+// prefix end with a separator, so DeletePrefix of such prefix does not scan redis
+type redisCache struct {
+	id     string
+	client *redis.Client
+	local  *memoryCache
+	pubsub *redis.PubSub
+}
+
+func NewCacheRedis(client *redis.Client) (*redisCache, error) {
+	ctx := context.Background()
+
+	// Wait subscription is ready, so no invalidation is missed after return
+	pubsub := client.Subscribe(ctx, InvalidateChannel)
+	_, err := pubsub.Receive(ctx)
+	if err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	_, err = rand.Read(id)
+	if err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	r := &redisCache{hex.EncodeToString(id), client, NewCacheMemory(), pubsub}
+	go r.listen()
+
+	return r, nil
+}
+
+// listen apply invalidation from other instances to memory of this instance
+func (r *redisCache) listen() {
+	for message := range r.pubsub.Channel() {
+		var data invalidation
+		err := json.Unmarshal([]byte(message.Payload), &data)
+		// Memory of this instance is already updated
+		if err != nil || data.Sender == r.id {
+			continue
+		}
+
+		r.local.Delete(data.Keys...)
+		if data.Prefix != "" {
+			r.local.DeletePrefix(data.Prefix)
+		}
+	}
+}
+
+func (r *redisCache) Get(key string) ([]byte, error) {
+	value, err := r.local.Get(key)
+	if err == nil {
+		return value, nil
+	}
+
+	value, err = r.client.Get(context.Background(), key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.local.Set(key, value, localTTL)
+	return value, nil
+}
+
+func (r *redisCache) Set(key string, value []byte, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Set(context.Background(), key, value, ttl)
+		for _, prefix := range prefixes(key) {
+			pipe.SAdd(context.Background(), prefixSetKey+prefix, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.local.Set(key, value, localExpiry(ttl))
+	if err != nil {
+		return err
+	}
+
+	// Other instances may keep the old value in memory
+	return r.publish(invalidation{Keys: []string{key}})
+}
+
+func (r *redisCache) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := r.client.Del(context.Background(), keys...).Err()
+	if err != nil {
+		return err
+	}
+
+	r.local.Delete(keys...)
+	return r.publish(invalidation{Keys: keys})
+}
+
+func (r *redisCache) DeletePrefix(prefix string) error {
+	var err error
+	if prefix != "" && strings.IndexByte(prefixSeparators, prefix[len(prefix)-1]) != -1 {
+		err = r.deleteMembers(prefixSetKey + prefix)
+	} else {
+		err = r.deleteScanned(prefix)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.local.DeletePrefix(prefix)
+	return r.publish(invalidation{Prefix: prefix})
+}
+
+// deleteMembers delete keys of set until it is empty. Member is popped before it is
+// deleted, so key set meanwhile is either deleted or kept in the set
+func (r *redisCache) deleteMembers(set string) error {
+	ctx := context.Background()
+	for {
+		keys, err := r.client.SPopN(ctx, set, 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		err = r.client.Del(ctx, keys...).Err()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// deleteScanned delete keys start with prefix found by SCAN, it read every key of redis
+func (r *redisCache) deleteScanned(prefix string) error {
+	ctx := context.Background()
+
+	iter := r.client.Scan(ctx, 0, escapePattern(prefix)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		err := r.client.Del(ctx, iter.Val()).Err()
+		if err != nil {
+			return err
+		}
+	}
+
+	return iter.Err()
+}
+
+// Close stop listening invalidation, client is not closed
+func (r *redisCache) Close() error {
+	return r.pubsub.Close()
+}
+
+func (r *redisCache) publish(data invalidation) error {
+	data.Sender = r.id
+	message, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Publish(context.Background(), InvalidateChannel, message).Err()
+}
+
+// prefixes return every prefix of key end with a separator, "todos:1@user:2" has
+// prefixes "todos:", "todos:1@" and "todos:1@user:"
+func prefixes(key string) []string {
+	var found []string
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(prefixSeparators, key[i]) != -1 {
+			found = append(found, key[:i+1])
+		}
+	}
+	return found
+}
+
+// escapePattern escape special characters of glob pattern in SCAN MATCH
+func escapePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(value)
+}
+
+// localExpiry return ttl of value in memory, zero ttl mean the value never expire
+func localExpiry(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > localTTL {
+		return localTTL
+	}
+	return ttl
+}
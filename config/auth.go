@@ -0,0 +1,35 @@
+package config
+
+import (
+	"crypto/rand"
+	"log"
+	"os"
+	"time"
+
+	"github.com/letenk/todo-list/service"
+)
+
+// SetupToken create token service by env JWT_SECRET and JWT_TTL (default 24h).
+// Without JWT_SECRET a random secret is used, so tokens are invalid after restart
+func SetupToken() service.TokenService {
+	secret := []byte(os.Getenv("JWT_SECRET"))
+	if len(secret) == 0 {
+		log.Println("JWT_SECRET is not set, using random secret")
+		secret = make([]byte, 32)
+		_, err := rand.Read(secret)
+		if err != nil {
+			log.Fatalf("Failed to create secret %v", err)
+		}
+	}
+
+	ttl := 24 * time.Hour
+	if value := os.Getenv("JWT_TTL"); value != "" {
+		var err error
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("Invalid JWT_TTL %s, must be a duration like 24h", value)
+		}
+	}
+
+	return service.NewServiceToken(secret, ttl)
+}
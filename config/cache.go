@@ -0,0 +1,39 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/letenk/todo-list/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetupCache create cache by env CACHE_DRIVER, memory (default) or redis.
+// Use redis when there is more than one instance of this app
+func SetupCache() cache.Cache {
+	driver := os.Getenv("CACHE_DRIVER")
+
+	switch driver {
+	case "", "memory":
+		log.Println("Using cache in memory")
+		return cache.NewCacheMemory()
+	case "redis":
+		DB, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+		client := redis.NewClient(&redis.Options{
+			Addr:     os.Getenv("REDIS_ADDR"),
+			Password: os.Getenv("REDIS_PASSWORD"),
+			DB:       DB,
+		})
+
+		redisCache, err := cache.NewCacheRedis(client)
+		if err != nil {
+			log.Fatalf("Failed to connect to redis %v", err)
+		}
+		log.Println("Connected to Redis!")
+		return redisCache
+	default:
+		log.Fatalf("Unknown CACHE_DRIVER %s, must be memory or redis", driver)
+		return nil
+	}
+}
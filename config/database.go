@@ -6,24 +6,55 @@ import (
 	"os"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"github.com/letenk/todo-list/models/domain"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var counts int64
 
-func openConnection() (*gorm.DB, error) {
-	DBUser := os.Getenv("MYSQL_USER")
-	DBPassword := os.Getenv("MYSQL_PASSWORD")
-	DBHost := os.Getenv("MYSQL_HOST")
-	DBPort := os.Getenv("MYSQL_PORT")
-	DBName := os.Getenv("MYSQL_DBNAME")
+// dialector create dialector of gorm by env DB_DRIVER, mysql (default), postgres or sqlite
+func dialector() (gorm.Dialector, error) {
+	driver := os.Getenv("DB_DRIVER")
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", DBUser, DBPassword, DBHost, DBPort, DBName)
+	switch driver {
+	case "", "mysql":
+		DBUser := os.Getenv("MYSQL_USER")
+		DBPassword := os.Getenv("MYSQL_PASSWORD")
+		DBHost := os.Getenv("MYSQL_HOST")
+		DBPort := os.Getenv("MYSQL_PORT")
+		DBName := os.Getenv("MYSQL_DBNAME")
 
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", DBUser, DBPassword, DBHost, DBPort, DBName)
+		return mysql.Open(dsn), nil
+	case "postgres":
+		DBUser := os.Getenv("POSTGRES_USER")
+		DBPassword := os.Getenv("POSTGRES_PASSWORD")
+		DBHost := os.Getenv("POSTGRES_HOST")
+		DBPort := os.Getenv("POSTGRES_PORT")
+		DBName := os.Getenv("POSTGRES_DBNAME")
+
+		dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", DBHost, DBUser, DBPassword, DBName, DBPort)
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "todo-list.db"
+		}
+
+		// Foreign key is disabled by default in sqlite
+		dsn := fmt.Sprintf("%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path)
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %s, must be mysql, postgres or sqlite", driver)
+	}
+}
+
+func openConnection(dialector gorm.Dialector) (*gorm.DB, error) {
 	// Open connection to db
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +64,11 @@ func openConnection() (*gorm.DB, error) {
 		return nil, err
 	}
 
+	// Sqlite allow one writer only, share one connection so writes wait each other
+	if db.Dialector.Name() == "sqlite" {
+		sqlDB.SetMaxOpenConns(1)
+	}
+
 	err = sqlDB.Ping()
 	if err != nil {
 		return nil, err
@@ -42,20 +78,27 @@ func openConnection() (*gorm.DB, error) {
 }
 
 func SetupDB() *gorm.DB {
+	dialector, err := dialector()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	for {
-		conn, err := openConnection()
+		conn, err := openConnection(dialector)
 		if err != nil {
-			log.Println("MySQL not yet ready ...")
+			log.Println("Database not yet ready ...")
 			// Increments var counts
 			counts++
 		} else {
-			// Auto Migrate
-			err = conn.AutoMigrate(&domain.Activity{}, &domain.Todo{})
+			// Auto Migrate is only for development, use command migrate for the others
+			if os.Getenv("DB_AUTO_MIGRATE") == "true" {
+				err = conn.AutoMigrate(&domain.User{}, &domain.APIKey{}, &domain.Activity{}, &domain.Membership{}, &domain.Todo{}, &domain.IdempotencyKey{}, &domain.Webhook{}, &domain.WebhookDelivery{}, &domain.OutboxEvent{})
 
-			if err != nil {
-				log.Fatalf("Failed to auto migration %v", err)
+				if err != nil {
+					log.Fatalf("Failed to auto migration %v", err)
+				}
 			}
-			log.Println("Connected to MySQL!")
+			log.Printf("Connected to %s!\n", conn.Dialector.Name())
 			return conn
 		}
 
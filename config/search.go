@@ -0,0 +1,45 @@
+package config
+
+import (
+	"log"
+	"os"
+
+	"github.com/letenk/todo-list/search"
+	"gorm.io/gorm"
+)
+
+// SetupSearch create search index by env SEARCH_DRIVER, database or memory. Default is
+// database for MySQL and PostgreSQL, memory for the others. Index in memory is built
+// from db on start and only see writes of this instance
+func SetupSearch(db *gorm.DB) search.Index {
+	driver := os.Getenv("SEARCH_DRIVER")
+
+	switch driver {
+	case "", "database":
+		index, err := search.NewIndexDatabase(db)
+		if err == nil {
+			log.Println("Using search index of database")
+			return index
+		}
+		if driver == "database" {
+			log.Fatalf("Failed to use search index of database %v", err)
+		}
+		return setupSearchMemory(db)
+	case "memory":
+		return setupSearchMemory(db)
+	default:
+		log.Fatalf("Unknown SEARCH_DRIVER %s, must be database or memory", driver)
+		return nil
+	}
+}
+
+func setupSearchMemory(db *gorm.DB) search.Index {
+	index := search.NewIndexMemory()
+	err := search.Reindex(index, db)
+	if err != nil {
+		log.Fatalf("Failed to build search index %v", err)
+	}
+
+	log.Println("Using search index in memory")
+	return index
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// SetupWebsocketOrigins return origins allowed to open websocket by env
+// WEBSOCKET_ALLOWED_ORIGINS, separated by comma like https://app.example.com. Without it
+// only client without header Origin is accepted
+func SetupWebsocketOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("WEBSOCKET_ALLOWED_ORIGINS"), ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "" {
+			continue
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			log.Fatalf("Invalid origin %s of WEBSOCKET_ALLOWED_ORIGINS, must be like https://app.example.com", origin)
+		}
+		origins = append(origins, origin)
+	}
+
+	if len(origins) == 0 {
+		log.Println("WEBSOCKET_ALLOWED_ORIGINS is not set, websocket of browser is refused")
+	}
+	return origins
+}
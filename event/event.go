@@ -0,0 +1,65 @@
+package event
+
+import "time"
+
+// Type of event, it is kind and change of the data
+const (
+	TodoCreated          = "todo.created"
+	TodoUpdated          = "todo.updated"
+	TodoDeleted          = "todo.deleted"
+	ActivityGroupCreated = "activity_group.created"
+	ActivityGroupUpdated = "activity_group.updated"
+	ActivityGroupDeleted = "activity_group.deleted"
+	MemberAdded          = "member.added"
+	MemberRemoved        = "member.removed"
+)
+
+// Types list all types of event
+var Types = []string{TodoCreated, TodoUpdated, TodoDeleted, ActivityGroupCreated, ActivityGroupUpdated, ActivityGroupDeleted, MemberAdded, MemberRemoved}
+
+// IsValidType check value is one of Types
+func IsValidType(eventType string) bool {
+	for _, t := range Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is change of a todo or an activity group
+type Event struct {
+	// ID is set by Bus, it is increased by every event
+	ID   uint64
+	Type string
+	// ActivityGroupID is activity group of the todo, or the activity group itself
+	ActivityGroupID uint64
+	// Data is domain.Todo, domain.Activity or domain.Membership after the change, before it
+	// for delete
+	Data      interface{}
+	CreatedAt time.Time
+}
+
+// Bus deliver published events to subscribers, it is safe for concurrent use
+type Bus interface {
+	// Publish set ID of event and send it to every subscriber
+	Publish(event Event) Event
+	// Subscribe return subscription of events published after event afterID, 0 is
+	// only the new events
+	Subscribe(afterID uint64) *Subscription
+}
+
+// Subscription receive events of Bus until it is closed
+type Subscription struct {
+	// Events is closed when the subscription is closed, or the subscriber is too slow to
+	// keep up. Subscribe again with the last received ID to resume
+	Events <-chan Event
+	// Missed is true when some events after afterID of Subscribe are not kept anymore
+	Missed bool
+	close  func()
+}
+
+// Close stop the subscription, it is safe to call more than once
+func (s *Subscription) Close() {
+	s.close()
+}
@@ -0,0 +1,96 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultKept is how many latest events memory bus keep for resume
+const DefaultKept = 1000
+
+// subscriberBuffer is how many events can wait for a subscriber, it is dropped when
+// the buffer is full
+const subscriberBuffer = 64
+
+// memoryBus is Bus of one process, events are lost when the process is stopped
+type memoryBus struct {
+	mu     sync.Mutex
+	lastID uint64
+	// kept is the latest events, the oldest first
+	kept        []Event
+	size        int
+	subscribers map[chan Event]struct{}
+}
+
+// NewBusMemory create bus keep size latest events for resume
+func NewBusMemory(size int) *memoryBus {
+	return &memoryBus{size: size, subscribers: map[chan Event]struct{}{}}
+}
+
+func (b *memoryBus) Publish(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event.ID = b.lastID
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	b.kept = append(b.kept, event)
+	if len(b.kept) > b.size {
+		b.kept = b.kept[len(b.kept)-b.size:]
+	}
+
+	for events := range b.subscribers {
+		select {
+		case events <- event:
+		default:
+			// Slow subscriber resume by Last-Event-ID, instead of block the publisher
+			delete(b.subscribers, events)
+			close(events)
+		}
+	}
+
+	return event
+}
+
+func (b *memoryBus) Subscribe(afterID uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Event
+	missed := false
+	if afterID != 0 {
+		for _, event := range b.kept {
+			if event.ID > afterID {
+				backlog = append(backlog, event)
+			}
+		}
+		// ID after the last one is of other process before restart
+		oldest := b.lastID + 1
+		if len(backlog) != 0 {
+			oldest = backlog[0].ID
+		}
+		missed = afterID > b.lastID || oldest > afterID+1
+	}
+
+	events := make(chan Event, len(backlog)+subscriberBuffer)
+	for _, event := range backlog {
+		events <- event
+	}
+	b.subscribers[events] = struct{}{}
+
+	return &Subscription{
+		Events: events,
+		Missed: missed,
+		close: func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[events]; ok {
+				delete(b.subscribers, events)
+				close(events)
+			}
+		},
+	}
+}
@@ -1,20 +1,14 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jellydator/ttlcache/v2"
-	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
 	"github.com/letenk/todo-list/service"
 )
 
-// Create new instance ttlcache
-var cache ttlcache.SimpleCache = ttlcache.NewCache()
-
 type ActivityHandler struct {
 	service service.ActivityService
 }
@@ -24,145 +18,94 @@ func NewActivityHandler(service service.ActivityService) *ActivityHandler {
 }
 
 func (h *ActivityHandler) GetAll(c *gin.Context) {
-	// Get data from cache
-	key := "activities"
-	activities, err := cache.Get(key)
-	if err == ttlcache.ErrNotFound {
-		// Get all from database
-		activities, err := h.service.GetAll()
-		if err != nil {
-			jsonResponse := web.JSONResponse(
-				"Internal Server Error",
-				"Internal Server Error",
-				domain.Activity{},
-			)
-			c.JSON(http.StatusInternalServerError, jsonResponse)
-			return
-		}
-
-		formatResponseJSON := web.FormatActivitiesGroup(activities)
-		// Cache data
-		go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-		jsonResponse := web.JSONResponse(
-			"Success",
-			"Success",
-			formatResponseJSON,
-		)
-		c.JSON(http.StatusOK, jsonResponse)
+	var query web.ActivityQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindingError(c, err, &query, "limit and offset must be a number")
+		return
+	}
+
+	if !repository.IsValidActivitySort(query.Sort) {
+		badRequestField(c, "sort", web.FieldOneOf, sortErrorMessage(repository.ActivitySorts))
+		return
+	}
+
+	field, message := pageError(query.PageQuery, true)
+	if message != "" {
+		badRequestField(c, field, web.FieldInvalid, message)
+		return
+	}
+
+	activities, err := h.service.WithOwner(ownerID(c)).GetAll(query)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	// Every list is paginated, the first page when query of pagination is not sent
+	total, err := h.service.WithOwner(ownerID(c)).Count()
+	if err != nil {
+		errorResponse(c, err)
 		return
 	}
 
-	// Get all data from cache
+	var nextCursor string
+	if len(activities) != 0 {
+		nextCursor = repository.EncodeCursor(repository.ActivityCursor(activities[len(activities)-1], query.Sort))
+	}
+	setPaginationHeader(c, query.PageQuery, total, len(activities), nextCursor)
+
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
-		activities,
+		web.FormatActivitiesGroup(activities),
 	)
 	c.JSON(http.StatusOK, jsonResponse)
-
 }
 
 func (h *ActivityHandler) GetOne(c *gin.Context) {
 	var activityID web.ActivityIdURI
 	err := c.ShouldBindUri(&activityID)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"Uri id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &activityID, "Uri id cannot be null")
+		return
+	}
+
+	// Find by id
+	Activity, err := h.service.WithOwner(ownerID(c)).GetOne(activityID.ID)
+	if err != nil {
+		errorResponse(c, err)
 		return
 	}
 
-	// Get data from cache
-	key := fmt.Sprintf("activity-id-%d", activityID.ID)
-	activity, err := cache.Get(key)
-	if err == ttlcache.ErrNotFound {
-		// Find by id from database
-		Activity, err := h.service.GetOne(activityID.ID)
-		if err != nil {
-			resp := gin.H{}
-			jsonResponse := web.JSONResponse(
-				"Internal Server Error",
-				err.Error(),
-				resp,
-			)
-			c.JSON(http.StatusInternalServerError, jsonResponse)
-			return
-		}
-
-		// If not found
-		if Activity.ID == 0 {
-			resp := gin.H{}
-			message := fmt.Sprintf("Activity with ID %d Not Found", activityID.ID)
-			jsonResponse := web.JSONResponse(
-				"Not Found",
-				message,
-				resp,
-			)
-			c.JSON(http.StatusNotFound, jsonResponse)
-			return
-		}
-
-		formatResponseJSON := web.FormatActivityGetOne(Activity)
-		// Cache data
-		go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-		jsonResponse := web.JSONResponse(
-			"Success",
-			"Success",
-			formatResponseJSON,
-		)
-		c.JSON(http.StatusOK, jsonResponse)
+	if notModified(c, Activity.Version) {
 		return
 	}
 
-	// Get one data from cache
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
-		activity,
+		web.FormatActivityGetOne(Activity),
 	)
 	c.JSON(http.StatusOK, jsonResponse)
-
 }
 
 func (h *ActivityHandler) Create(c *gin.Context) {
 	var req web.ActivityRequest
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"title cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &req, "title cannot be null")
 		return
 	}
 
 	// Create
-	newActivity, err := h.service.Create(req)
+	newActivity, err := h.service.WithOwner(ownerID(c)).Create(req)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			"Internal Server Error",
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
 
 	formatResponseJSON := web.FormatActivity(newActivity)
-	// Cache
-	if newActivity.ID != 0 {
-		key := fmt.Sprintf("activity-id-%d", newActivity.ID)
-		go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-		go cache.Remove("activities")
-	}
-
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
@@ -175,76 +118,33 @@ func (h *ActivityHandler) Update(c *gin.Context) {
 	var id web.ActivityIdURI
 	err := c.ShouldBindUri(&id)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"Uri id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &id, "Uri id cannot be null")
 		return
 	}
 
 	var req web.ActivityUpdateRequest
 	err = c.ShouldBindJSON(&req)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"title cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &req, "title cannot be null")
 		return
 	}
 
-	// Find by id
-	Activity, err := h.service.GetOne(id.ID)
+	// Update only the version of If-Match when it is sent
+	req.Version, err = ifMatch(c, "Activity", id.ID, h.currentVersion(c, id.ID))
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
-		return
-	}
-
-	// If not found
-	if Activity.ID == 0 {
-		resp := gin.H{}
-		message := fmt.Sprintf("Activity with ID %d Not Found", id.ID)
-		jsonResponse := web.JSONResponse(
-			"Not Found",
-			message,
-			resp,
-		)
-		c.JSON(http.StatusNotFound, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
 
 	// Update
-	updatedActivity, err := h.service.Update(Activity.ID, req)
+	updatedActivity, err := h.service.WithOwner(ownerID(c)).Update(id.ID, req)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
-	formatResponseJSON := web.FormatActivityGetOne(updatedActivity)
-	// Cache and remove
-	if updatedActivity.ID != 0 {
-		key := fmt.Sprintf("activity-id-%d", updatedActivity.ID)
-		go cache.Remove(key)
-		go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-		go cache.Remove("activities")
-	}
 
+	c.Header("ETag", etag(updatedActivity.Version))
+	formatResponseJSON := web.FormatActivityGetOne(updatedActivity)
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
@@ -258,60 +158,39 @@ func (h *ActivityHandler) Delete(c *gin.Context) {
 	var id web.ActivityIdURI
 	err := c.ShouldBindUri(&id)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"Uri id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &id, "Uri id cannot be null")
 		return
 	}
 
-	// Find by id
-	activity, err := h.service.GetOne(id.ID)
+	var query web.ActivityDeleteQuery
+	err = c.ShouldBindQuery(&query)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		bindingError(c, err, &query, "move_to must be a number")
 		return
 	}
 
-	// If not found
-	if activity.ID == 0 {
-		resp := gin.H{}
-		message := fmt.Sprintf("Activity with ID %d Not Found", id.ID)
-		jsonResponse := web.JSONResponse(
-			"Not Found",
-			message,
-			resp,
-		)
-		c.JSON(http.StatusNotFound, jsonResponse)
+	if query.Policy != "" && query.Policy != web.DeletePolicyCascade && query.Policy != web.DeletePolicyRestrict {
+		badRequestField(c, "policy", web.FieldOneOf, "policy must be one of cascade, restrict")
 		return
 	}
 
-	// Delete
-	ok, err := h.service.Delete(activity.ID)
+	if query.MoveTo == id.ID {
+		badRequestField(c, "move_to", web.FieldInvalid, "move_to cannot be the deleted activity group")
+		return
+	}
+
+	// Delete only the version of If-Match when it is sent
+	query.Version, err = ifMatch(c, "Activity", id.ID, h.currentVersion(c, id.ID))
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
 
-	// Cache and remove
-	if ok {
-		key := fmt.Sprintf("activity-id-%d", activity.ID)
-		go cache.Remove(key)
-		go cache.Remove("activities")
+	// Delete, activity group not found, still has todos or move_to not found is an error
+	_, err = h.service.WithOwner(ownerID(c)).DeleteWithPolicy(id.ID, query)
+	if err != nil {
+		errorResponse(c, err)
+		return
 	}
 
 	resp := gin.H{}
@@ -322,3 +201,35 @@ func (h *ActivityHandler) Delete(c *gin.Context) {
 	)
 	c.JSON(http.StatusOK, jsonResponse)
 }
+
+// currentVersion return func get version of activity group for ifMatch
+func (h *ActivityHandler) currentVersion(c *gin.Context, id uint64) func() (uint64, error) {
+	return func() (uint64, error) {
+		Activity, err := h.service.WithOwner(ownerID(c)).GetOne(id)
+		return Activity.Version, err
+	}
+}
+
+func (h *ActivityHandler) Restore(c *gin.Context) {
+	var id web.ActivityIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	// Restore, activity group must be in trash
+	restoredActivity, err := h.service.WithOwner(ownerID(c)).Restore(id.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	formatResponseJSON := web.FormatActivityGetOne(restoredActivity)
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		formatResponseJSON,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
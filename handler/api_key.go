@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+)
+
+// Message for invalid value of scopes
+var scopeErrorMessage = fmt.Sprintf("scopes must be one of %s", strings.Join(domain.APIKeyScopes, ", "))
+
+type apiKeyHandler struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyHandler(service service.APIKeyService) *apiKeyHandler {
+	return &apiKeyHandler{service}
+}
+
+func (h *apiKeyHandler) GetAll(c *gin.Context) {
+	keys, err := h.service.GetAll(ownerID(c))
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatAPIKeys(keys),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *apiKeyHandler) Create(c *gin.Context) {
+	var req web.APIKeyRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		bindingError(c, err, &req, "name and scopes cannot be null")
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !domain.IsValidScope(scope) {
+			badRequestField(c, "scopes", web.FieldOneOf, scopeErrorMessage)
+			return
+		}
+	}
+
+	// Create, the key is shown only in this response
+	key, secret, err := h.service.Create(ownerID(c), req)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatCreatedAPIKey(key, secret),
+	)
+	c.JSON(http.StatusCreated, jsonResponse)
+}
+
+func (h *apiKeyHandler) Revoke(c *gin.Context) {
+	var id web.APIKeyIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	// Delete, key of other user is not found
+	_, err = h.service.Revoke(ownerID(c), id.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	resp := gin.H{}
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		resp,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+)
+
+// Key of authenticated user in gin context
+const (
+	userIDKey = "user_id"
+	// apiKeyKey is set only when request is authenticated with API key
+	apiKeyKey = "api_key"
+	// queryTokenKey is token of query access_token, it is set by RedactQueryToken
+	queryTokenKey = "query_token"
+)
+
+// WebsocketTokenProtocol is subprotocol of websocket followed by the token, for browser
+// send token without URL like new WebSocket(url, ["bearer", token])
+const WebsocketTokenProtocol = "bearer"
+
+type authHandler struct {
+	service       service.AuthService
+	apiKeyService service.APIKeyService
+}
+
+func NewAuthHandler(service service.AuthService, apiKeyService service.APIKeyService) *authHandler {
+	return &authHandler{service, apiKeyService}
+}
+
+// Authenticate is middleware require header Authorization with bearer token of login
+// or API key, id of the user is kept in context for the next handlers
+func (h *authHandler) Authenticate(c *gin.Context) {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		errorResponse(c, apperror.Unauthorized("Authorization header with bearer token is required").WithCode("missing_token"))
+		c.Abort()
+		return
+	}
+
+	token = strings.TrimSpace(token)
+	if service.IsAPIKey(token) {
+		apiKey, err := h.apiKeyService.Authenticate(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			errorResponse(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Set(userIDKey, apiKey.UserID)
+		c.Set(apiKeyKey, apiKey)
+		c.Next()
+		return
+	}
+
+	userID, err := h.service.Authenticate(token)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		errorResponse(c, err)
+		c.Abort()
+		return
+	}
+
+	c.Set(userIDKey, userID)
+	c.Next()
+}
+
+// RequireScope is middleware allow request with API key only when the key has one of
+// scopes of the method, read for GET and HEAD and write for the others. Request with
+// bearer token of login is always allowed
+func RequireScope(read []string, write []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes := write
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scopes = read
+		}
+		if hasScope(c, scopes) {
+			c.Next()
+			return
+		}
+
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(scopes, " ")))
+		errorResponse(c, apperror.Forbidden("API key require scope %s", strings.Join(scopes, " or ")).WithCode("insufficient_scope"))
+		c.Abort()
+	}
+}
+
+// hasScope check API key of request has one of scopes, request with bearer token of login
+// has every scope
+func hasScope(c *gin.Context, scopes []string) bool {
+	value, ok := c.Get(apiKeyKey)
+	if !ok {
+		return true
+	}
+	apiKey := value.(domain.APIKey)
+
+	for _, scope := range scopes {
+		if apiKey.HasScope(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactQueryToken is middleware remove query access_token from URL, so the token is not
+// written to access log. It must run before the logger, the token is used by TokenFromQuery only
+func RedactQueryToken(c *gin.Context) {
+	query := c.Request.URL.Query()
+	if query.Has("access_token") {
+		c.Set(queryTokenKey, query.Get("access_token"))
+		query.Del("access_token")
+		c.Request.URL.RawQuery = query.Encode()
+	}
+	c.Next()
+}
+
+// TokenFromQuery is middleware use token of subprotocol bearer of websocket, or query
+// access_token, as bearer token when header Authorization is not sent. It is for client
+// cannot set header like websocket of browser
+func TokenFromQuery(c *gin.Context) {
+	if c.GetHeader("Authorization") != "" {
+		c.Next()
+		return
+	}
+
+	token := c.GetString(queryTokenKey)
+	protocols := strings.Split(c.GetHeader("Sec-WebSocket-Protocol"), ",")
+	for i := 0; i+1 < len(protocols); i++ {
+		if strings.TrimSpace(protocols[i]) == WebsocketTokenProtocol {
+			token = strings.TrimSpace(protocols[i+1])
+			break
+		}
+	}
+
+	if token != "" {
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	c.Next()
+}
+
+// RequireLogin is middleware refuse request with API key, so a key cannot manage keys
+func RequireLogin(c *gin.Context) {
+	if _, ok := c.Get(apiKeyKey); ok {
+		errorResponse(c, apperror.Forbidden("API key cannot be used here, login is required").WithCode("login_required"))
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// ownerID return id of authenticated user, 0 when the route is not authenticated
+func ownerID(c *gin.Context) uint64 {
+	return c.GetUint64(userIDKey)
+}
+
+func (h *authHandler) Register(c *gin.Context) {
+	var req web.RegisterRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		bindingError(c, err, &req, "email, name and password of at least 8 characters cannot be null")
+		return
+	}
+
+	// Register, email already used is an error
+	user, token, expiresAt, err := h.service.Register(req)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatToken(user, token, expiresAt),
+	)
+	c.JSON(http.StatusCreated, jsonResponse)
+}
+
+func (h *authHandler) Login(c *gin.Context) {
+	var req web.LoginRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		bindingError(c, err, &req, "email and password cannot be null")
+		return
+	}
+
+	user, token, expiresAt, err := h.service.Login(req)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatToken(user, token, expiresAt),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *authHandler) Me(c *gin.Context) {
+	user, err := h.service.GetUser(ownerID(c))
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatUser(user),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/web"
+)
+
+// Code of problem not from apperror
+const (
+	codeBadRequest    = "bad_request"
+	codeInternalError = "internal_error"
+	codeBodyTooLarge  = "body_too_large"
+)
+
+// errorKind hold status and problem code of a kind of apperror
+type errorKind struct {
+	status int
+	code   string
+}
+
+// Status and problem code of each kind of apperror
+var errorKinds = map[error]errorKind{
+	apperror.ErrNotFound:           {http.StatusNotFound, "not_found"},
+	apperror.ErrConflict:           {http.StatusConflict, "conflict"},
+	apperror.ErrValidation:         {http.StatusUnprocessableEntity, "validation_failed"},
+	apperror.ErrUnavailable:        {http.StatusServiceUnavailable, "service_unavailable"},
+	apperror.ErrUnauthorized:       {http.StatusUnauthorized, "unauthorized"},
+	apperror.ErrForbidden:          {http.StatusForbidden, "forbidden"},
+	apperror.ErrPreconditionFailed: {http.StatusPreconditionFailed, "precondition_failed"},
+}
+
+// acceptProblem check client prefer application/problem+json to the envelope, by the
+// order of header Accept
+func acceptProblem(c *gin.Context) bool {
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.Split(accepted, ";")[0])
+		switch mediaType {
+		case web.ProblemContentType:
+			return true
+		case gin.MIMEJSON, "application/*", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// writeError response error as Problem when client accept it, otherwise as the envelope with data
+func writeError(c *gin.Context, status int, code string, message string, data interface{}, fieldErrors []web.FieldError) {
+	if acceptProblem(c) {
+		problem := web.Problem{
+			Type:     web.ProblemType(code),
+			Title:    http.StatusText(status),
+			Status:   status,
+			Detail:   message,
+			Instance: c.Request.URL.Path,
+			Code:     code,
+			Errors:   fieldErrors,
+		}
+		c.Header("Content-Type", web.ProblemContentType)
+		c.JSON(status, problem)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		http.StatusText(status),
+		message,
+		data,
+	)
+	c.JSON(status, jsonResponse)
+}
+
+// badRequest response request is invalid before it reach the service
+func badRequest(c *gin.Context, message string, fieldErrors ...web.FieldError) {
+	writeError(c, http.StatusBadRequest, codeBadRequest, message, gin.H{}, fieldErrors)
+}
+
+// badRequestField response request is invalid because of one field
+func badRequestField(c *gin.Context, field string, code string, message string) {
+	badRequest(c, message, web.FieldError{Field: field, Code: code, Message: message})
+}
+
+// bindingError response failed binding of obj, with error of each field
+func bindingError(c *gin.Context, err error, obj interface{}, message string) {
+	badRequest(c, message, bindingFieldErrors(c, obj, err)...)
+}
+
+// errorResponse response err with status of its kind. Message of apperror is shown to
+// client, except for server error which only attached to context for the logger
+func errorResponse(c *gin.Context, err error) {
+	kind, message := describeError(c, err)
+	resp := gin.H{}
+	var fieldErrors []web.FieldError
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) && appErr.Field != "" {
+		resp = gin.H{
+			"field": appErr.Field,
+			"value": appErr.Value,
+		}
+		fieldErrors = append(fieldErrors, web.FieldError{Field: appErr.Field, Code: kind.code, Message: appErr.Message})
+	}
+
+	writeError(c, kind.status, kind.code, message, resp, fieldErrors)
+}
+
+// describeError return status, code and message of err shown to client. Server error
+// is attached to context for the logger
+func describeError(c *gin.Context, err error) (errorKind, string) {
+	kind := errorKind{http.StatusInternalServerError, codeInternalError}
+	message := http.StatusText(kind.status)
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		if errKind, ok := errorKinds[appErr.Kind]; ok {
+			kind = errKind
+		}
+
+		if kind.status < http.StatusInternalServerError {
+			message = appErr.Message
+		} else {
+			message = http.StatusText(kind.status)
+		}
+
+		if appErr.Code != "" {
+			kind.code = appErr.Code
+		}
+	}
+
+	if kind.status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+
+	return kind, message
+}
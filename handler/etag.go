@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/apperror"
+)
+
+// etag of version of todo or activity group, it is strong because the response is
+// changed only by update and update always increment version
+func etag(version uint64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// matchETag check list of ETag of header has ETag of version or is *. Weak ETag is
+// compared only when weak is true, If-Match use strong comparison
+func matchETag(header string, version uint64, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified set header ETag of version, then respond 304 when header If-None-Match has it
+func notModified(c *gin.Context, version uint64) bool {
+	c.Header("ETag", etag(version))
+
+	header := c.GetHeader("If-None-Match")
+	if header != "" && matchETag(header, version, true) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ifMatch return version header If-Match is made for, 0 when it is not sent or is *.
+// The current version is got only when header is sent, error is precondition failed when
+// it is not in the header
+func ifMatch(c *gin.Context, name string, id uint64, current func() (uint64, error)) (uint64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	version, err := current()
+	if err != nil {
+		return 0, err
+	}
+
+	if !matchETag(header, version, false) {
+		return 0, apperror.PreconditionFailed("%s with ID %d is changed, it does not match If-Match", name, id).
+			WithCode("version_mismatch")
+	}
+	return version, nil
+}
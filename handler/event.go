@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+)
+
+// eventHeartbeat is interval of comment sent to idle stream, so proxy keep it open
+const eventHeartbeat = 15 * time.Second
+
+type eventHandler struct {
+	service service.EventService
+}
+
+func NewEventHandler(service service.EventService) *eventHandler {
+	return &eventHandler{service}
+}
+
+// writeEvent write event in format of server-sent events
+func writeEvent(w gin.ResponseWriter, e event.Event) error {
+	data, err := json.Marshal(web.FormatEvent(e))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	w.Flush()
+	return err
+}
+
+// Stream send events of todos and activity groups as server-sent events until client
+// disconnect. Client resume with header Last-Event-ID, event reset is sent first when
+// some events are missed so client should get the data again
+func (h *eventHandler) Stream(c *gin.Context) {
+	var query web.EventQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindingError(c, err, &query, "activity_group_id and last_event_id must be a number")
+		return
+	}
+
+	lastEventID := query.LastEventID
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		lastEventID, err = strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			badRequestField(c, "Last-Event-ID", web.FieldInvalid, "Last-Event-ID must be a number")
+			return
+		}
+	}
+
+	stream, err := h.service.WithOwner(ownerID(c)).Subscribe(lastEventID, query.ActivityGroupID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+	defer stream.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Nginx buffer response unless it is disabled
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if stream.Missed {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		case e, ok := <-stream.Events:
+			// Closed for slow client, it resume by reconnect
+			if !ok {
+				return
+			}
+			if !stream.Visible(e) {
+				continue
+			}
+			if writeEvent(c.Writer, e) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/service"
+)
+
+// Header of idempotency key sent by client, and of replayed response
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	// maxIdempotencyKeyLength is size of column key
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize is the largest body of request with idempotency key, it is read
+	// whole for the fingerprint
+	maxIdempotentBodySize = 1 << 20
+)
+
+type idempotencyHandler struct {
+	service service.IdempotencyService
+}
+
+func NewIdempotencyHandler(service service.IdempotencyService) *idempotencyHandler {
+	return &idempotencyHandler{service}
+}
+
+// responseRecorder keep copy of body written to response
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// fingerprint is SHA-256 of method, path, header Accept and body of request. JSON body is
+// compacted, so only change of value is a different request. Response may be negotiated by
+// Accept, so the replayed response is always of the accepted type
+func fingerprint(request *http.Request, body []byte) string {
+	var compacted bytes.Buffer
+	if json.Compact(&compacted, body) == nil {
+		body = compacted.Bytes()
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n%s\n", request.Method, request.URL.Path, request.Header.Get("Accept"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Idempotent is middleware run request with header Idempotency-Key once. The response is
+// stored and replayed for the same request with the key within service.IdempotencyWindow,
+// the key with other request is an error. Request without the header is run as usual
+func (h *idempotencyHandler) Idempotent(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		badRequest(c, fmt.Sprintf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+		c.Abort()
+		return
+	}
+
+	// Body is read for fingerprint, then given back to the handler
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+	if err != nil {
+		writeError(c, http.StatusRequestEntityTooLarge, codeBodyTooLarge,
+			fmt.Sprintf("Request body with %s must be at most %d bytes", idempotencyKeyHeader, maxIdempotentBodySize), gin.H{}, nil)
+		c.Abort()
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	idempotencyKey, replay, err := h.service.Begin(ownerID(c), key, fingerprint(c.Request, body))
+	if err != nil {
+		errorResponse(c, err)
+		c.Abort()
+		return
+	}
+
+	if replay {
+		c.Header(idempotentReplayedHeader, "true")
+		c.Data(idempotencyKey.StatusCode, idempotencyKey.ContentType, idempotencyKey.Body)
+		c.Abort()
+		return
+	}
+
+	// Key is released when the handler panic, so it is not in progress until expired
+	completed := false
+	defer func() {
+		if !completed {
+			_ = h.service.Release(idempotencyKey)
+		}
+	}()
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	// Server error is not stored, so the request can be retried with the key
+	status := c.Writer.Status()
+	if status >= http.StatusInternalServerError {
+		return
+	}
+
+	// The handler has done the request, so the key is kept even when the response cannot be
+	// stored. Retry with the key is refused as in progress until the key is expired, instead
+	// of run again
+	completed = true
+	err = h.service.Complete(idempotencyKey, status, c.Writer.Header().Get("Content-Type"), recorder.body.Bytes())
+	if err != nil {
+		log.Printf("Failed to store response of idempotency key %s %v", key, err)
+	}
+}
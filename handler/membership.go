@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+)
+
+// Message for invalid value of role
+var roleErrorMessage = fmt.Sprintf("role must be one of %s", strings.Join(domain.Roles, ", "))
+
+type membershipHandler struct {
+	service service.MembershipService
+}
+
+func NewMembershipHandler(service service.MembershipService) *membershipHandler {
+	return &membershipHandler{service}
+}
+
+func (h *membershipHandler) GetAll(c *gin.Context) {
+	var id web.ActivityIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	members, err := h.service.WithOwner(ownerID(c)).GetAll(id.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatMembers(members),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *membershipHandler) Invite(c *gin.Context) {
+	var id web.ActivityIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	var req web.MemberInviteRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindingError(c, err, &req, "email and role cannot be null")
+		return
+	}
+
+	if !domain.IsValidRole(req.Role) {
+		badRequestField(c, "role", web.FieldOneOf, roleErrorMessage)
+		return
+	}
+
+	// Invite, only owner can invite and the user must be registered
+	member, err := h.service.WithOwner(ownerID(c)).Invite(id.ID, req)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatMember(member),
+	)
+	c.JSON(http.StatusCreated, jsonResponse)
+}
+
+func (h *membershipHandler) Remove(c *gin.Context) {
+	var uri web.MemberURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		bindingError(c, err, &uri, "Uri id and user_id cannot be null")
+		return
+	}
+
+	// Remove, only owner can remove other member and the last owner cannot be removed
+	_, err = h.service.WithOwner(ownerID(c)).Remove(uri.ID, uri.UserID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	resp := gin.H{}
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		resp,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
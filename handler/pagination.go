@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+)
+
+// pageError return field and message of invalid query pagination, empty if it is valid
+func pageError(query web.PageQuery, cursorSupported bool) (string, string) {
+	if query.Limit < 0 || query.Limit > web.MaxLimit {
+		return "limit", fmt.Sprintf("limit must be between 1 and %d", web.MaxLimit)
+	}
+
+	if query.Offset < 0 {
+		return "offset", "offset cannot be negative"
+	}
+
+	if query.Cursor != "" {
+		if query.Offset != 0 {
+			return "cursor", "cursor cannot be used with offset"
+		}
+
+		if !cursorSupported {
+			return "cursor", "cursor cannot be used with this sort"
+		}
+
+		_, err := repository.DecodeCursor(query.Cursor)
+		if err != nil {
+			return "cursor", err.Error()
+		}
+	}
+
+	return "", ""
+}
+
+// sortErrorMessage return message of invalid query sort
+func sortErrorMessage(sorts []string) string {
+	return fmt.Sprintf("sort must be one of %s, prefix - for descending", strings.Join(sorts, ", "))
+}
+
+// setPaginationHeader set header X-Total-Count and Link of a paginated list. Link next
+// use cursor when nextCursor is not empty, otherwise use offset
+func setPaginationHeader(c *gin.Context, query web.PageQuery, total int64, count int, nextCursor string) {
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+
+	limit := query.PageLimit()
+	var links []string
+	links = append(links, pageLink(c, "first", nil))
+
+	// The page is full, there is may be a next page
+	if count == limit {
+		if nextCursor != "" {
+			links = append(links, pageLink(c, "next", map[string]string{"cursor": nextCursor}))
+		} else if int64(query.Offset+limit) < total {
+			offset := strconv.Itoa(query.Offset + limit)
+			links = append(links, pageLink(c, "next", map[string]string{"offset": offset}))
+		}
+	}
+
+	if query.Cursor == "" && query.Offset > 0 {
+		offset := query.Offset - limit
+		if offset < 0 {
+			offset = 0
+		}
+		links = append(links, pageLink(c, "prev", map[string]string{"offset": strconv.Itoa(offset)}))
+	}
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// pageLink create link of current request with replaced page position
+func pageLink(c *gin.Context, rel string, position map[string]string) string {
+	values := c.Request.URL.Query()
+	values.Del("offset")
+	values.Del("cursor")
+	for key, value := range position {
+		values.Set(key, value)
+	}
+
+	link := url.URL{Path: c.Request.URL.Path, RawQuery: values.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", link.String(), rel)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/search"
+	"github.com/letenk/todo-list/service"
+)
+
+// Message for invalid value of type
+var searchTypeErrorMessage = fmt.Sprintf("type must be one of %s", strings.Join(search.Kinds, ", "))
+
+type searchHandler struct {
+	service service.SearchService
+}
+
+func NewSearchHandler(service service.SearchService) *searchHandler {
+	return &searchHandler{service}
+}
+
+func (h *searchHandler) Search(c *gin.Context) {
+	var query web.SearchQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindingError(c, err, &query, "q cannot be null")
+		return
+	}
+
+	if len(search.Tokenize(query.Q)) == 0 {
+		badRequestField(c, "q", web.FieldInvalid, "q must have a letter or digit")
+		return
+	}
+
+	if query.Type != "" && query.Type != search.KindTodo && query.Type != search.KindActivityGroup {
+		badRequestField(c, "type", web.FieldOneOf, searchTypeErrorMessage)
+		return
+	}
+
+	if query.Limit < 0 || query.Limit > web.MaxLimit {
+		badRequestField(c, "limit", web.FieldInvalid, fmt.Sprintf("limit must be between 1 and %d", web.MaxLimit))
+		return
+	}
+
+	results, err := h.service.WithOwner(ownerID(c)).Search(query)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatSearchResults(results),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
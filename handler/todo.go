@@ -1,14 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jellydator/ttlcache/v2"
+	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
 	"github.com/letenk/todo-list/service"
 )
 
@@ -21,110 +22,62 @@ func NewTodoHandler(service service.TodoService) *todoHandler {
 }
 
 func (h *todoHandler) GetAll(c *gin.Context) {
-	todoID, _ := strconv.Atoi(c.Query("activity_group_id"))
-	key := "todos"
-	keyTodoQuerySearch := "todo-search"
-	keyTodoID := "todo-id"
-
-	if todoID != 0 {
-		cache.Remove(key)
+	var query web.TodoQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		bindingError(c, err, &query, "activity_group_id must be a number, is_active must be true or false, due_before, due_after, created_before and created_after must be RFC3339 time")
+		return
+	}
 
-		todosIdCache, _ := cache.Get(keyTodoID)
-		if todosIdCache != todoID {
-			cache.Remove(keyTodoQuerySearch)
+	if query.Filter != "" {
+		_, err := repository.ParseTodoFilter(query.Filter)
+		if err != nil {
+			badRequestField(c, "filter", web.FieldInvalid, "filter is invalid, "+err.Error())
+			return
 		}
+	}
 
-		todos, err := cache.Get(keyTodoQuerySearch)
-		if err == ttlcache.ErrNotFound {
-			cache.Remove(key)
-			cache.Remove(keyTodoQuerySearch)
-			// Get all
-			todos, err := h.service.GetAll(uint64(todoID))
-			if err != nil {
-				resp := gin.H{}
-				jsonResponse := web.JSONResponse(
-					"Internal Server Error",
-					"Internal Server Error",
-					resp,
-				)
-				c.JSON(http.StatusInternalServerError, jsonResponse)
-				return
-			}
-
-			formatResponseJSON := web.FormatTodos(todos)
-
-			if len(todos) != 0 {
-				// Cache data
-				go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-				go cache.SetWithTTL(keyTodoQuerySearch, formatResponseJSON, time.Hour)
-				go cache.SetWithTTL(keyTodoID, todoID, time.Hour)
-			} else {
-				go cache.Remove(key)
-			}
-
-			jsonResponse := web.JSONResponse(
-				"Success",
-				"Success",
-				formatResponseJSON,
-			)
-			c.JSON(http.StatusOK, jsonResponse)
+	for _, priority := range query.Priorities() {
+		if !domain.IsValidPriority(priority) {
+			badRequestField(c, "priority", web.FieldOneOf, service.PriorityErrorMessage)
 			return
 		}
-		// Get all data from cache
-		jsonResponse := web.JSONResponse(
-			"Success",
-			"Success",
-			todos,
-		)
-		c.JSON(http.StatusOK, jsonResponse)
-		return
 	}
 
-	_, err := cache.Get(keyTodoQuerySearch)
-	if err != ttlcache.ErrNotFound {
-		cache.Remove(key)
-		cache.Remove(keyTodoQuerySearch)
+	if !repository.IsValidTodoSort(query.Sort) {
+		badRequestField(c, "sort", web.FieldOneOf, sortErrorMessage(repository.TodoSorts))
+		return
 	}
 
-	// Get data from cache
-	todos, err := cache.Get(key)
-	if err == ttlcache.ErrNotFound {
-		// Get all
-		todos, err := h.service.GetAll(uint64(todoID))
-		if err != nil {
-			resp := gin.H{}
-			jsonResponse := web.JSONResponse(
-				"Internal Server Error",
-				"Internal Server Error",
-				resp,
-			)
-			c.JSON(http.StatusInternalServerError, jsonResponse)
-			return
-		}
-
-		formatResponseJSON := web.FormatTodos(todos)
+	field, message := pageError(query.PageQuery, repository.IsCursorTodoSort(query.Sort))
+	if message != "" {
+		badRequestField(c, field, web.FieldInvalid, message)
+		return
+	}
 
-		if len(todos) != 0 {
-			// Cache data
-			go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-		} else {
-			go cache.Remove(key)
-		}
+	todos, err := h.service.WithOwner(ownerID(c)).GetAll(query)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
 
-		jsonResponse := web.JSONResponse(
-			"Success",
-			"Success",
-			formatResponseJSON,
-		)
-		c.JSON(http.StatusOK, jsonResponse)
+	// Every list is paginated, the first page when query of pagination is not sent
+	total, err := h.service.WithOwner(ownerID(c)).Count(query)
+	if err != nil {
+		errorResponse(c, err)
 		return
 	}
 
-	// Get all data from cache
+	var nextCursor string
+	if len(todos) != 0 && repository.IsCursorTodoSort(query.Sort) {
+		nextCursor = repository.EncodeCursor(repository.TodoCursor(todos[len(todos)-1], query.Sort))
+	}
+	setPaginationHeader(c, query.PageQuery, total, len(todos), nextCursor)
+
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
-		todos,
+		web.FormatTodos(todos),
 	)
 	c.JSON(http.StatusOK, jsonResponse)
 }
@@ -133,65 +86,25 @@ func (h *todoHandler) GetOne(c *gin.Context) {
 	var todoID web.TodoURI
 	err := c.ShouldBindUri(&todoID)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"Uri id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &todoID, "Uri id cannot be null")
 		return
 	}
 
-	// Get data from cache
-	key := fmt.Sprintf("todo-id-%d", todoID.ID)
-	todo, err := cache.Get(key)
-	if err == ttlcache.ErrNotFound {
-
-		// Get all
-		todo, err := h.service.GetOne(todoID.ID)
-		if err != nil {
-			resp := gin.H{}
-			jsonResponse := web.JSONResponse(
-				"Internal Server Error",
-				"Internal Server Error",
-				resp,
-			)
-			c.JSON(http.StatusInternalServerError, jsonResponse)
-			return
-		}
-
-		// If not found
-		if todo.ID == 0 {
-			resp := gin.H{}
-			message := fmt.Sprintf("Todo with ID %d Not Found", todoID.ID)
-			jsonResponse := web.JSONResponse(
-				"Not Found",
-				message,
-				resp,
-			)
-			c.JSON(http.StatusNotFound, jsonResponse)
-			return
-		}
-
-		formatResponseJSON := web.FormatTodo(todo)
-		// Cache data
-		go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
+	// Find by id
+	todo, err := h.service.WithOwner(ownerID(c)).GetOne(todoID.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
 
-		jsonResponse := web.JSONResponse(
-			"Success",
-			"Success",
-			formatResponseJSON,
-		)
-		c.JSON(http.StatusOK, jsonResponse)
+	if notModified(c, todo.Version) {
 		return
 	}
 
-	// Get one data from cache
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
-		todo,
+		web.FormatTodo(todo),
 	)
 	c.JSON(http.StatusOK, jsonResponse)
 }
@@ -199,60 +112,30 @@ func (h *todoHandler) GetOne(c *gin.Context) {
 func (h *todoHandler) Create(c *gin.Context) {
 	var req web.TodoCreateRequest
 	err := c.ShouldBindJSON(&req)
+	// Title and activity_group_id is required by binding, so err has error of both fields
 	if req.Title == "" {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"title cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &req, "title cannot be null")
 		return
 	}
 
 	if req.ActivityGroupID == 0 {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"activity_group_id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &req, "activity_group_id cannot be null")
 		return
 	}
 
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"title, activity_group_id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &req, "title, activity_group_id cannot be null")
 		return
 	}
 
-	// Create
-	newTodo, err := h.service.Create(req)
+	// Create, activity group not found is an error
+	newTodo, err := h.service.WithOwner(ownerID(c)).Create(req)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			"Internal Server Error",
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
 
 	formatResponseJSON := web.FormatCreatedTodo(newTodo)
-	// Cache
-	if newTodo.ID != 0 {
-		key := fmt.Sprintf("todo-id-%d", newTodo.ID)
-		go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-		go cache.Remove("todos")
-	}
-
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
@@ -265,81 +148,43 @@ func (h *todoHandler) Update(c *gin.Context) {
 	var todoURI web.TodoURI
 	err := c.ShouldBindUri(&todoURI)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"Uri id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &todoURI, "Uri id cannot be null")
 		return
 	}
 
 	var req web.TodoUpdateRequest
 	err = c.ShouldBindJSON(&req)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"title or is_active cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &req, "title or is_active cannot be null")
 		return
 	}
 
 	// Get one by id
-	todo, err := h.service.GetOne(todoURI.ID)
+	todo, err := h.service.WithOwner(ownerID(c)).GetOne(todoURI.ID)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
 
-	// If not found
-	if todo.ID == 0 {
-		resp := gin.H{}
-		message := fmt.Sprintf("Todo with ID %d Not Found", todoURI.ID)
-		jsonResponse := web.JSONResponse(
-			"Not Found",
-			message,
-			resp,
-		)
-		c.JSON(http.StatusNotFound, jsonResponse)
+	// Update only the version of If-Match when it is sent
+	req.Version, err = ifMatch(c, "Todo", todo.ID, func() (uint64, error) {
+		return todo.Version, nil
+	})
+	if err != nil {
+		errorResponse(c, err)
 		return
 	}
 
-	if req.Title != "" {
-		req.IsActive = true
-	}
-
-	// Update
-	updatedTodo, err := h.service.Update(todo.ID, req)
+	// Update, activity group not found is an error
+	updatedTodo, err := h.service.WithOwner(ownerID(c)).Update(todo.ID, req)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
 
-	formatResponseJSON := web.FormatTodo(updatedTodo)
-	// Cache
-	if updatedTodo.ID != 0 {
-		key := fmt.Sprintf("todo-id-%d", updatedTodo.ID)
-		go cache.Remove(key)
-		go cache.SetWithTTL(key, formatResponseJSON, time.Hour)
-		go cache.Remove("todos")
-	}
+	c.Header("ETag", etag(updatedTodo.Version))
 
+	formatResponseJSON := web.FormatTodo(updatedTodo)
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
@@ -352,63 +197,129 @@ func (h *todoHandler) Delete(c *gin.Context) {
 	var todoURI web.ActivityIdURI
 	err := c.ShouldBindUri(&todoURI)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Bad Request",
-			"Uri id cannot be null",
-			resp,
-		)
-		c.JSON(http.StatusBadRequest, jsonResponse)
+		bindingError(c, err, &todoURI, "Uri id cannot be null")
 		return
 	}
 
-	// Find by id
-	todo, err := h.service.GetOne(todoURI.ID)
+	// Delete only the version of If-Match when it is sent
+	version, err := ifMatch(c, "Todo", todoURI.ID, func() (uint64, error) {
+		todo, err := h.service.WithOwner(ownerID(c)).GetOne(todoURI.ID)
+		return todo.Version, err
+	})
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		errorResponse(c, err)
 		return
 	}
 
-	// If not found
-	if todo.ID == 0 {
-		resp := gin.H{}
-		message := fmt.Sprintf("Todo with ID %d Not Found", todoURI.ID)
-		jsonResponse := web.JSONResponse(
-			"Not Found",
-			message,
-			resp,
-		)
-		c.JSON(http.StatusNotFound, jsonResponse)
+	// Delete
+	_, err = h.service.WithOwner(ownerID(c)).Delete(todoURI.ID, version)
+	if err != nil {
+		errorResponse(c, err)
 		return
 	}
 
-	// Delete
-	ok, err := h.service.Delete(todo.ID)
+	resp := gin.H{}
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		resp,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *todoHandler) Restore(c *gin.Context) {
+	var todoURI web.TodoURI
+	err := c.ShouldBindUri(&todoURI)
 	if err != nil {
-		resp := gin.H{}
-		jsonResponse := web.JSONResponse(
-			"Internal Server Error",
-			err.Error(),
-			resp,
-		)
-		c.JSON(http.StatusInternalServerError, jsonResponse)
+		bindingError(c, err, &todoURI, "Uri id cannot be null")
 		return
 	}
 
-	// Cache and remove
-	if ok {
-		key := fmt.Sprintf("todo-id-%d", todo.ID)
-		go cache.Remove(key)
-		go cache.Remove("activities")
+	// Restore, todo must be in trash and its activity group is not deleted
+	restoredTodo, err := h.service.WithOwner(ownerID(c)).Restore(todoURI.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	formatResponseJSON := web.FormatTodo(restoredTodo)
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		formatResponseJSON,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+// Message for invalid value of action of bulk
+var bulkActionErrorMessage = fmt.Sprintf("action must be one of %s", strings.Join(domain.BulkActions, ", "))
+
+func (h *todoHandler) Bulk(c *gin.Context) {
+	var req web.TodoBulkRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		bindingError(c, err, &req, "actions cannot be null")
+		return
+	}
+
+	if len(req.Actions) == 0 || len(req.Actions) > web.MaxBulkActions {
+		badRequestField(c, "actions", web.FieldInvalid, fmt.Sprintf("actions must have between 1 and %d action", web.MaxBulkActions))
+		return
+	}
+
+	for i, action := range req.Actions {
+		field := fmt.Sprintf("actions[%d]", i)
+		switch {
+		case action.Action != domain.BulkActionUpdate && action.Action != domain.BulkActionDelete:
+			badRequestField(c, field+".action", web.FieldOneOf, bulkActionErrorMessage)
+			return
+		case action.ID == 0:
+			badRequestField(c, field+".id", web.FieldRequired, field+".id cannot be null")
+			return
+		case action.Action == domain.BulkActionUpdate && action.IsActive == nil && action.Priority == "" && action.ActivityGroupID == 0:
+			badRequestField(c, field, web.FieldRequired, field+" must change is_active, priority or activity_group_id")
+			return
+		}
+	}
+
+	results, err := h.service.WithOwner(ownerID(c)).Bulk(req)
+	var bulkErr *service.BulkError
+	if err != nil && !errors.As(err, &bulkErr) {
+		errorResponse(c, err)
+		return
+	}
+
+	resp := web.TodoBulkResponse{Results: []web.TodoBulkResultResponse{}}
+	for i, result := range results {
+		item := web.TodoBulkResultResponse{Index: i, ID: result.ID, Action: result.Action, Status: http.StatusOK}
+		switch {
+		case errors.Is(result.Err, service.ErrBulkNotApplied):
+			item.Status = http.StatusFailedDependency
+			item.Code = "not_applied"
+			item.Message = fmt.Sprintf("Action is not applied, action %d failed", bulkErr.Index)
+		case result.Err != nil:
+			kind, message := describeError(c, result.Err)
+			item.Status, item.Code, item.Message = kind.status, kind.code, message
+		case result.Action == domain.BulkActionUpdate:
+			todo := web.FormatTodo(result.Todo)
+			item.Data = &todo
+		}
+
+		if result.Err == nil {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+
+	// Atomic bulk is responded with status of the failed action
+	if bulkErr != nil {
+		kind, message := describeError(c, bulkErr.Err)
+		writeError(c, kind.status, kind.code, fmt.Sprintf("Action %d failed, no action is applied: %s", bulkErr.Index, message), resp, nil)
+		return
 	}
 
-	resp := gin.H{}
 	jsonResponse := web.JSONResponse(
 		"Success",
 		"Success",
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+)
+
+type trashHandler struct {
+	activityService service.ActivityService
+	todoService     service.TodoService
+}
+
+func NewTrashHandler(activityService service.ActivityService, todoService service.TodoService) *trashHandler {
+	return &trashHandler{activityService, todoService}
+}
+
+func (h *trashHandler) GetAll(c *gin.Context) {
+	// Get all deleted activity groups
+	activities, err := h.activityService.WithOwner(ownerID(c)).GetTrashed()
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	// Get all deleted todos
+	todos, err := h.todoService.WithOwner(ownerID(c)).GetTrashed()
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatTrash(activities, todos),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *trashHandler) Purge(c *gin.Context) {
+	// Delete permanently todos first, they may belong to deleted activity groups
+	todos, err := h.todoService.WithOwner(ownerID(c)).PurgeTrashed()
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	activities, err := h.activityService.WithOwner(ownerID(c)).PurgeTrashed()
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.PurgeResponse{ActivityGroups: activities, TodoItems: todos},
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *trashHandler) PurgeTodo(c *gin.Context) {
+	var todoURI web.TodoURI
+	err := c.ShouldBindUri(&todoURI)
+	if err != nil {
+		bindingError(c, err, &todoURI, "Uri id cannot be null")
+		return
+	}
+
+	// Delete permanently, todo must be in trash
+	_, err = h.todoService.WithOwner(ownerID(c)).Purge(todoURI.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	resp := gin.H{}
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		resp,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *trashHandler) PurgeActivity(c *gin.Context) {
+	var id web.ActivityIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	// Delete permanently, activity group must be in trash
+	_, err = h.activityService.WithOwner(ownerID(c)).Purge(id.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	resp := gin.H{}
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		resp,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
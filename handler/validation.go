@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/letenk/todo-list/models/web"
+)
+
+// Code of FieldError for tag of validator
+var validatorCodes = map[string]string{
+	"required": web.FieldRequired,
+	"oneof":    web.FieldOneOf,
+}
+
+// SetupValidator name field of validation error by tag json, form or uri, so the error
+// is matched to the field sent by client
+func SetupValidator() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			name := strings.Split(field.Tag.Get(tag), ",")[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// fieldError create error of field with message of code
+func fieldError(field string, code string) web.FieldError {
+	var message string
+	switch code {
+	case web.FieldRequired:
+		message = fmt.Sprintf("%s cannot be null", field)
+	case web.FieldInvalidType:
+		message = fmt.Sprintf("%s has invalid type", field)
+	default:
+		message = fmt.Sprintf("%s is invalid", field)
+	}
+
+	return web.FieldError{Field: field, Code: code, Message: message}
+}
+
+// bindingFieldErrors return error of each field from failed binding of obj. Parsing error of
+// query string and uri has no field, so each value is bound alone to find the invalid one
+func bindingFieldErrors(c *gin.Context, obj interface{}, err error) []web.FieldError {
+	var fieldErrors []web.FieldError
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, validationError := range validationErrors {
+			code, ok := validatorCodes[validationError.Tag()]
+			if !ok {
+				code = validationError.Tag()
+			}
+			fieldErrors = append(fieldErrors, fieldError(validationError.Field(), code))
+		}
+		return fieldErrors
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) && typeError.Field != "" {
+		return append(fieldErrors, fieldError(typeError.Field, web.FieldInvalidType))
+	}
+
+	params := map[string][]string{}
+	for _, param := range c.Params {
+		params[param.Key] = []string{param.Value}
+	}
+
+	fieldErrors = append(fieldErrors, invalidFields(obj, c.Request.URL.Query(), "form")...)
+	fieldErrors = append(fieldErrors, invalidFields(obj, params, "uri")...)
+	return fieldErrors
+}
+
+// invalidFields bind each value alone to a new obj, and return error of value cannot be bound
+func invalidFields(obj interface{}, values map[string][]string, tag string) []web.FieldError {
+	var fieldErrors []web.FieldError
+
+	objType := reflect.TypeOf(obj)
+	if objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+	if objType.Kind() != reflect.Struct {
+		return fieldErrors
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := binding.MapFormWithTag(reflect.New(objType).Interface(), map[string][]string{key: values[key]}, tag)
+		if err != nil {
+			fieldErrors = append(fieldErrors, fieldError(key, web.FieldInvalid))
+		}
+	}
+
+	return fieldErrors
+}
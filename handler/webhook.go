@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+)
+
+// Message for invalid value of event types and status of delivery
+var (
+	eventTypeErrorMessage      = fmt.Sprintf("event_types must be one of %s", strings.Join(event.Types, ", "))
+	deliveryStatusErrorMessage = fmt.Sprintf("status must be one of %s", strings.Join(domain.DeliveryStatuses, ", "))
+)
+
+// Message for url of webhook is not http or https
+const webhookURLErrorMessage = "url must be http or https URL"
+
+type webhookHandler struct {
+	service service.WebhookService
+}
+
+func NewWebhookHandler(service service.WebhookService) *webhookHandler {
+	return &webhookHandler{service}
+}
+
+// checkWebhook response bad request when url or event types of webhook is invalid
+func checkWebhook(c *gin.Context, webhookURL string, eventTypes []string) bool {
+	if webhookURL != "" {
+		parsed, err := url.Parse(webhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			badRequestField(c, "url", web.FieldInvalid, webhookURLErrorMessage)
+			return false
+		}
+	}
+
+	for _, eventType := range eventTypes {
+		if !event.IsValidType(eventType) {
+			badRequestField(c, "event_types", web.FieldOneOf, eventTypeErrorMessage)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *webhookHandler) GetAll(c *gin.Context) {
+	webhooks, err := h.service.GetAll(ownerID(c))
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatWebhooks(webhooks),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *webhookHandler) GetOne(c *gin.Context) {
+	var id web.WebhookIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	// Find by id, webhook of other user is not found
+	webhook, err := h.service.GetOne(ownerID(c), id.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatWebhook(webhook),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *webhookHandler) Create(c *gin.Context) {
+	var req web.WebhookRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		bindingError(c, err, &req, "url and event_types cannot be null, secret must have at least 16 characters")
+		return
+	}
+
+	if !checkWebhook(c, req.URL, req.EventTypes) {
+		return
+	}
+
+	// Create, the secret is shown only in this response
+	webhook, err := h.service.Create(ownerID(c), req)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatCreatedWebhook(webhook),
+	)
+	c.JSON(http.StatusCreated, jsonResponse)
+}
+
+func (h *webhookHandler) Update(c *gin.Context) {
+	var id web.WebhookIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	var req web.WebhookUpdateRequest
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		bindingError(c, err, &req, "url must be URL, secret must have at least 16 characters")
+		return
+	}
+
+	if !checkWebhook(c, req.URL, req.EventTypes) {
+		return
+	}
+
+	webhook, err := h.service.Update(ownerID(c), id.ID, req)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatWebhook(webhook),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *webhookHandler) Delete(c *gin.Context) {
+	var id web.WebhookIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	// Delete with its deliveries, webhook of other user is not found
+	_, err = h.service.Delete(ownerID(c), id.ID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	resp := gin.H{}
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		resp,
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *webhookHandler) GetDeliveries(c *gin.Context) {
+	var id web.WebhookIdURI
+	err := c.ShouldBindUri(&id)
+	if err != nil {
+		bindingError(c, err, &id, "Uri id cannot be null")
+		return
+	}
+
+	var query web.WebhookDeliveryQuery
+	err = c.ShouldBindQuery(&query)
+	if err != nil {
+		bindingError(c, err, &query, "limit must be a number")
+		return
+	}
+
+	if query.Status != "" && !domain.IsValidDeliveryStatus(query.Status) {
+		badRequestField(c, "status", web.FieldOneOf, deliveryStatusErrorMessage)
+		return
+	}
+
+	if query.Limit < 0 || query.Limit > web.MaxLimit {
+		badRequestField(c, "limit", web.FieldInvalid, fmt.Sprintf("limit must be between 1 and %d", web.MaxLimit))
+		return
+	}
+
+	// Delivery log, status dead is the dead-letter list
+	deliveries, err := h.service.GetDeliveries(ownerID(c), id.ID, query)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatWebhookDeliveries(deliveries),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
+
+func (h *webhookHandler) Redeliver(c *gin.Context) {
+	var uri web.WebhookDeliveryURI
+	err := c.ShouldBindUri(&uri)
+	if err != nil {
+		bindingError(c, err, &uri, "Uri id and delivery_id cannot be null")
+		return
+	}
+
+	delivery, err := h.service.Redeliver(ownerID(c), uri.ID, uri.DeliveryID)
+	if err != nil {
+		errorResponse(c, err)
+		return
+	}
+
+	jsonResponse := web.JSONResponse(
+		"Success",
+		"Success",
+		web.FormatWebhookDelivery(delivery),
+	)
+	c.JSON(http.StatusOK, jsonResponse)
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+	"golang.org/x/net/websocket"
+)
+
+// websocketMaxMessage is the largest message accepted from client
+const websocketMaxMessage = 1 << 20
+
+// Message for invalid type of websocket message
+var websocketTypeErrorMessage = fmt.Sprintf("type must be one of %s", strings.Join([]string{
+	web.WebsocketSubscribe, web.WebsocketUnsubscribe, web.WebsocketTodoCreate, web.WebsocketTodoUpdate, web.WebsocketTodoDelete,
+}, ", "))
+
+// websocketBadRequest is error of invalid message, it is responded like badRequest
+type websocketBadRequest struct {
+	message     string
+	fieldErrors []web.FieldError
+}
+
+func (e *websocketBadRequest) Error() string {
+	return e.message
+}
+
+func badRequestFieldError(field string, code string, message string) error {
+	return &websocketBadRequest{message, []web.FieldError{{Field: field, Code: code, Message: message}}}
+}
+
+// errOriginNotAllowed refuse handshake of websocket from origin not allowed
+var errOriginNotAllowed = errors.New("origin is not allowed")
+
+type websocketHandler struct {
+	todoService  service.TodoService
+	eventService service.EventService
+	// allowedOrigins is origins of browser allowed to connect
+	allowedOrigins []string
+}
+
+func NewWebsocketHandler(todoService service.TodoService, eventService service.EventService, allowedOrigins []string) *websocketHandler {
+	return &websocketHandler{todoService, eventService, allowedOrigins}
+}
+
+// checkOrigin refuse origin not in allowed origins. Token can be kept by browser in URL,
+// so page of other site cannot connect with it. Client without Origin is not a browser
+func (h *websocketHandler) checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil {
+		return nil
+	}
+
+	value := strings.ToLower(origin.Scheme + "://" + origin.Host)
+	for _, allowed := range h.allowedOrigins {
+		if strings.ToLower(allowed) == value {
+			return nil
+		}
+	}
+	return errOriginNotAllowed
+}
+
+// Connect upgrade request to websocket. Client subscribe to activity groups to receive
+// their events, and send commands of todos answered by ack or error with ID of the command
+func (h *websocketHandler) Connect(c *gin.Context) {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			err := h.checkOrigin(config, req)
+			if err != nil {
+				return err
+			}
+
+			// Only subprotocol bearer is selected, the token after it is not sent back
+			for _, protocol := range config.Protocol {
+				if protocol == WebsocketTokenProtocol {
+					config.Protocol = []string{WebsocketTokenProtocol}
+					break
+				}
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = websocketMaxMessage
+			session := &websocketSession{
+				conn:         conn,
+				c:            c,
+				todoService:  h.todoService.WithOwner(ownerID(c)),
+				eventService: h.eventService.WithOwner(ownerID(c)),
+				streams:      map[uint64]*service.EventStream{},
+			}
+			defer session.close()
+			session.serve()
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// websocketSession is a connection of websocket with streams of its subscribed activity groups
+type websocketSession struct {
+	conn         *websocket.Conn
+	c            *gin.Context
+	todoService  service.TodoService
+	eventService service.EventService
+
+	// sendMutex guard conn, events and responses are sent by many goroutines
+	sendMutex sync.Mutex
+
+	mutex   sync.Mutex
+	streams map[uint64]*service.EventStream
+}
+
+func (s *websocketSession) send(resp web.WebsocketResponse) error {
+	s.sendMutex.Lock()
+	defer s.sendMutex.Unlock()
+	return websocket.JSON.Send(s.conn, resp)
+}
+
+// serve read message of client until the connection is closed
+func (s *websocketSession) serve() {
+	for {
+		var message []byte
+		err := websocket.Message.Receive(s.conn, &message)
+		if err != nil {
+			return
+		}
+
+		var req web.WebsocketRequest
+		err = json.Unmarshal(message, &req)
+		if err != nil {
+			err = &websocketBadRequest{"message must be JSON object", bindingFieldErrors(s.c, &req, err)}
+		} else {
+			var data interface{}
+			data, err = s.handle(req)
+			if err == nil {
+				err = s.send(web.WebsocketResponse{ID: req.ID, Type: web.WebsocketAck, ActivityGroupID: req.ActivityGroupID, Data: data})
+				if err != nil {
+					return
+				}
+				continue
+			}
+		}
+
+		if s.sendError(req.ID, err) != nil {
+			return
+		}
+	}
+}
+
+func (s *websocketSession) sendError(id string, err error) error {
+	resp := web.WebsocketResponse{ID: id, Type: web.WebsocketError}
+
+	var badRequest *websocketBadRequest
+	if errors.As(err, &badRequest) {
+		resp.Status, resp.Code, resp.Message, resp.Errors = http.StatusBadRequest, codeBadRequest, badRequest.message, badRequest.fieldErrors
+		return s.send(resp)
+	}
+
+	kind, message := describeError(s.c, err)
+	resp.Status, resp.Code, resp.Message = kind.status, kind.code, message
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) && appErr.Field != "" {
+		resp.Errors = []web.FieldError{{Field: appErr.Field, Code: kind.code, Message: appErr.Message}}
+	}
+	return s.send(resp)
+}
+
+// handle run command of client, and return data of its ack
+func (s *websocketSession) handle(req web.WebsocketRequest) (interface{}, error) {
+	switch req.Type {
+	case web.WebsocketSubscribe:
+		return nil, s.subscribe(req.ActivityGroupID)
+	case web.WebsocketUnsubscribe:
+		s.unsubscribe(req.ActivityGroupID)
+		return nil, nil
+	case web.WebsocketTodoCreate, web.WebsocketTodoUpdate, web.WebsocketTodoDelete:
+		if !hasScope(s.c, []string{domain.ScopeTodosWrite}) {
+			return nil, apperror.Forbidden("API key require scope %s", domain.ScopeTodosWrite).WithCode("insufficient_scope")
+		}
+	default:
+		return nil, badRequestFieldError("type", web.FieldOneOf, websocketTypeErrorMessage)
+	}
+
+	switch req.Type {
+	case web.WebsocketTodoCreate:
+		return s.createTodo(req)
+	case web.WebsocketTodoUpdate:
+		return s.updateTodo(req)
+	default:
+		return s.deleteTodo(req)
+	}
+}
+
+func (s *websocketSession) subscribe(activityGroupID uint64) error {
+	if activityGroupID == 0 {
+		return badRequestFieldError("activity_group_id", web.FieldRequired, "activity_group_id cannot be null")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.streams[activityGroupID]; ok {
+		return nil
+	}
+
+	stream, err := s.eventService.Subscribe(0, activityGroupID)
+	if err != nil {
+		return err
+	}
+	s.streams[activityGroupID] = stream
+
+	go s.forward(activityGroupID, stream)
+	return nil
+}
+
+func (s *websocketSession) unsubscribe(activityGroupID uint64) {
+	s.mutex.Lock()
+	stream, ok := s.streams[activityGroupID]
+	delete(s.streams, activityGroupID)
+	s.mutex.Unlock()
+
+	if ok {
+		stream.Close()
+	}
+}
+
+// forward send events of stream until it is closed. Stream closed for slow client is
+// sent as reset, client subscribe again and get the data again
+func (s *websocketSession) forward(activityGroupID uint64, stream *service.EventStream) {
+	for e := range stream.Events {
+		if !stream.Visible(e) {
+			continue
+		}
+		event := web.FormatEvent(e)
+		if s.send(web.WebsocketResponse{Type: web.WebsocketEvent, ActivityGroupID: activityGroupID, Data: event}) != nil {
+			return
+		}
+	}
+
+	s.mutex.Lock()
+	dropped := s.streams[activityGroupID] == stream
+	if dropped {
+		delete(s.streams, activityGroupID)
+	}
+	s.mutex.Unlock()
+
+	if dropped {
+		s.send(web.WebsocketResponse{Type: web.WebsocketReset, ActivityGroupID: activityGroupID})
+	}
+}
+
+// close stop streams of every subscribed activity group
+func (s *websocketSession) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for activityGroupID, stream := range s.streams {
+		stream.Close()
+		delete(s.streams, activityGroupID)
+	}
+}
+
+// bindData decode data of message to req and validate it like ShouldBindJSON
+func bindData(c *gin.Context, data json.RawMessage, req interface{}, message string) error {
+	if len(data) == 0 {
+		return badRequestFieldError("data", web.FieldRequired, "data cannot be null")
+	}
+
+	err := json.Unmarshal(data, req)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(req)
+	}
+	if err != nil {
+		return &websocketBadRequest{message, bindingFieldErrors(c, req, err)}
+	}
+	return nil
+}
+
+func (s *websocketSession) createTodo(req web.WebsocketRequest) (interface{}, error) {
+	var data web.TodoCreateRequest
+	err := bindData(s.c, req.Data, &data, "title, activity_group_id cannot be null")
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.todoService.Create(data)
+	if err != nil {
+		return nil, err
+	}
+	return web.FormatTodo(todo), nil
+}
+
+func (s *websocketSession) updateTodo(req web.WebsocketRequest) (interface{}, error) {
+	if req.TodoID == 0 {
+		return nil, badRequestFieldError("todo_id", web.FieldRequired, "todo_id cannot be null")
+	}
+
+	var data web.TodoUpdateRequest
+	err := bindData(s.c, req.Data, &data, "title or is_active cannot be null")
+	if err != nil {
+		return nil, err
+	}
+
+	// Version of message is checked like If-Match
+	data.Version = req.Version
+
+	updatedTodo, err := s.todoService.Update(req.TodoID, data)
+	if err != nil {
+		return nil, err
+	}
+	return web.FormatTodo(updatedTodo), nil
+}
+
+func (s *websocketSession) deleteTodo(req web.WebsocketRequest) (interface{}, error) {
+	if req.TodoID == 0 {
+		return nil, badRequestFieldError("todo_id", web.FieldRequired, "todo_id cannot be null")
+	}
+
+	_, err := s.todoService.Delete(req.TodoID, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -1,15 +1,115 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"os"
 
 	"github.com/letenk/todo-list/config"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/migration"
+	"github.com/letenk/todo-list/repository"
 	"github.com/letenk/todo-list/router"
+	"github.com/letenk/todo-list/service"
 )
 
 func main() {
+	// Command migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
 	fmt.Println("App is starting...")
 	db := config.SetupDB()
-	router := router.SetupRouter(db)
+	if db == nil {
+		log.Fatalln("Failed to connect to database")
+	}
+
+	// Warn when schema is not up to date
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Failed to read migrations %v", err)
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		log.Fatalf("Failed to read migrations %v", err)
+	}
+	if len(pending) != 0 {
+		log.Printf("There are %d pending migrations, run command: migrate up", len(pending))
+	}
+
+	repositories := repository.NewRepositories(db)
+	bus := event.NewBusMemory(event.DefaultKept)
+
+	// Post events to webhooks in background
+	dispatcher := service.NewServiceWebhookDispatcher(repositories.Webhook, repositories.WebhookDelivery,
+		repositories.Activity, service.WebhookBackoff, service.WebhookMaxAttempts)
+	go dispatcher.Run(context.Background())
+
+	// Relay events committed to outbox to subscribers, webhooks and log
+	relay := service.NewServiceOutboxRelay(repositories.Outbox, service.OutboxBackoff, service.OutboxMaxAttempts, service.NewBusSink(bus), dispatcher, service.NewLogSink())
+	go relay.Run(context.Background())
+
+	router := router.SetupRouter(repositories, config.SetupCache(), config.SetupToken(), config.SetupSearch(db), bus, config.SetupWebsocketOrigins())
 	router.Run(":3030")
 }
+
+func migrate(args []string) {
+	if len(args) != 1 {
+		log.Fatalln("Usage: migrate up|down|status")
+	}
+
+	db := config.SetupDB()
+	if db == nil {
+		log.Fatalln("Failed to connect to database")
+	}
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Failed to read migrations %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("Applied %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migration")
+		}
+	case "down":
+		m, err := migrator.Down()
+		if errors.Is(err, migration.ErrNoMigration) {
+			fmt.Println("No migration to roll back")
+			return
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Printf("Rolled back %d_%s\n", m.Version, m.Name)
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.Statements != 0 {
+				appliedAt = fmt.Sprintf("pending, failed after %d statements", s.Statements)
+			}
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%d_%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		log.Fatalln("Usage: migrate up|down|status")
+	}
+}
@@ -0,0 +1,131 @@
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql
+var files embed.FS
+
+// ErrNoMigration returned by Down when there is no applied migration
+var ErrNoMigration = errors.New("no migration is applied")
+
+// Migration is a version of schema, Up apply it and Down roll it back
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration with the time it is applied, nil if it is pending
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	// Statements is how many statements of Up are applied of pending migration failed
+	// halfway, Up resume it from the next one
+	Statements int
+}
+
+// schemaMigration is row of table schema_migrations, one for each applied migration or
+// migration failed halfway
+type schemaMigration struct {
+	Version   uint64 `gorm:"primary_key;autoIncrement:false"`
+	Name      string `gorm:"type:varchar(191);not null"`
+	AppliedAt time.Time
+	// Statements is how many statements of Up are kept applied after the migration failed,
+	// 0 is applied completely. Only MySQL keep them, it commit DDL implicitly
+	Statements int `gorm:"not null;default:0"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load read migrations of dialect from files sql/<dialect>/<version>_<name>.up.sql
+// and .down.sql, ordered by version
+func Load(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrations of %s not found", dialect)
+	}
+
+	migrations := map[uint64]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		direction := path.Ext(strings.TrimSuffix(name, ".sql"))
+		if !strings.HasSuffix(name, ".sql") || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("migration file %s must end with .up.sql or .down.sql", name)
+		}
+
+		base := strings.TrimSuffix(name, direction+".sql")
+		versionName := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseUint(versionName[0], 10, 64)
+		if err != nil || len(versionName) != 2 {
+			return nil, fmt.Errorf("migration file %s must start with <version>_<name>", name)
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrations[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: versionName[1]}
+			migrations[version] = migration
+		}
+		if migration.Name != versionName[1] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, migration.Name, versionName[1])
+		}
+
+		if direction == ".up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var result []Migration
+	for _, migration := range migrations {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down file", migration.Version, migration.Name)
+		}
+		result = append(result, *migration)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// statements split sql of a migration file by semicolon at the end of line,
+// comment line is removed so a file with comment only does nothing
+func statements(sql string) []string {
+	var lines []string
+	for _, line := range strings.Split(sql, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var result []string
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";\n") {
+		statement = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(statement), ";"))
+		if statement != "" {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package migration
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator create migrator with migrations for dialect of db, table
+// schema_migrations is created when it is not exist
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.AutoMigrate(&schemaMigration{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db, migrations}, nil
+}
+
+// Status list all migrations with the time they are applied
+func (m *Migrator) Status() ([]Status, error) {
+	var applied []schemaMigration
+	err := m.db.Order("version").Find(&applied).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := map[uint64]schemaMigration{}
+	for _, row := range applied {
+		rows[row.Version] = row
+	}
+
+	var result []Status
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := rows[migration.Version]; ok {
+			status.Statements = row.Statements
+			if row.Statements == 0 {
+				status.AppliedAt = &row.AppliedAt
+			}
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// Pending list migrations are not applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Migration
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			result = append(result, s.Migration)
+		}
+	}
+
+	return result, nil
+}
+
+// Up apply all pending migrations ordered by version, each one in a transaction. MySQL
+// commit DDL implicitly, so statements before the failed one are kept. They are recorded
+// after each statement, so the next Up resume the migration from the failed statement
+func (m *Migrator) Up() ([]Migration, error) {
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			continue
+		}
+
+		migration := s.Migration
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			row := schemaMigration{Version: migration.Version, Name: migration.Name}
+			ups := statements(migration.Up)
+			for i := s.Statements; i < len(ups); i++ {
+				err := tx.Exec(ups[i]).Error
+				if err != nil {
+					return err
+				}
+
+				// Progress is rolled back with the statements, unless MySQL committed them
+				if i+1 < len(ups) {
+					row.Statements = i + 1
+					row.AppliedAt = time.Now()
+					err = tx.Save(&row).Error
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			row.Statements = 0
+			row.AppliedAt = time.Now()
+			return tx.Save(&row).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
+
+// Down roll back the latest applied migration
+func (m *Migrator) Down() (Migration, error) {
+	var latest schemaMigration
+	err := m.db.Order("version DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return Migration{}, err
+	}
+
+	if latest.Version == 0 {
+		return Migration{}, ErrNoMigration
+	}
+	if latest.Statements != 0 {
+		return Migration{}, fmt.Errorf("migration %d_%s failed halfway, run up to finish it before rolling it back", latest.Version, latest.Name)
+	}
+
+	var migration Migration
+	for _, item := range m.migrations {
+		if item.Version == latest.Version {
+			migration = item
+		}
+	}
+
+	if migration.Version == 0 {
+		return migration, fmt.Errorf("migration %d_%s is applied but its file is not found", latest.Version, latest.Name)
+	}
+
+	err = m.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements(migration.Down) {
+			err := tx.Exec(statement).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return migration, fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	return migration, nil
+}
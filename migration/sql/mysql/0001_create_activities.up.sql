@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `activities` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `email` varchar(191) NOT NULL,
+  `title` varchar(191) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_activities_email` (`email`),
+  INDEX `idx_activities_deleted_at` (`deleted_at`)
+);
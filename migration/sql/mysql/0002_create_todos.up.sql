@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `todos` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `activity_group_id` bigint unsigned NOT NULL,
+  `title` varchar(191) NOT NULL,
+  `is_active` boolean NOT NULL DEFAULT true,
+  `priority` enum('very-high', 'high', 'medium', 'low', 'very-low') NOT NULL DEFAULT 'very-high',
+  `start_at` datetime(3) NULL DEFAULT NULL,
+  `due_at` datetime(3) NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_todos_due_at` (`due_at`),
+  INDEX `idx_todos_deleted_at` (`deleted_at`),
+  CONSTRAINT `fk_activities_todos` FOREIGN KEY (`activity_group_id`) REFERENCES `activities` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
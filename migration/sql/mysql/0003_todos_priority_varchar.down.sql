@@ -0,0 +1,2 @@
+ALTER TABLE `todos` DROP CHECK `chk_todos_priority`;
+ALTER TABLE `todos` MODIFY `priority` enum('very-high', 'high', 'medium', 'low', 'very-low') NOT NULL DEFAULT 'very-high';
@@ -0,0 +1,2 @@
+ALTER TABLE `todos` MODIFY `priority` varchar(20) NOT NULL DEFAULT 'very-high';
+ALTER TABLE `todos` ADD CONSTRAINT `chk_todos_priority` CHECK (`priority` IN ('very-high', 'high', 'medium', 'low', 'very-low'));
@@ -0,0 +1,4 @@
+ALTER TABLE `activities` DROP FOREIGN KEY `fk_users_activities`;
+ALTER TABLE `activities` DROP INDEX `idx_activities_user_id`;
+ALTER TABLE `activities` DROP COLUMN `user_id`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `email` varchar(191) NOT NULL,
+  `name` varchar(191) NOT NULL,
+  `password_hash` varchar(255) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_users_email` (`email`)
+);
+ALTER TABLE `activities` ADD COLUMN `user_id` bigint unsigned NULL DEFAULT NULL;
+ALTER TABLE `activities` ADD INDEX `idx_activities_user_id` (`user_id`);
+ALTER TABLE `activities` ADD CONSTRAINT `fk_users_activities` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE;
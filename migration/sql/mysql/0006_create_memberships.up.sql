@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `memberships` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `activity_group_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `role` varchar(20) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_memberships_activity_user` (`activity_group_id`, `user_id`),
+  INDEX `idx_memberships_user_id` (`user_id`),
+  CONSTRAINT `fk_activities_members` FOREIGN KEY (`activity_group_id`) REFERENCES `activities` (`id`) ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT `fk_memberships_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT `chk_memberships_role` CHECK (`role` IN ('viewer', 'editor', 'owner'))
+);
+-- Creator of activity group is its first owner
+INSERT INTO `memberships` (`activity_group_id`, `user_id`, `role`, `created_at`, `updated_at`)
+SELECT `id`, `user_id`, 'owner', `created_at`, `updated_at` FROM `activities` WHERE `user_id` IS NOT NULL;
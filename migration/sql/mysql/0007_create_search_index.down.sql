@@ -0,0 +1,2 @@
+ALTER TABLE `activities` DROP INDEX `ft_activities_title`;
+ALTER TABLE `todos` DROP INDEX `ft_todos_title`;
@@ -0,0 +1,2 @@
+ALTER TABLE `todos` ADD FULLTEXT INDEX `ft_todos_title` (`title`);
+ALTER TABLE `activities` ADD FULLTEXT INDEX `ft_activities_title` (`title`);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `key` varchar(255) NOT NULL,
+  `fingerprint` varchar(64) NOT NULL,
+  `status_code` bigint NOT NULL DEFAULT 0,
+  `content_type` varchar(255) NOT NULL DEFAULT '',
+  `body` longblob NULL DEFAULT NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_idempotency_keys_user_key` (`user_id`, `key`),
+  INDEX `idx_idempotency_keys_expires_at` (`expires_at`),
+  CONSTRAINT `fk_users_idempotency_keys` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS `webhooks` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `url` varchar(2048) NOT NULL,
+  `secret` varchar(255) NOT NULL,
+  `event_types` varchar(255) NOT NULL,
+  `activity_group_id` bigint unsigned NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_webhooks_user_id` (`user_id`),
+  CONSTRAINT `fk_users_webhooks` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `webhook_id` bigint unsigned NOT NULL,
+  `event_id` bigint unsigned NOT NULL,
+  `event_type` varchar(64) NOT NULL,
+  `payload` longblob NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime(3) NOT NULL,
+  `last_status_code` bigint NOT NULL DEFAULT 0,
+  `last_error` varchar(1024) NOT NULL DEFAULT '',
+  `delivered_at` datetime(3) NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_webhook_deliveries_webhook_event` (`webhook_id`, `event_id`),
+  INDEX `idx_webhook_deliveries_due` (`status`, `next_attempt_at`),
+  CONSTRAINT `fk_webhooks_deliveries` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
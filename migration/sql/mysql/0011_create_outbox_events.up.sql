@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `aggregate_type` varchar(32) NOT NULL,
+  `aggregate_id` bigint unsigned NOT NULL,
+  `event_type` varchar(64) NOT NULL,
+  `activity_group_id` bigint unsigned NOT NULL,
+  `payload` longblob NOT NULL,
+  `status` varchar(20) NOT NULL DEFAULT 'pending',
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `sent_sinks` bigint NOT NULL DEFAULT 0,
+  `last_error` varchar(1024) NOT NULL DEFAULT '',
+  `next_attempt_at` datetime(3) NULL DEFAULT NULL,
+  `published_at` datetime(3) NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  INDEX `idx_outbox_events_aggregate` (`aggregate_type`, `aggregate_id`, `status`),
+  INDEX `idx_outbox_events_published_at` (`published_at`)
+);
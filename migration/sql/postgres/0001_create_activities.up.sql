@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "activities" (
+  "id" bigserial PRIMARY KEY,
+  "email" varchar(191) NOT NULL,
+  "title" varchar(191) NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  "deleted_at" timestamptz NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_activities_email" ON "activities" ("email");
+CREATE INDEX IF NOT EXISTS "idx_activities_deleted_at" ON "activities" ("deleted_at");
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "todos" (
+  "id" bigserial PRIMARY KEY,
+  "activity_group_id" bigint NOT NULL,
+  "title" varchar(191) NOT NULL,
+  "is_active" boolean NOT NULL DEFAULT true,
+  "priority" varchar(20) NOT NULL DEFAULT 'very-high',
+  "start_at" timestamptz NULL DEFAULT NULL,
+  "due_at" timestamptz NULL DEFAULT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  "deleted_at" timestamptz NULL,
+  CONSTRAINT "fk_activities_todos" FOREIGN KEY ("activity_group_id") REFERENCES "activities" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_todos_due_at" ON "todos" ("due_at");
+CREATE INDEX IF NOT EXISTS "idx_todos_deleted_at" ON "todos" ("deleted_at");
@@ -0,0 +1 @@
+ALTER TABLE "todos" DROP CONSTRAINT IF EXISTS "chk_todos_priority";
@@ -0,0 +1 @@
+ALTER TABLE "todos" ADD CONSTRAINT "chk_todos_priority" CHECK ("priority" IN ('very-high', 'high', 'medium', 'low', 'very-low'));
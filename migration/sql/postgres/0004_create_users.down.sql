@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_activities_user_id";
+ALTER TABLE "activities" DROP CONSTRAINT IF EXISTS "fk_users_activities";
+ALTER TABLE "activities" DROP COLUMN IF EXISTS "user_id";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" bigserial PRIMARY KEY,
+  "email" varchar(191) NOT NULL,
+  "name" varchar(191) NOT NULL,
+  "password_hash" varchar(255) NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+ALTER TABLE "activities" ADD COLUMN "user_id" bigint NULL DEFAULT NULL;
+ALTER TABLE "activities" ADD CONSTRAINT "fk_users_activities" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS "idx_activities_user_id" ON "activities" ("user_id");
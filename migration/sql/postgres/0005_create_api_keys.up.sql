@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "api_keys" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "name" varchar(191) NOT NULL,
+  "prefix" varchar(20) NOT NULL,
+  "key_hash" varchar(64) NOT NULL,
+  "scopes" varchar(255) NOT NULL,
+  "last_used_at" timestamptz NULL DEFAULT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  CONSTRAINT "fk_users_api_keys" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+CREATE INDEX IF NOT EXISTS "idx_api_keys_user_id" ON "api_keys" ("user_id");
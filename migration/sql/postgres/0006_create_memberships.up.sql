@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS "memberships" (
+  "id" bigserial PRIMARY KEY,
+  "activity_group_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "role" varchar(20) NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  CONSTRAINT "fk_activities_members" FOREIGN KEY ("activity_group_id") REFERENCES "activities" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "fk_memberships_user" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT "chk_memberships_role" CHECK ("role" IN ('viewer', 'editor', 'owner'))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_memberships_activity_user" ON "memberships" ("activity_group_id", "user_id");
+CREATE INDEX IF NOT EXISTS "idx_memberships_user_id" ON "memberships" ("user_id");
+-- Creator of activity group is its first owner
+INSERT INTO "memberships" ("activity_group_id", "user_id", "role", "created_at", "updated_at")
+SELECT "id", "user_id", 'owner', "created_at", "updated_at" FROM "activities" WHERE "user_id" IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX "ft_activities_title";
+DROP INDEX "ft_todos_title";
@@ -0,0 +1,2 @@
+CREATE INDEX "ft_todos_title" ON "todos" USING GIN (to_tsvector('simple', "title"));
+CREATE INDEX "ft_activities_title" ON "activities" USING GIN (to_tsvector('simple', "title"));
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "key" varchar(255) NOT NULL,
+  "fingerprint" varchar(64) NOT NULL,
+  "status_code" bigint NOT NULL DEFAULT 0,
+  "content_type" varchar(255) NOT NULL DEFAULT '',
+  "body" bytea NULL DEFAULT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  CONSTRAINT "fk_users_idempotency_keys" FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_idempotency_keys_user_key" ON "idempotency_keys" ("user_id", "key");
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("expires_at");
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "outbox_events" (
+  "id" bigserial PRIMARY KEY,
+  "aggregate_type" varchar(32) NOT NULL,
+  "aggregate_id" bigint NOT NULL,
+  "event_type" varchar(64) NOT NULL,
+  "activity_group_id" bigint NOT NULL,
+  "payload" bytea NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'pending',
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "sent_sinks" bigint NOT NULL DEFAULT 0,
+  "last_error" varchar(1024) NOT NULL DEFAULT '',
+  "next_attempt_at" timestamptz NULL DEFAULT NULL,
+  "published_at" timestamptz NULL DEFAULT NULL,
+  "created_at" timestamptz NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_aggregate" ON "outbox_events" ("aggregate_type", "aggregate_id", "status");
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_published_at" ON "outbox_events" ("published_at");
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `activities` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `email` varchar(191) NOT NULL,
+  `title` varchar(191) NOT NULL,
+  `created_at` datetime NULL,
+  `updated_at` datetime NULL,
+  `deleted_at` datetime NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_activities_email` ON `activities` (`email`);
+CREATE INDEX IF NOT EXISTS `idx_activities_deleted_at` ON `activities` (`deleted_at`);
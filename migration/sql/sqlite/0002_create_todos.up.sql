@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `todos` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `activity_group_id` integer NOT NULL,
+  `title` varchar(191) NOT NULL,
+  `is_active` numeric NOT NULL DEFAULT true,
+  `priority` varchar(20) NOT NULL DEFAULT 'very-high',
+  `start_at` datetime NULL DEFAULT NULL,
+  `due_at` datetime NULL DEFAULT NULL,
+  `created_at` datetime NULL,
+  `updated_at` datetime NULL,
+  `deleted_at` datetime NULL,
+  CONSTRAINT `fk_activities_todos` FOREIGN KEY (`activity_group_id`) REFERENCES `activities` (`id`) ON UPDATE CASCADE ON DELETE CASCADE,
+  CONSTRAINT `chk_todos_priority` CHECK (`priority` IN ('very-high', 'high', 'medium', 'low', 'very-low'))
+);
+CREATE INDEX IF NOT EXISTS `idx_todos_due_at` ON `todos` (`due_at`);
+CREATE INDEX IF NOT EXISTS `idx_todos_deleted_at` ON `todos` (`deleted_at`);
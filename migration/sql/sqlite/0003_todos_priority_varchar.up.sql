@@ -0,0 +1 @@
+-- Sqlite cannot add constraint to a table, chk_todos_priority is created in 0002
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_activities_user_id`;
+ALTER TABLE `activities` DROP COLUMN `user_id`;
+DROP TABLE IF EXISTS `users`;
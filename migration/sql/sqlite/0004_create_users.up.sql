@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `email` varchar(191) NOT NULL,
+  `name` varchar(191) NOT NULL,
+  `password_hash` varchar(255) NOT NULL,
+  `created_at` datetime NULL,
+  `updated_at` datetime NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_email` ON `users` (`email`);
+ALTER TABLE `activities` ADD COLUMN `user_id` integer NULL DEFAULT NULL REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS `idx_activities_user_id` ON `activities` (`user_id`);
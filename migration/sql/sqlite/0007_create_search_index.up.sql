@@ -0,0 +1 @@
+-- Sqlite has no FULLTEXT index, search use the embedded index
@@ -0,0 +1,2 @@
+ALTER TABLE `activities` ADD COLUMN `version` integer NOT NULL DEFAULT 1;
+ALTER TABLE `todos` ADD COLUMN `version` integer NOT NULL DEFAULT 1;
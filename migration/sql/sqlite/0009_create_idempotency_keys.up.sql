@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `key` varchar(255) NOT NULL,
+  `fingerprint` varchar(64) NOT NULL,
+  `status_code` integer NOT NULL DEFAULT 0,
+  `content_type` varchar(255) NOT NULL DEFAULT '',
+  `body` blob NULL DEFAULT NULL,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime NULL,
+  `updated_at` datetime NULL,
+  CONSTRAINT `fk_users_idempotency_keys` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_idempotency_keys_user_key` ON `idempotency_keys` (`user_id`, `key`);
+CREATE INDEX IF NOT EXISTS `idx_idempotency_keys_expires_at` ON `idempotency_keys` (`expires_at`);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS `webhooks` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `url` varchar(2048) NOT NULL,
+  `secret` varchar(255) NOT NULL,
+  `event_types` varchar(255) NOT NULL,
+  `activity_group_id` integer NULL DEFAULT NULL,
+  `created_at` datetime NULL,
+  `updated_at` datetime NULL,
+  CONSTRAINT `fk_users_webhooks` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS `idx_webhooks_user_id` ON `webhooks` (`user_id`);
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `webhook_id` integer NOT NULL,
+  `event_id` integer NOT NULL,
+  `event_type` varchar(64) NOT NULL,
+  `payload` blob NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `attempts` integer NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime NOT NULL,
+  `last_status_code` integer NOT NULL DEFAULT 0,
+  `last_error` varchar(1024) NOT NULL DEFAULT '',
+  `delivered_at` datetime NULL DEFAULT NULL,
+  `created_at` datetime NULL,
+  `updated_at` datetime NULL,
+  CONSTRAINT `fk_webhooks_deliveries` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks` (`id`) ON UPDATE CASCADE ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_webhook_deliveries_webhook_event` ON `webhook_deliveries` (`webhook_id`, `event_id`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_due` ON `webhook_deliveries` (`status`, `next_attempt_at`);
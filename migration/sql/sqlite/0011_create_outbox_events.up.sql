@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `outbox_events` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `aggregate_type` varchar(32) NOT NULL,
+  `aggregate_id` integer NOT NULL,
+  `event_type` varchar(64) NOT NULL,
+  `activity_group_id` integer NOT NULL,
+  `payload` blob NOT NULL,
+  `status` varchar(20) NOT NULL DEFAULT 'pending',
+  `attempts` integer NOT NULL DEFAULT 0,
+  `sent_sinks` integer NOT NULL DEFAULT 0,
+  `last_error` varchar(1024) NOT NULL DEFAULT '',
+  `next_attempt_at` datetime NULL DEFAULT NULL,
+  `published_at` datetime NULL DEFAULT NULL,
+  `created_at` datetime NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_outbox_events_aggregate` ON `outbox_events` (`aggregate_type`, `aggregate_id`, `status`);
+CREATE INDEX IF NOT EXISTS `idx_outbox_events_published_at` ON `outbox_events` (`published_at`);
@@ -1,12 +1,25 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Activity struct {
-	ID        uint64     `gorm:"primary_key"`
-	Email     string     `gorm:"type:varchar(191);not null;unique"`
-	Title     string     `gorm:"type:varchar(191);not null"`
-	CreatedAt *time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time  `gorm:"autoCreateTime"`
-	DeletedAt *time.Time `gorm:"default:null"`
+	ID    uint64 `gorm:"primary_key"`
+	Email string `gorm:"type:varchar(191);not null;unique"`
+	Title string `gorm:"type:varchar(191);not null"`
+	// UserID is user created activity group, nil for activity group created without authentication.
+	// Access is given by Members, the creator is its first owner
+	UserID *uint64 `gorm:"default:null;index"`
+	// Version is incremented by every update, update of other version is refused
+	Version   uint64         `gorm:"default:1;not null"`
+	CreatedAt *time.Time     `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoCreateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	// Todos is only used for foreign key of todos, it is not loaded
+	Todos []Todo `gorm:"foreignKey:ActivityGroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	// Members is only used for foreign key of memberships, it is not loaded
+	Members []Membership `gorm:"foreignKey:ActivityGroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
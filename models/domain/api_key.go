@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes of API key
+const (
+	ScopeTodosRead   = "todos:read"
+	ScopeTodosWrite  = "todos:write"
+	ScopeGroupsAdmin = "groups:admin"
+)
+
+// APIKeyScopes list all scopes of API key
+var APIKeyScopes = []string{ScopeTodosRead, ScopeTodosWrite, ScopeGroupsAdmin}
+
+// IsValidScope check value is one of APIKeyScopes
+func IsValidScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKey struct {
+	ID     uint64 `gorm:"primary_key"`
+	UserID uint64 `gorm:"not null;index"`
+	Name   string `gorm:"type:varchar(191);not null"`
+	// Prefix is the start of the key, it is shown to user to recognize the key
+	Prefix string `gorm:"type:varchar(20);not null"`
+	// KeyHash is SHA-256 of the key, the key itself is never stored
+	KeyHash string `gorm:"type:varchar(64);not null;unique"`
+	// Scopes is separated by space
+	Scopes     string     `gorm:"type:varchar(255);not null"`
+	LastUsedAt *time.Time `gorm:"default:null"`
+	CreatedAt  *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"autoCreateTime"`
+}
+
+// ScopeList return scopes of the key
+func (k APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// HasScope check the key is given scope
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
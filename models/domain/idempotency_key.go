@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// IdempotencyKey is key of header Idempotency-Key sent by user, the response of the first
+// request is replayed for the next requests with the key until ExpiresAt
+type IdempotencyKey struct {
+	ID     uint64 `gorm:"primary_key"`
+	UserID uint64 `gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key    string `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	// Fingerprint is SHA-256 of method, path and body of the request
+	Fingerprint string `gorm:"type:varchar(64);not null"`
+	// StatusCode of the response, 0 while the first request is still in progress
+	StatusCode  int        `gorm:"not null;default:0"`
+	ContentType string     `gorm:"type:varchar(255);not null;default:''"`
+	Body        []byte     `gorm:"default:null"`
+	ExpiresAt   time.Time  `gorm:"not null;index"`
+	CreatedAt   *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"autoCreateTime"`
+}
+
+// IsCompleted check response of the first request is stored
+func (k IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != 0
+}
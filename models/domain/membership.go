@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// Role of member of activity group, ordered from the least access
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+// Roles list all roles, ordered from the least access
+var Roles = []string{RoleViewer, RoleEditor, RoleOwner}
+
+// IsValidRole check value is one of Roles
+func IsValidRole(role string) bool {
+	return roleRank(role) != 0
+}
+
+// HasRole check role give access of required role at least, owner can do anything editor can
+func HasRole(role string, required string) bool {
+	return roleRank(role) != 0 && roleRank(role) >= roleRank(required)
+}
+
+// roleRank return position of role in Roles, start from 1
+func roleRank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Membership give user access to activity group with role
+type Membership struct {
+	ID              uint64     `gorm:"primary_key"`
+	ActivityGroupID uint64     `gorm:"not null;uniqueIndex:idx_memberships_activity_user"`
+	UserID          uint64     `gorm:"not null;uniqueIndex:idx_memberships_activity_user;index"`
+	Role            string     `gorm:"type:varchar(20);not null;check:chk_memberships_role,role IN ('viewer', 'editor', 'owner')"`
+	CreatedAt       *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"autoCreateTime"`
+	// User is the member, it is loaded when members is listed
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
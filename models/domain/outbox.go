@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// Type of aggregate of outbox event, events of one aggregate are relayed in order
+const (
+	AggregateTodo          = "todo"
+	AggregateActivityGroup = "activity_group"
+	AggregateMembership    = "membership"
+)
+
+// Status of outbox event
+const (
+	OutboxPending   = "pending"
+	OutboxPublished = "published"
+	// OutboxDead is event failed every attempt, it is not relayed anymore
+	OutboxDead = "dead"
+)
+
+// OutboxEvent is event written in the same transaction as its change, it is relayed to
+// sinks after the transaction is committed
+type OutboxEvent struct {
+	ID              uint64 `gorm:"primary_key"`
+	AggregateType   string `gorm:"type:varchar(32);not null;index:idx_outbox_events_aggregate"`
+	AggregateID     uint64 `gorm:"not null;index:idx_outbox_events_aggregate"`
+	EventType       string `gorm:"type:varchar(64);not null"`
+	ActivityGroupID uint64 `gorm:"not null"`
+	// Payload is JSON of the todo, the activity group or the membership
+	Payload  []byte `gorm:"not null"`
+	Status   string `gorm:"type:varchar(20);not null;default:'pending';index:idx_outbox_events_aggregate"`
+	Attempts int    `gorm:"not null;default:0"`
+	// SentSinks is how many sinks received the event in order, retry start from the next one
+	SentSinks int    `gorm:"not null;default:0"`
+	LastError string `gorm:"type:varchar(1024);not null;default:''"`
+	// NextAttemptAt is time of the next attempt of failed event, nil is relayed right away
+	NextAttemptAt *time.Time `gorm:"default:null"`
+	// PublishedAt is set when every sink received the event
+	PublishedAt *time.Time `gorm:"default:null;index"`
+	CreatedAt   *time.Time `gorm:"autoCreateTime"`
+}
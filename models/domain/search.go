@@ -0,0 +1,13 @@
+package domain
+
+// SearchResult is todo or activity group found by search, not stored in database
+type SearchResult struct {
+	// Kind is search.KindTodo or search.KindActivityGroup
+	Kind            string
+	ID              uint64
+	ActivityGroupID uint64
+	Title           string
+	Score           float64
+	// Highlight is title escaped as HTML with matched words in <mark>
+	Highlight string
+}
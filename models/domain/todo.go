@@ -1,14 +1,67 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Priority values accepted by column priority, ordered from the most urgent
+const (
+	PriorityVeryHigh = "very-high"
+	PriorityHigh     = "high"
+	PriorityMedium   = "medium"
+	PriorityLow      = "low"
+	PriorityVeryLow  = "very-low"
+)
+
+// Priorities list all priority values, ordered from the most urgent
+var Priorities = []string{PriorityVeryHigh, PriorityHigh, PriorityMedium, PriorityLow, PriorityVeryLow}
 
 type Todo struct {
 	ID              uint64     `gorm:"primary_key"`
 	ActivityGroupID uint64     `gorm:"not null"`
 	Title           string     `gorm:"type:varchar(191);not null"`
 	IsActive        bool       `gorm:"default:true;not null"`
-	Priority        string     `gorm:"type:enum('very-high', 'high', 'medium', 'low', 'very-low');default:'very-high';not null"`
-	CreatedAt       *time.Time `gorm:"autoCreateTime"`
-	UpdatedAt       time.Time  `gorm:"autoCreateTime"`
-	DeletedAt       *time.Time `gorm:"default:null"`
+	Priority        string     `gorm:"type:varchar(20);default:'very-high';not null;check:chk_todos_priority,priority IN ('very-high', 'high', 'medium', 'low', 'very-low')"`
+	StartAt         *time.Time `gorm:"default:null"`
+	DueAt           *time.Time `gorm:"default:null;index"`
+	// Version is incremented by every update, update of other version is refused
+	Version   uint64         `gorm:"default:1;not null"`
+	CreatedAt *time.Time     `gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `gorm:"autoCreateTime"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// IsValidPriority check value is one of Priorities
+func IsValidPriority(priority string) bool {
+	for _, p := range Priorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverdue check todo is still active and the due date has passed at given time
+func (t Todo) IsOverdue(at time.Time) bool {
+	return t.IsActive && t.DueAt != nil && t.DueAt.Before(at)
+}
+
+// Action of bulk todos
+const (
+	BulkActionUpdate = "update"
+	BulkActionDelete = "delete"
+)
+
+// BulkActions list all actions of bulk todos
+var BulkActions = []string{BulkActionUpdate, BulkActionDelete}
+
+// TodoBulkResult is result of one action of bulk todos, Err is nil when it succeed
+type TodoBulkResult struct {
+	ID     uint64
+	Action string
+	// Todo is the updated todo, or the todo before it is deleted
+	Todo Todo
+	Err  error
 }
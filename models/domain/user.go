@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+type User struct {
+	ID           uint64     `gorm:"primary_key"`
+	Email        string     `gorm:"type:varchar(191);not null;unique"`
+	Name         string     `gorm:"type:varchar(191);not null"`
+	PasswordHash string     `gorm:"type:varchar(255);not null"`
+	CreatedAt    *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time  `gorm:"autoCreateTime"`
+	// Activities is only used for foreign key of activity groups, it is not loaded
+	Activities []Activity `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	// APIKeys is only used for foreign key of API keys, it is not loaded
+	APIKeys []APIKey `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	// IdempotencyKeys is only used for foreign key of idempotency keys, it is not loaded
+	IdempotencyKeys []IdempotencyKey `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	// Webhooks is only used for foreign key of webhooks, it is not loaded
+	Webhooks []Webhook `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
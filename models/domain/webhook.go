@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Status of webhook delivery
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	// DeliveryDead is delivery failed every attempt, it is in dead-letter list until redelivered
+	DeliveryDead = "dead"
+)
+
+// DeliveryStatuses list all status of webhook delivery
+var DeliveryStatuses = []string{DeliveryPending, DeliverySucceeded, DeliveryDead}
+
+// IsValidDeliveryStatus check value is one of DeliveryStatuses
+func IsValidDeliveryStatus(status string) bool {
+	for _, s := range DeliveryStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is subscription of user to events, they are posted to URL signed by Secret
+type Webhook struct {
+	ID     uint64 `gorm:"primary_key"`
+	UserID uint64 `gorm:"not null;index"`
+	URL    string `gorm:"type:varchar(2048);not null"`
+	// Secret is key of HMAC signature of deliveries, it is shown to user only when created
+	Secret string `gorm:"type:varchar(255);not null"`
+	// EventTypes is separated by space
+	EventTypes string `gorm:"type:varchar(255);not null"`
+	// ActivityGroupID only deliver events of the activity group when it is not nil
+	ActivityGroupID *uint64    `gorm:"default:null"`
+	CreatedAt       *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time  `gorm:"autoCreateTime"`
+	// Deliveries is only used for foreign key of deliveries, it is not loaded
+	Deliveries []WebhookDelivery `gorm:"foreignKey:WebhookID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// EventTypeList return event types of the webhook
+func (w Webhook) EventTypeList() []string {
+	return strings.Fields(w.EventTypes)
+}
+
+// IsSubscribed check the webhook is subscribed to event of type in activity group
+func (w Webhook) IsSubscribed(eventType string, activityGroupID uint64) bool {
+	if w.ActivityGroupID != nil && *w.ActivityGroupID != activityGroupID {
+		return false
+	}
+	for _, t := range w.EventTypeList() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is an event posted to webhook, it is retried until it succeed or it is dead.
+// Every event has one delivery for a webhook
+type WebhookDelivery struct {
+	ID        uint64 `gorm:"primary_key"`
+	WebhookID uint64 `gorm:"not null;uniqueIndex:idx_webhook_deliveries_webhook_event"`
+	EventID   uint64 `gorm:"not null;uniqueIndex:idx_webhook_deliveries_webhook_event"`
+	EventType string `gorm:"type:varchar(64);not null"`
+	// Payload is the body posted, every attempt post the same body
+	Payload  []byte `gorm:"not null"`
+	Status   string `gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due"`
+	Attempts int    `gorm:"not null;default:0"`
+	// NextAttemptAt is time of the next attempt of pending delivery
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_deliveries_due"`
+	LastStatusCode int        `gorm:"not null;default:0"`
+	LastError      string     `gorm:"type:varchar(1024);not null;default:''"`
+	DeliveredAt    *time.Time `gorm:"default:null"`
+	CreatedAt      *time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time  `gorm:"autoCreateTime"`
+}
@@ -14,8 +14,38 @@ type ActivityRequest struct {
 	Email string `json:"email" binding:"required"`
 }
 
+// ActivityQuery is query string of get all activity group
+type ActivityQuery struct {
+	Sort string `form:"sort"`
+	PageQuery
+}
+
+// IsFiltered check any query is exist
+func (q ActivityQuery) IsFiltered() bool {
+	return q.Sort != "" || q.IsPaginated()
+}
+
+// Policy for todos of deleted activity group
+const (
+	// DeletePolicyCascade delete todos with the activity group
+	DeletePolicyCascade = "cascade"
+	// DeletePolicyRestrict refuse to delete activity group still has todos
+	DeletePolicyRestrict = "restrict"
+)
+
+// ActivityDeleteQuery is query string of delete activity group, todos is moved
+// to activity group MoveTo when it is set, otherwise handled by Policy
+type ActivityDeleteQuery struct {
+	Policy string `form:"policy"`
+	MoveTo uint64 `form:"move_to"`
+	// Version is version of activity group expected by header If-Match, 0 is not checked
+	Version uint64 `form:"-"`
+}
+
 type ActivityUpdateRequest struct {
 	Title string `json:"title" binding:"required"`
+	// Version is version of activity group expected by header If-Match, 0 is not checked
+	Version uint64 `json:"-"`
 }
 
 type ActivityCreateResponse struct {
@@ -55,11 +85,27 @@ func FormatActivityGetOne(Activity domain.Activity) ActivityGetOneResponse {
 		Email:     Activity.Email,
 		CreatedAt: Activity.CreatedAt,
 		UpdatedAt: Activity.UpdatedAt,
-		DeletedAt: Activity.DeletedAt,
+		DeletedAt: deletedAt(Activity.DeletedAt),
 	}
 	return formatter
 }
 
+// Format for handle multiples get one response activity group
+func FormatActivitiesGetOne(Activity []domain.Activity) []ActivityGetOneResponse {
+	if len(Activity) == 0 {
+		return []ActivityGetOneResponse{}
+	}
+
+	var formatters []ActivityGetOneResponse
+
+	for _, data := range Activity {
+		formatter := FormatActivityGetOne(data)
+		formatters = append(formatters, formatter)
+	}
+
+	return formatters
+}
+
 // Format for handle multiples response activity group
 func FormatActivitiesGroup(Activity []domain.Activity) []ActivityCreateResponse {
 	if len(Activity) == 0 {
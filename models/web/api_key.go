@@ -0,0 +1,62 @@
+package web
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+)
+
+type APIKeyIdURI struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type APIKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+}
+
+type APIKeyResponse struct {
+	ID         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// APIKeyCreateResponse has the key, it is only shown once when it is created
+type APIKeyCreateResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// Format for handle single response API key
+func FormatAPIKey(key domain.APIKey) APIKeyResponse {
+	formatter := APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+	return formatter
+}
+
+// Format for handle response of created API key
+func FormatCreatedAPIKey(key domain.APIKey, secret string) APIKeyCreateResponse {
+	formatter := APIKeyCreateResponse{
+		APIKeyResponse: FormatAPIKey(key),
+		Key:            secret,
+	}
+	return formatter
+}
+
+// Format for handle multiples response API key
+func FormatAPIKeys(keys []domain.APIKey) []APIKeyResponse {
+	formatters := []APIKeyResponse{}
+	for _, key := range keys {
+		formatters = append(formatters, FormatAPIKey(key))
+	}
+	return formatters
+}
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// EventQuery is query string of stream of events
+type EventQuery struct {
+	ActivityGroupID uint64 `form:"activity_group_id"`
+	// LastEventID resume the stream like header Last-Event-ID, for client cannot set header
+	LastEventID uint64 `form:"last_event_id"`
+}
+
+type EventResponse struct {
+	ID              uint64      `json:"id"`
+	Type            string      `json:"type"`
+	ActivityGroupID uint64      `json:"activity_group_id"`
+	Data            interface{} `json:"data"`
+	CreatedAt       time.Time   `json:"created_at"`
+}
+
+// FormatEvent format event with its todo, activity group or member like response of get one
+func FormatEvent(e event.Event) EventResponse {
+	formatter := EventResponse{
+		ID:              e.ID,
+		Type:            e.Type,
+		ActivityGroupID: e.ActivityGroupID,
+		Data:            e.Data,
+		CreatedAt:       e.CreatedAt,
+	}
+
+	switch data := e.Data.(type) {
+	case domain.Todo:
+		formatter.Data = FormatTodo(data)
+	case domain.Activity:
+		formatter.Data = FormatActivityGetOne(data)
+	case domain.Membership:
+		formatter.Data = FormatMember(data)
+	}
+
+	return formatter
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// MemberURI is uri of a member of activity group
+type MemberURI struct {
+	ID     uint64 `uri:"id" binding:"required"`
+	UserID uint64 `uri:"user_id" binding:"required"`
+}
+
+// MemberInviteRequest invite registered user by email
+type MemberInviteRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type MemberResponse struct {
+	UserID    uint64     `json:"user_id"`
+	Email     string     `json:"email"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// Format for handle single response member of activity group
+func FormatMember(membership domain.Membership) MemberResponse {
+	formatter := MemberResponse{
+		UserID:    membership.UserID,
+		Email:     membership.User.Email,
+		Name:      membership.User.Name,
+		Role:      membership.Role,
+		CreatedAt: membership.CreatedAt,
+	}
+	return formatter
+}
+
+// Format for handle multiples response member of activity group
+func FormatMembers(memberships []domain.Membership) []MemberResponse {
+	formatters := []MemberResponse{}
+	for _, membership := range memberships {
+		formatters = append(formatters, FormatMember(membership))
+	}
+	return formatters
+}
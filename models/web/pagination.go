@@ -0,0 +1,25 @@
+package web
+
+// MaxLimit is the greatest value of query limit
+const MaxLimit = 100
+
+// PageQuery is query string of paginated list
+type PageQuery struct {
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+	Cursor string `form:"cursor"`
+}
+
+// PageLimit return limit of the page, list without query limit is paginated by MaxLimit
+// so every request read one page at most
+func (q PageQuery) PageLimit() int {
+	if q.Limit == 0 {
+		return MaxLimit
+	}
+	return q.Limit
+}
+
+// IsPaginated check any query of pagination is exist
+func (q PageQuery) IsPaginated() bool {
+	return q.Limit != 0 || q.Offset != 0 || q.Cursor != ""
+}
@@ -0,0 +1,35 @@
+package web
+
+// ProblemContentType is media type of Problem, client get it by header Accept
+const ProblemContentType = "application/problem+json"
+
+// Code of FieldError
+const (
+	FieldRequired    = "required"
+	FieldOneOf       = "one_of"
+	FieldInvalid     = "invalid"
+	FieldInvalidType = "invalid_type"
+)
+
+// FieldError is error of one field of request
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Problem is error response of RFC 7807, Code is the same as the last segment of Type
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// ProblemType return URI of type of problem code
+func ProblemType(code string) string {
+	return "/problems/" + code
+}
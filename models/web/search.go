@@ -0,0 +1,45 @@
+package web
+
+import "github.com/letenk/todo-list/models/domain"
+
+// DefaultSearchLimit is limit of search result when query limit is empty
+const DefaultSearchLimit = 20
+
+// SearchQuery is query string of search
+type SearchQuery struct {
+	Q string `form:"q" binding:"required"`
+	// Type is todo or activity_group, empty search both
+	Type  string `form:"type"`
+	Limit int    `form:"limit"`
+}
+
+type SearchResponse struct {
+	Type            string  `json:"type"`
+	ID              uint64  `json:"id"`
+	ActivityGroupID uint64  `json:"activity_group_id"`
+	Title           string  `json:"title"`
+	Highlight       string  `json:"highlight"`
+	Score           float64 `json:"score"`
+}
+
+// Format for handle single response of search
+func FormatSearchResult(result domain.SearchResult) SearchResponse {
+	formatter := SearchResponse{
+		Type:            result.Kind,
+		ID:              result.ID,
+		ActivityGroupID: result.ActivityGroupID,
+		Title:           result.Title,
+		Highlight:       result.Highlight,
+		Score:           result.Score,
+	}
+	return formatter
+}
+
+// Format for handle multiples response of search
+func FormatSearchResults(results []domain.SearchResult) []SearchResponse {
+	formatters := []SearchResponse{}
+	for _, result := range results {
+		formatters = append(formatters, FormatSearchResult(result))
+	}
+	return formatters
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"strings"
 	"time"
 
 	"github.com/letenk/todo-list/models/domain"
@@ -11,20 +12,96 @@ type TodoURI struct {
 }
 
 type TodoCreateRequest struct {
-	ActivityGroupID uint64 `json:"activity_group_id" binding:"required"`
-	Title           string `json:"title" binding:"required"`
+	ActivityGroupID uint64     `json:"activity_group_id" binding:"required"`
+	Title           string     `json:"title" binding:"required"`
+	Priority        string     `json:"priority,omitempty"`
+	StartAt         *time.Time `json:"start_at"`
+	DueAt           *time.Time `json:"due_at"`
 }
 
 type TodoUpdateRequest struct {
-	Title    string `json:"title,omitempty"`
-	IsActive bool   `json:"is_active"`
+	Title    string     `json:"title,omitempty"`
+	IsActive *bool      `json:"is_active"`
+	Priority string     `json:"priority,omitempty"`
+	StartAt  *time.Time `json:"start_at"`
+	DueAt    *time.Time `json:"due_at"`
+	// ActivityGroupID move todo to other activity group
+	ActivityGroupID uint64 `json:"activity_group_id,omitempty"`
+	// Version is version of todo expected by header If-Match, 0 is not checked
+	Version uint64 `json:"-"`
 }
+
+// TodoQuery is query string of get all todo
+type TodoQuery struct {
+	ActivityGroupID uint64 `form:"activity_group_id"`
+	// Priority can hold many priority separated by comma, ex: high,very-high
+	Priority string `form:"priority"`
+	Sort     string `form:"sort"`
+	// DueBefore and DueAfter is RFC3339 time
+	DueBefore time.Time `form:"due_before"`
+	DueAfter  time.Time `form:"due_after"`
+	Overdue   bool      `form:"overdue"`
+	IsActive  *bool     `form:"is_active"`
+	// CreatedBefore and CreatedAfter is RFC3339 time
+	CreatedBefore time.Time `form:"created_before"`
+	CreatedAfter  time.Time `form:"created_after"`
+	// TitleContains is query title~=, find title contains it
+	TitleContains string `form:"title~"`
+	// Filter is expression of conditions, see repository.ParseTodoFilter
+	Filter string `form:"filter"`
+	PageQuery
+}
+
+// IsFiltered check any query other than activity_group_id is exist
+func (q TodoQuery) IsFiltered() bool {
+	return q.Priority != "" || q.Sort != "" || !q.DueBefore.IsZero() || !q.DueAfter.IsZero() || q.Overdue ||
+		q.IsActive != nil || !q.CreatedBefore.IsZero() || !q.CreatedAfter.IsZero() || q.TitleContains != "" || q.Filter != "" ||
+		q.IsPaginated()
+}
+
+// Priorities split query priority by comma
+func (q TodoQuery) Priorities() []string {
+	if q.Priority == "" {
+		return nil
+	}
+
+	var priorities []string
+	for _, priority := range strings.Split(q.Priority, ",") {
+		priority = strings.TrimSpace(priority)
+		if priority != "" {
+			priorities = append(priorities, priority)
+		}
+	}
+	return priorities
+}
+
+// MaxBulkActions is the most actions of one bulk request
+const MaxBulkActions = 100
+
+// TodoBulkRequest run actions to many todos in one transaction
+type TodoBulkRequest struct {
+	// Atomic roll back every action when one of them failed, otherwise only the failed action
+	Atomic  bool             `json:"atomic"`
+	Actions []TodoBulkAction `json:"actions" binding:"required"`
+}
+
+// TodoBulkAction is update or delete of one todo, update change the field sent only
+type TodoBulkAction struct {
+	Action          string `json:"action"`
+	ID              uint64 `json:"id"`
+	IsActive        *bool  `json:"is_active"`
+	Priority        string `json:"priority,omitempty"`
+	ActivityGroupID uint64 `json:"activity_group_id,omitempty"`
+}
+
 type TodoResponse struct {
 	ID         uint64     `json:"id"`
 	Title      string     `json:"title"`
 	ActivityID uint64     `json:"activity_group_id"`
 	IsActive   string     `json:"is_active"`
 	Priority   string     `json:"priority"`
+	StartAt    *time.Time `json:"start_at"`
+	DueAt      *time.Time `json:"due_at"`
 	CreatedAt  *time.Time `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
 	DeletetAt  *time.Time `json:"deleted_at"`
@@ -36,6 +113,8 @@ type TodoCreatedResponse struct {
 	ActivityID uint64     `json:"activity_group_id"`
 	IsActive   bool       `json:"is_active"`
 	Priority   string     `json:"priority"`
+	StartAt    *time.Time `json:"start_at"`
+	DueAt      *time.Time `json:"due_at"`
 	CreatedAt  *time.Time `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
 	DeletetAt  *time.Time `json:"deleted_at"`
@@ -58,9 +137,11 @@ func FormatTodo(todo domain.Todo) TodoResponse {
 		ActivityID: todo.ActivityGroupID,
 		IsActive:   isActive,
 		Priority:   todo.Priority,
+		StartAt:    todo.StartAt,
+		DueAt:      todo.DueAt,
 		CreatedAt:  todo.CreatedAt,
 		UpdatedAt:  todo.UpdatedAt,
-		DeletetAt:  todo.DeletedAt,
+		DeletetAt:  deletedAt(todo.DeletedAt),
 	}
 	return formatter
 }
@@ -73,9 +154,11 @@ func FormatCreatedTodo(todo domain.Todo) TodoCreatedResponse {
 		ActivityID: todo.ActivityGroupID,
 		IsActive:   todo.IsActive,
 		Priority:   todo.Priority,
+		StartAt:    todo.StartAt,
+		DueAt:      todo.DueAt,
 		CreatedAt:  todo.CreatedAt,
 		UpdatedAt:  todo.UpdatedAt,
-		DeletetAt:  todo.DeletedAt,
+		DeletetAt:  deletedAt(todo.DeletedAt),
 	}
 	return formatter
 }
@@ -96,9 +179,11 @@ func FormatTodoResponse(todo domain.Todo) TodoResponse {
 		ActivityID: todo.ActivityGroupID,
 		IsActive:   isActive,
 		Priority:   todo.Priority,
+		StartAt:    todo.StartAt,
+		DueAt:      todo.DueAt,
 		CreatedAt:  todo.CreatedAt,
 		UpdatedAt:  todo.UpdatedAt,
-		DeletetAt:  todo.DeletedAt,
+		DeletetAt:  deletedAt(todo.DeletedAt),
 	}
 	return formatter
 }
@@ -118,3 +203,20 @@ func FormatTodos(todo []domain.Todo) []TodoResponse {
 
 	return formatters
 }
+
+// TodoBulkResultResponse is result of one action of bulk, Data is the updated todo
+type TodoBulkResultResponse struct {
+	Index   int           `json:"index"`
+	ID      uint64        `json:"id"`
+	Action  string        `json:"action"`
+	Status  int           `json:"status"`
+	Code    string        `json:"code,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Data    *TodoResponse `json:"data,omitempty"`
+}
+
+type TodoBulkResponse struct {
+	Succeeded int                      `json:"succeeded"`
+	Failed    int                      `json:"failed"`
+	Results   []TodoBulkResultResponse `json:"results"`
+}
@@ -0,0 +1,35 @@
+package web
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+type TrashResponse struct {
+	ActivityGroups []ActivityGetOneResponse `json:"activity_groups"`
+	TodoItems      []TodoResponse           `json:"todo_items"`
+}
+
+type PurgeResponse struct {
+	ActivityGroups int64 `json:"activity_groups"`
+	TodoItems      int64 `json:"todo_items"`
+}
+
+// Format for handle response trash
+func FormatTrash(activities []domain.Activity, todos []domain.Todo) TrashResponse {
+	formatter := TrashResponse{
+		ActivityGroups: FormatActivitiesGetOne(activities),
+		TodoItems:      FormatTodos(todos),
+	}
+	return formatter
+}
+
+// deletedAt convert soft delete time to nullable time for response
+func deletedAt(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return &deletedAt.Time
+}
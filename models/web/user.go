@@ -0,0 +1,57 @@
+package web
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+)
+
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type UserResponse struct {
+	ID        uint64     `json:"id"`
+	Email     string     `json:"email"`
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TokenResponse is bearer token of user, send it in header Authorization
+type TokenResponse struct {
+	AccessToken string       `json:"access_token"`
+	TokenType   string       `json:"token_type"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	User        UserResponse `json:"user"`
+}
+
+// Format for handle single response user, password hash is never shown
+func FormatUser(user domain.User) UserResponse {
+	formatter := UserResponse{
+		ID:        user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+	return formatter
+}
+
+// Format for handle response of register and login
+func FormatToken(user domain.User, token string, expiresAt time.Time) TokenResponse {
+	formatter := TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		User:        FormatUser(user),
+	}
+	return formatter
+}
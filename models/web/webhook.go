@@ -0,0 +1,135 @@
+package web
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// DefaultDeliveryLimit is the number of deliveries of log when query limit is not sent
+const DefaultDeliveryLimit = 20
+
+type WebhookIdURI struct {
+	ID uint64 `uri:"id" binding:"required"`
+}
+
+type WebhookDeliveryURI struct {
+	ID         uint64 `uri:"id" binding:"required"`
+	DeliveryID uint64 `uri:"delivery_id" binding:"required"`
+}
+
+type WebhookRequest struct {
+	URL string `json:"url" binding:"required,url"`
+	// Secret is generated when it is not sent
+	Secret          string   `json:"secret" binding:"omitempty,min=16"`
+	EventTypes      []string `json:"event_types" binding:"required,min=1"`
+	ActivityGroupID uint64   `json:"activity_group_id"`
+}
+
+type WebhookUpdateRequest struct {
+	URL        string   `json:"url" binding:"omitempty,url"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16"`
+	EventTypes []string `json:"event_types"`
+	// ActivityGroupID 0 remove filter of activity group, nil does not change it
+	ActivityGroupID *uint64 `json:"activity_group_id"`
+}
+
+// WebhookDeliveryQuery is query string of delivery log of webhook
+type WebhookDeliveryQuery struct {
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+}
+
+type WebhookResponse struct {
+	ID              uint64     `json:"id"`
+	URL             string     `json:"url"`
+	EventTypes      []string   `json:"event_types"`
+	ActivityGroupID *uint64    `json:"activity_group_id"`
+	CreatedAt       *time.Time `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// WebhookCreateResponse has the secret, it is only shown once when it is created
+type WebhookCreateResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID        uint64          `json:"id"`
+	WebhookID uint64          `json:"webhook_id"`
+	EventID   uint64          `json:"event_id"`
+	EventType string          `json:"event_type"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	// NextAttemptAt is only shown for pending delivery
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      *time.Time `json:"created_at"`
+}
+
+// Format for handle single response webhook
+func FormatWebhook(webhook domain.Webhook) WebhookResponse {
+	formatter := WebhookResponse{
+		ID:              webhook.ID,
+		URL:             webhook.URL,
+		EventTypes:      webhook.EventTypeList(),
+		ActivityGroupID: webhook.ActivityGroupID,
+		CreatedAt:       webhook.CreatedAt,
+		UpdatedAt:       webhook.UpdatedAt,
+	}
+	return formatter
+}
+
+// Format for handle response of created webhook
+func FormatCreatedWebhook(webhook domain.Webhook) WebhookCreateResponse {
+	formatter := WebhookCreateResponse{
+		WebhookResponse: FormatWebhook(webhook),
+		Secret:          webhook.Secret,
+	}
+	return formatter
+}
+
+// Format for handle multiples response webhook
+func FormatWebhooks(webhooks []domain.Webhook) []WebhookResponse {
+	formatters := []WebhookResponse{}
+	for _, webhook := range webhooks {
+		formatters = append(formatters, FormatWebhook(webhook))
+	}
+	return formatters
+}
+
+// Format for handle single response delivery of webhook
+func FormatWebhookDelivery(delivery domain.WebhookDelivery) WebhookDeliveryResponse {
+	formatter := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+	if delivery.Status == domain.DeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		formatter.NextAttemptAt = &nextAttemptAt
+	}
+	return formatter
+}
+
+// Format for handle multiples response delivery of webhook
+func FormatWebhookDeliveries(deliveries []domain.WebhookDelivery) []WebhookDeliveryResponse {
+	formatters := []WebhookDeliveryResponse{}
+	for _, delivery := range deliveries {
+		formatters = append(formatters, FormatWebhookDelivery(delivery))
+	}
+	return formatters
+}
@@ -0,0 +1,44 @@
+package web
+
+import "encoding/json"
+
+// Type of message sent by client of websocket
+const (
+	WebsocketSubscribe   = "subscribe"
+	WebsocketUnsubscribe = "unsubscribe"
+	WebsocketTodoCreate  = "todo.create"
+	WebsocketTodoUpdate  = "todo.update"
+	WebsocketTodoDelete  = "todo.delete"
+)
+
+// Type of message sent by server of websocket
+const (
+	WebsocketAck   = "ack"
+	WebsocketError = "error"
+	WebsocketEvent = "event"
+	WebsocketReset = "reset"
+)
+
+// WebsocketRequest is message sent by client, its ID is sent back with the ack or error
+type WebsocketRequest struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	ActivityGroupID uint64 `json:"activity_group_id"`
+	TodoID          uint64 `json:"todo_id"`
+	// Version is expected version of todo like header If-Match, 0 is not checked
+	Version uint64 `json:"version"`
+	// Data is TodoCreateRequest or TodoUpdateRequest
+	Data json.RawMessage `json:"data"`
+}
+
+// WebsocketResponse is message sent by server, ack and error of request has its ID
+type WebsocketResponse struct {
+	ID              string       `json:"id,omitempty"`
+	Type            string       `json:"type"`
+	ActivityGroupID uint64       `json:"activity_group_id,omitempty"`
+	Status          int          `json:"status,omitempty"`
+	Code            string       `json:"code,omitempty"`
+	Message         string       `json:"message,omitempty"`
+	Errors          []FieldError `json:"errors,omitempty"`
+	Data            interface{}  `json:"data,omitempty"`
+}
@@ -1,71 +1,286 @@
 package repository
 
 import (
+	"strings"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
 	"github.com/letenk/todo-list/models/domain"
 	"gorm.io/gorm"
 )
 
+// Column can be used by ActivityFilter.Sort, prefix "-" for descending
+var activitySortColumns = map[string]sortColumn{
+	"id":         {expression: "id", cursorValue: intCursorValue},
+	"created_at": {expression: "created_at", cursorValue: timeCursorValue},
+	"title":      {expression: "title", cursorValue: stringCursorValue},
+}
+
+// ActivitySorts list column can be used by ActivityFilter.Sort
+var ActivitySorts = []string{"id", "created_at", "title"}
+
+// IsValidActivitySort check sort can be used by ActivityFilter.Sort
+func IsValidActivitySort(sort string) bool {
+	return isValidSort(activitySortColumns, sort)
+}
+
+// ActivityCursor create cursor point to activity, as the last row of a page sorted by sort
+func ActivityCursor(activity domain.Activity, sort string) Cursor {
+	cursor := Cursor{Sort: sort, ID: activity.ID}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case "created_at":
+		if activity.CreatedAt != nil {
+			cursor.Value = activity.CreatedAt.Format(time.RFC3339Nano)
+		}
+	case "title":
+		cursor.Value = activity.Title
+	}
+
+	return cursor
+}
+
+// ActivityFilter hold sort and page for find activity groups
+type ActivityFilter struct {
+	Sort string
+	Page Page
+}
+
 type ActivityRepository interface {
 	Save(Activity domain.Activity) (domain.Activity, error)
 	FindAll() ([]domain.Activity, error)
+	FindByFilter(filter ActivityFilter) ([]domain.Activity, error)
+	Count() (int64, error)
 	FindOne(id uint64) (domain.Activity, error)
 	Update(Activity domain.Activity) (domain.Activity, error)
 	Delete(Activity domain.Activity) (bool, error)
+	FindTrashed() ([]domain.Activity, error)
+	FindTrashedOne(id uint64) (domain.Activity, error)
+	Restore(Activity domain.Activity) (domain.Activity, error)
+	Purge(Activity domain.Activity) (bool, error)
+	// FindRole return role of the owner in activity group, deleted included. It is
+	// RoleOwner when not scoped
+	FindRole(id uint64) (string, error)
+	// WithOwner return repository scoped to activity groups user userID is member of, 0 is not scoped
+	WithOwner(userID uint64) ActivityRepository
 }
 
 type activityRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	owner uint64
 }
 
 func NewRepositoryActivity(db *gorm.DB) *activityRepository {
-	return &activityRepository{db}
+	return &activityRepository{db: db}
+}
+
+func (r *activityRepository) WithOwner(userID uint64) ActivityRepository {
+	return &activityRepository{db: r.db, owner: userID}
+}
+
+// scoped return query of activity groups the owner is member of
+func (r *activityRepository) scoped() *gorm.DB {
+	if r.owner == 0 {
+		return r.db
+	}
+	return r.db.Where("id IN (?)", memberOf(r.db, r.owner))
+}
+
+// memberOf return query of id of activity groups user userID is member of, deleted included
+func memberOf(db *gorm.DB, userID uint64) *gorm.DB {
+	return db.Model(&domain.Membership{}).Select("activity_group_id").Where("user_id = ?", userID)
+}
+
+func (r *activityRepository) FindRole(id uint64) (string, error) {
+	if r.owner == 0 {
+		return domain.RoleOwner, nil
+	}
+
+	var membership domain.Membership
+	err := r.db.Where("activity_group_id = ? AND user_id = ?", id, r.owner).Find(&membership).Error
+	if err != nil {
+		return "", translateError(err)
+	}
+
+	if membership.ID == 0 {
+		return "", apperror.NotFound("Activity with ID %d Not Found", id)
+	}
+
+	return membership.Role, nil
 }
 
 func (r *activityRepository) FindAll() ([]domain.Activity, error) {
 	var Activitys []domain.Activity
 
-	err := r.db.Find(&Activitys).Error
+	err := r.scoped().Find(&Activitys).Error
 	if err != nil {
-		return Activitys, nil
+		return Activitys, translateError(err)
 	}
 
 	return Activitys, nil
 }
 
+func (r *activityRepository) FindByFilter(filter ActivityFilter) ([]domain.Activity, error) {
+	var activities []domain.Activity
+
+	query, err := paginate(r.scoped(), activitySortColumns, filter.Sort, filter.Page)
+	if err != nil {
+		return activities, translateError(err)
+	}
+
+	err = query.Find(&activities).Error
+	if err != nil {
+		return activities, translateError(err)
+	}
+
+	return activities, nil
+}
+
+func (r *activityRepository) Count() (int64, error) {
+	var count int64
+
+	err := r.scoped().Model(&domain.Activity{}).Count(&count).Error
+	if err != nil {
+		return count, translateError(err)
+	}
+
+	return count, nil
+}
+
 func (r *activityRepository) FindOne(id uint64) (domain.Activity, error) {
 	var Activity domain.Activity
 
-	err := r.db.Where("id = ?", id).Find(&Activity).Error
+	err := r.scoped().Where("id = ?", id).Find(&Activity).Error
 	if err != nil {
-		return Activity, nil
+		return Activity, translateError(err)
+	}
+
+	if Activity.ID == 0 {
+		return Activity, apperror.NotFound("Activity with ID %d Not Found", id)
 	}
 
 	return Activity, nil
 }
 
 func (r *activityRepository) Save(Activity domain.Activity) (domain.Activity, error) {
-	err := r.db.Create(&Activity).Error
+	// Default of column is not read back by Create
+	Activity.Version = 1
+	if r.owner == 0 {
+		err := r.db.Create(&Activity).Error
+		if err != nil {
+			return Activity, translateError(err)
+		}
+		return Activity, nil
+	}
+
+	// The creator is the first owner
+	Activity.UserID = &r.owner
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&Activity).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&domain.Membership{ActivityGroupID: Activity.ID, UserID: r.owner, Role: domain.RoleOwner}).Error
+	})
 	if err != nil {
-		return Activity, err
+		return Activity, translateError(err)
 	}
 
 	return Activity, nil
 }
 
 func (r *activityRepository) Update(Activity domain.Activity) (domain.Activity, error) {
-	err := r.db.Save(&Activity).Error
+	// Activity group of other user is not found, instead of changed by other request
+	if r.owner != 0 {
+		var count int64
+		err := r.scoped().Unscoped().Model(&domain.Activity{}).Where("id = ?", Activity.ID).Count(&count).Error
+		if err != nil {
+			return Activity, translateError(err)
+		}
+		if count == 0 {
+			return Activity, apperror.NotFound("Activity with ID %d Not Found", Activity.ID)
+		}
+	}
+
+	updated, err := updateVersion(r.db, &Activity, Activity.ID, &Activity.Version)
 	if err != nil {
-		return Activity, err
+		return Activity, translateError(err)
+	}
+	if !updated {
+		return Activity, versionConflict("Activity", Activity.ID)
 	}
 
 	return Activity, nil
 }
 
 func (r *activityRepository) Delete(Activity domain.Activity) (bool, error) {
-	err := r.db.Delete(&Activity).Error
+	db := r.scoped()
+	// Version 0 delete whatever version is stored
+	if Activity.Version != 0 {
+		db = db.Where("version = ?", Activity.Version)
+	}
+
+	result := db.Delete(&Activity)
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+	if Activity.Version != 0 && result.RowsAffected == 0 {
+		// Not found is deleted already, only other version is refused
+		var count int64
+		err := r.scoped().Model(&domain.Activity{}).Where("id = ?", Activity.ID).Count(&count).Error
+		if err != nil {
+			return false, translateError(err)
+		}
+		if count != 0 {
+			return false, versionConflict("Activity", Activity.ID)
+		}
+	}
+
+	return true, nil
+}
+
+func (r *activityRepository) FindTrashed() ([]domain.Activity, error) {
+	var activities []domain.Activity
+
+	err := r.scoped().Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&activities).Error
+	if err != nil {
+		return activities, translateError(err)
+	}
+
+	return activities, nil
+}
+
+func (r *activityRepository) FindTrashedOne(id uint64) (domain.Activity, error) {
+	var Activity domain.Activity
+
+	err := r.scoped().Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Find(&Activity).Error
 	if err != nil {
-		return false, err
+		return Activity, translateError(err)
+	}
+
+	if Activity.ID == 0 {
+		return Activity, apperror.NotFound("Activity with ID %d Not Found in trash", id)
+	}
+
+	return Activity, nil
+}
+
+func (r *activityRepository) Restore(Activity domain.Activity) (domain.Activity, error) {
+	err := r.scoped().Unscoped().Model(&Activity).Update("deleted_at", nil).Error
+	if err != nil {
+		return Activity, translateError(err)
+	}
+
+	Activity.DeletedAt = gorm.DeletedAt{}
+	return Activity, nil
+}
+
+func (r *activityRepository) Purge(Activity domain.Activity) (bool, error) {
+	err := r.scoped().Unscoped().Delete(&Activity).Error
+	if err != nil {
+		return false, translateError(err)
 	}
 
 	return true, nil
 }
+
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+// Value of activity for each column of activitySortColumns
+var activityMemoryValues = map[string]func(domain.Activity) interface{}{
+	"id":         func(a domain.Activity) interface{} { return a.ID },
+	"created_at": func(a domain.Activity) interface{} { return a.CreatedAt },
+	"title":      func(a domain.Activity) interface{} { return a.Title },
+}
+
+// activityMemoryRepository is ActivityRepository in memory, it is safe for concurrent use
+type activityMemoryRepository struct {
+	store *MemoryStore
+	owner uint64
+}
+
+func NewRepositoryActivityMemory(store *MemoryStore) *activityMemoryRepository {
+	return &activityMemoryRepository{store: store}
+}
+
+func (r *activityMemoryRepository) WithOwner(userID uint64) ActivityRepository {
+	return &activityMemoryRepository{store: r.store, owner: userID}
+}
+
+// isOwned check activity group with id is exist and owned by the owner
+func (r *activityMemoryRepository) isOwned(id uint64) bool {
+	_, ok := r.store.data.activities[id]
+	return ok && r.store.data.isOwnedBy(id, r.owner)
+}
+
+// find return activities of the owner matched by match ordered by id
+func (r *activityMemoryRepository) find(match func(domain.Activity) bool) []domain.Activity {
+	activities := []domain.Activity{}
+	for _, activity := range r.store.data.activities {
+		if match(activity) && r.store.data.isOwnedBy(activity.ID, r.owner) {
+			activities = append(activities, cloneActivity(activity))
+		}
+	}
+
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].ID < activities[j].ID
+	})
+	return activities
+}
+
+func isActivityFound(activity domain.Activity) bool {
+	return !activity.DeletedAt.Valid
+}
+
+func isActivityTrashed(activity domain.Activity) bool {
+	return activity.DeletedAt.Valid
+}
+
+// check return error when activity break constraint of table activities
+func (r *activityMemoryRepository) check(Activity domain.Activity) error {
+	// Email is unique index
+	for _, activity := range r.store.data.activities {
+		if activity.ID != Activity.ID && activity.Email == Activity.Email {
+			return errDuplicate.Wrap(fmt.Errorf("duplicate email %s of activities", Activity.Email))
+		}
+	}
+
+	// Foreign key to user
+	if Activity.UserID != nil {
+		_, ok := r.store.data.users[*Activity.UserID]
+		if !ok {
+			return errReferenceMissing.Wrap(fmt.Errorf("user %d of activities is not exist", *Activity.UserID))
+		}
+	}
+
+	return nil
+}
+
+func (r *activityMemoryRepository) Save(Activity domain.Activity) (domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.owner != 0 {
+		Activity.UserID = &r.owner
+	}
+	Activity.Version = 1
+
+	err := r.check(Activity)
+	if err != nil {
+		return Activity, err
+	}
+
+	if Activity.ID == 0 {
+		r.store.data.lastActivityID++
+		Activity.ID = r.store.data.lastActivityID
+	} else if _, ok := r.store.data.activities[Activity.ID]; ok {
+		return Activity, errDuplicate.Wrap(fmt.Errorf("duplicate id %d of activities", Activity.ID))
+	}
+	if Activity.ID > r.store.data.lastActivityID {
+		r.store.data.lastActivityID = Activity.ID
+	}
+
+	now := time.Now()
+	if Activity.CreatedAt == nil {
+		Activity.CreatedAt = &now
+	}
+	if Activity.UpdatedAt.IsZero() {
+		Activity.UpdatedAt = now
+	}
+
+	r.store.data.activities[Activity.ID] = cloneActivity(Activity)
+
+	// The creator is the first owner
+	if r.owner != 0 {
+		r.store.data.lastMemberID++
+		r.store.data.memberships[r.store.data.lastMemberID] = domain.Membership{
+			ID:              r.store.data.lastMemberID,
+			ActivityGroupID: Activity.ID,
+			UserID:          r.owner,
+			Role:            domain.RoleOwner,
+			CreatedAt:       cloneTime(Activity.CreatedAt),
+			UpdatedAt:       now,
+		}
+	}
+
+	return cloneActivity(Activity), nil
+}
+
+func (r *activityMemoryRepository) FindRole(id uint64) (string, error) {
+	if r.owner == 0 {
+		return domain.RoleOwner, nil
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	membership, ok := r.store.data.findMembership(id, r.owner)
+	if !ok {
+		return "", apperror.NotFound("Activity with ID %d Not Found", id)
+	}
+
+	return membership.Role, nil
+}
+
+func (r *activityMemoryRepository) FindAll() ([]domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.find(isActivityFound), nil
+}
+
+func (r *activityMemoryRepository) FindByFilter(filter ActivityFilter) ([]domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := func(a domain.Activity) uint64 { return a.ID }
+	return paginateMemory(r.find(isActivityFound), activitySortColumns, activityMemoryValues, id, filter.Sort, filter.Page)
+}
+
+func (r *activityMemoryRepository) Count() (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return int64(len(r.find(isActivityFound))), nil
+}
+
+func (r *activityMemoryRepository) FindOne(id uint64) (domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	activity, ok := r.store.data.activities[id]
+	if !ok || !isActivityFound(activity) || !r.isOwned(id) {
+		return domain.Activity{}, apperror.NotFound("Activity with ID %d Not Found", id)
+	}
+
+	return cloneActivity(activity), nil
+}
+
+func (r *activityMemoryRepository) Update(Activity domain.Activity) (domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Activity group of other user is not found, instead of inserted
+	if r.owner != 0 {
+		if !r.isOwned(Activity.ID) {
+			return Activity, apperror.NotFound("Activity with ID %d Not Found", Activity.ID)
+		}
+	}
+
+	err := r.check(Activity)
+	if err != nil {
+		return Activity, err
+	}
+
+	// Update only the version read, version 0 update whatever version is stored
+	stored, ok := r.store.data.activities[Activity.ID]
+	if !ok || !isActivityFound(stored) || (Activity.Version != 0 && Activity.Version != stored.Version) {
+		return Activity, versionConflict("Activity", Activity.ID)
+	}
+	Activity.Version = stored.Version + 1
+	Activity.UpdatedAt = time.Now()
+
+	r.store.data.activities[Activity.ID] = cloneActivity(Activity)
+	return cloneActivity(Activity), nil
+}
+
+func (r *activityMemoryRepository) Delete(Activity domain.Activity) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	activity, ok := r.store.data.activities[Activity.ID]
+	if ok && isActivityFound(activity) && r.isOwned(activity.ID) {
+		if Activity.Version != 0 && Activity.Version != activity.Version {
+			return false, versionConflict("Activity", Activity.ID)
+		}
+		activity.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.store.data.activities[activity.ID] = activity
+	}
+
+	return true, nil
+}
+
+func (r *activityMemoryRepository) FindTrashed() ([]domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	activities := r.find(isActivityTrashed)
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].DeletedAt.Time.After(activities[j].DeletedAt.Time)
+	})
+	return activities, nil
+}
+
+func (r *activityMemoryRepository) FindTrashedOne(id uint64) (domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	activity, ok := r.store.data.activities[id]
+	if !ok || !isActivityTrashed(activity) || !r.isOwned(id) {
+		return domain.Activity{}, apperror.NotFound("Activity with ID %d Not Found in trash", id)
+	}
+
+	return cloneActivity(activity), nil
+}
+
+func (r *activityMemoryRepository) Restore(Activity domain.Activity) (domain.Activity, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	Activity.DeletedAt = gorm.DeletedAt{}
+	Activity.UpdatedAt = time.Now()
+
+	activity, ok := r.store.data.activities[Activity.ID]
+	if ok && r.isOwned(activity.ID) {
+		activity.DeletedAt = Activity.DeletedAt
+		activity.UpdatedAt = Activity.UpdatedAt
+		r.store.data.activities[activity.ID] = activity
+	}
+
+	return Activity, nil
+}
+
+// purge delete activity permanently with its todos and members, like foreign key on delete cascade
+func (r *activityMemoryRepository) purge(id uint64) {
+	delete(r.store.data.activities, id)
+	for _, todo := range r.store.data.todos {
+		if todo.ActivityGroupID == id {
+			delete(r.store.data.todos, todo.ID)
+		}
+	}
+	for _, membership := range r.store.data.memberships {
+		if membership.ActivityGroupID == id {
+			delete(r.store.data.memberships, membership.ID)
+		}
+	}
+}
+
+func (r *activityMemoryRepository) Purge(Activity domain.Activity) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.isOwned(Activity.ID) {
+		r.purge(Activity.ID)
+	}
+	return true, nil
+}
+
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Save(key domain.APIKey) (domain.APIKey, error)
+	FindByUserID(userID uint64) ([]domain.APIKey, error)
+	FindByHash(hash string) (domain.APIKey, error)
+	// Delete delete key of user userID, key of other user is not found
+	Delete(userID uint64, id uint64) (bool, error)
+	// Touch set last used time of key
+	Touch(id uint64, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewRepositoryAPIKey(db *gorm.DB) *apiKeyRepository {
+	return &apiKeyRepository{db}
+}
+
+func (r *apiKeyRepository) Save(key domain.APIKey) (domain.APIKey, error) {
+	err := r.db.Create(&key).Error
+	if err != nil {
+		return key, translateError(err)
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) FindByUserID(userID uint64) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&keys).Error
+	if err != nil {
+		return keys, translateError(err)
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) FindByHash(hash string) (domain.APIKey, error) {
+	var key domain.APIKey
+
+	err := r.db.Where("key_hash = ?", hash).Find(&key).Error
+	if err != nil {
+		return key, translateError(err)
+	}
+
+	if key.ID == 0 {
+		return key, apperror.NotFound("API key Not Found")
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) Delete(userID uint64, id uint64) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.APIKey{})
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return false, apperror.NotFound("API key with ID %d Not Found", id)
+	}
+
+	return true, nil
+}
+
+func (r *apiKeyRepository) Touch(id uint64, at time.Time) error {
+	err := r.db.Model(&domain.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
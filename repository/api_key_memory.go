@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// apiKeyMemoryRepository is APIKeyRepository in memory, it is safe for concurrent use
+type apiKeyMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewRepositoryAPIKeyMemory(store *MemoryStore) *apiKeyMemoryRepository {
+	return &apiKeyMemoryRepository{store}
+}
+
+func (r *apiKeyMemoryRepository) Save(key domain.APIKey) (domain.APIKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Hash is unique index, user is foreign key
+	for _, stored := range r.store.data.apiKeys {
+		if stored.KeyHash == key.KeyHash {
+			return key, errDuplicate.Wrap(fmt.Errorf("duplicate key hash of api keys"))
+		}
+	}
+	_, ok := r.store.data.users[key.UserID]
+	if !ok {
+		return key, errReferenceMissing.Wrap(fmt.Errorf("user %d of api keys is not exist", key.UserID))
+	}
+
+	r.store.data.lastAPIKeyID++
+	key.ID = r.store.data.lastAPIKeyID
+
+	now := time.Now()
+	if key.CreatedAt == nil {
+		key.CreatedAt = &now
+	}
+	if key.UpdatedAt.IsZero() {
+		key.UpdatedAt = now
+	}
+
+	r.store.data.apiKeys[key.ID] = cloneAPIKey(key)
+	return cloneAPIKey(key), nil
+}
+
+func (r *apiKeyMemoryRepository) FindByUserID(userID uint64) ([]domain.APIKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	keys := []domain.APIKey{}
+	for _, key := range r.store.data.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, cloneAPIKey(key))
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys, nil
+}
+
+func (r *apiKeyMemoryRepository) FindByHash(hash string) (domain.APIKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, key := range r.store.data.apiKeys {
+		if key.KeyHash == hash {
+			return cloneAPIKey(key), nil
+		}
+	}
+
+	return domain.APIKey{}, apperror.NotFound("API key Not Found")
+}
+
+func (r *apiKeyMemoryRepository) Delete(userID uint64, id uint64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key, ok := r.store.data.apiKeys[id]
+	if !ok || key.UserID != userID {
+		return false, apperror.NotFound("API key with ID %d Not Found", id)
+	}
+
+	delete(r.store.data.apiKeys, id)
+	return true, nil
+}
+
+func (r *apiKeyMemoryRepository) Touch(id uint64, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key, ok := r.store.data.apiKeys[id]
+	if ok {
+		key.LastUsedAt = &at
+		r.store.data.apiKeys[id] = key
+	}
+
+	return nil
+}
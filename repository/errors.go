@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/letenk/todo-list/apperror"
+	"gorm.io/gorm"
+)
+
+// Code of constraint error of each database
+const (
+	mysqlDuplicateEntry  = 1062
+	mysqlRowIsReferenced = 1451
+	mysqlNoReferencedRow = 1452
+	mysqlCheckViolated   = 3819
+
+	postgresUniqueViolation     = "23505"
+	postgresForeignKeyViolation = "23503"
+	postgresCheckViolation      = "23514"
+
+	sqliteConstraintCheck      = 275
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+)
+
+// Error of constraint, the cause is wrapped by translateError
+var (
+	errDuplicate        = apperror.Conflict("Data already exists").WithCode("duplicate")
+	errReferenced       = apperror.Conflict("Data is still used by other data").WithCode("referenced")
+	errReferenceMissing = apperror.Validation("Data used by the request is not exist").WithCode("reference_not_found")
+	errInvalidValue     = apperror.Validation("Data has invalid value").WithCode("invalid_value")
+)
+
+// translateError convert error of database to apperror, so constraint violation can be
+// told from a failed database. Error is returned as is when it is already apperror
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	constraintErr := constraintError(err)
+	if constraintErr != nil {
+		return constraintErr.Wrap(err)
+	}
+
+	return apperror.Unavailable(err)
+}
+
+// constraintError return apperror of constraint violated by err, nil if it is not a constraint error
+func constraintError(err error) *apperror.Error {
+	var mysqlErr *mysql.MySQLError
+	var postgresErr *pgconn.PgError
+	var sqliteErr interface{ Code() int }
+
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errDuplicate
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return errReferenceMissing
+	case errors.As(err, &mysqlErr):
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry:
+			return errDuplicate
+		case mysqlRowIsReferenced:
+			return errReferenced
+		case mysqlNoReferencedRow:
+			return errReferenceMissing
+		case mysqlCheckViolated:
+			return errInvalidValue
+		}
+	case errors.As(err, &postgresErr):
+		switch postgresErr.Code {
+		case postgresUniqueViolation:
+			return errDuplicate
+		case postgresForeignKeyViolation:
+			// Code is the same for both sides of the constraint, delete of referenced row is
+			// "update or delete on table ..." with detail "Key ... is still referenced ..."
+			if strings.HasPrefix(postgresErr.Message, "update or delete") || strings.Contains(postgresErr.Detail, "is still referenced") {
+				return errReferenced
+			}
+			return errReferenceMissing
+		case postgresCheckViolation:
+			return errInvalidValue
+		}
+	case errors.As(err, &sqliteErr):
+		switch sqliteErr.Code() {
+		case sqliteConstraintUnique, sqliteConstraintPrimaryKey:
+			return errDuplicate
+		case sqliteConstraintForeignKey:
+			return errReferenceMissing
+		case sqliteConstraintCheck:
+			return errInvalidValue
+		}
+	}
+
+	return nil
+}
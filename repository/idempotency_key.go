@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+type IdempotencyKeyRepository interface {
+	// Save reserve key, it is conflict when the user already has the key
+	Save(key domain.IdempotencyKey) (domain.IdempotencyKey, error)
+	FindByKey(userID uint64, key string) (domain.IdempotencyKey, error)
+	// Update store response of the key
+	Update(key domain.IdempotencyKey) (domain.IdempotencyKey, error)
+	Delete(id uint64) (bool, error)
+	// DeleteExpired delete keys expired before at
+	DeleteExpired(at time.Time) (int64, error)
+}
+
+type idempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewRepositoryIdempotencyKey(db *gorm.DB) *idempotencyKeyRepository {
+	return &idempotencyKeyRepository{db}
+}
+
+func (r *idempotencyKeyRepository) Save(key domain.IdempotencyKey) (domain.IdempotencyKey, error) {
+	err := r.db.Create(&key).Error
+	if err != nil {
+		return key, translateError(err)
+	}
+
+	return key, nil
+}
+
+func (r *idempotencyKeyRepository) FindByKey(userID uint64, key string) (domain.IdempotencyKey, error) {
+	var idempotencyKey domain.IdempotencyKey
+
+	// Column key is quoted by gorm, it is reserved word of MySQL
+	err := r.db.Where(map[string]interface{}{"user_id": userID, "key": key}).Find(&idempotencyKey).Error
+	if err != nil {
+		return idempotencyKey, translateError(err)
+	}
+
+	if idempotencyKey.ID == 0 {
+		return idempotencyKey, apperror.NotFound("Idempotency key Not Found")
+	}
+
+	return idempotencyKey, nil
+}
+
+func (r *idempotencyKeyRepository) Update(key domain.IdempotencyKey) (domain.IdempotencyKey, error) {
+	err := r.db.Model(&key).Select("status_code", "content_type", "body", "updated_at").Updates(&key).Error
+	if err != nil {
+		return key, translateError(err)
+	}
+
+	return key, nil
+}
+
+func (r *idempotencyKeyRepository) Delete(id uint64) (bool, error) {
+	err := r.db.Delete(&domain.IdempotencyKey{}, id).Error
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return true, nil
+}
+
+func (r *idempotencyKeyRepository) DeleteExpired(at time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", at).Delete(&domain.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, translateError(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
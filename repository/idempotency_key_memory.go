@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// idempotencyKeyMemoryRepository is IdempotencyKeyRepository in memory, it is safe for concurrent use
+type idempotencyKeyMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewRepositoryIdempotencyKeyMemory(store *MemoryStore) *idempotencyKeyMemoryRepository {
+	return &idempotencyKeyMemoryRepository{store}
+}
+
+func (r *idempotencyKeyMemoryRepository) Save(key domain.IdempotencyKey) (domain.IdempotencyKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// User and key is unique index, user is foreign key
+	for _, stored := range r.store.data.idempotencyKeys {
+		if stored.UserID == key.UserID && stored.Key == key.Key {
+			return key, errDuplicate.Wrap(fmt.Errorf("duplicate key %s of idempotency keys", key.Key))
+		}
+	}
+	_, ok := r.store.data.users[key.UserID]
+	if !ok {
+		return key, errReferenceMissing.Wrap(fmt.Errorf("user %d of idempotency keys is not exist", key.UserID))
+	}
+
+	r.store.data.lastIdempotencyKeyID++
+	key.ID = r.store.data.lastIdempotencyKeyID
+
+	now := time.Now()
+	if key.CreatedAt == nil {
+		key.CreatedAt = &now
+	}
+	if key.UpdatedAt.IsZero() {
+		key.UpdatedAt = now
+	}
+
+	r.store.data.idempotencyKeys[key.ID] = cloneIdempotencyKey(key)
+	return cloneIdempotencyKey(key), nil
+}
+
+func (r *idempotencyKeyMemoryRepository) FindByKey(userID uint64, key string) (domain.IdempotencyKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, stored := range r.store.data.idempotencyKeys {
+		if stored.UserID == userID && stored.Key == key {
+			return cloneIdempotencyKey(stored), nil
+		}
+	}
+
+	return domain.IdempotencyKey{}, apperror.NotFound("Idempotency key Not Found")
+}
+
+func (r *idempotencyKeyMemoryRepository) Update(key domain.IdempotencyKey) (domain.IdempotencyKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.data.idempotencyKeys[key.ID]
+	if ok {
+		stored.StatusCode = key.StatusCode
+		stored.ContentType = key.ContentType
+		stored.Body = key.Body
+		stored.UpdatedAt = time.Now()
+		r.store.data.idempotencyKeys[key.ID] = cloneIdempotencyKey(stored)
+	}
+
+	return key, nil
+}
+
+func (r *idempotencyKeyMemoryRepository) Delete(id uint64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.data.idempotencyKeys, id)
+	return true, nil
+}
+
+func (r *idempotencyKeyMemoryRepository) DeleteExpired(at time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var count int64
+	for id, key := range r.store.data.idempotencyKeys {
+		if key.ExpiresAt.Before(at) {
+			delete(r.store.data.idempotencyKeys, id)
+			count++
+		}
+	}
+
+	return count, nil
+}
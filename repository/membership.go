@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MembershipRepository interface {
+	Save(membership domain.Membership) (domain.Membership, error)
+	// FindByActivityID return members of activity group with their user, ordered by id
+	FindByActivityID(activityID uint64) ([]domain.Membership, error)
+	FindOne(activityID uint64, userID uint64) (domain.Membership, error)
+	// CountByRole count members of role, in transaction they are locked until it end so
+	// concurrent change of them wait for it
+	CountByRole(activityID uint64, role string) (int64, error)
+	Delete(membership domain.Membership) (bool, error)
+}
+
+type membershipRepository struct {
+	db *gorm.DB
+}
+
+func NewRepositoryMembership(db *gorm.DB) *membershipRepository {
+	return &membershipRepository{db}
+}
+
+func (r *membershipRepository) Save(membership domain.Membership) (domain.Membership, error) {
+	err := r.db.Omit("User").Create(&membership).Error
+	if err != nil {
+		return membership, translateError(err)
+	}
+
+	return membership, nil
+}
+
+func (r *membershipRepository) FindByActivityID(activityID uint64) ([]domain.Membership, error) {
+	var memberships []domain.Membership
+
+	err := r.db.Preload("User").Where("activity_group_id = ?", activityID).Order("id").Find(&memberships).Error
+	if err != nil {
+		return memberships, translateError(err)
+	}
+
+	return memberships, nil
+}
+
+func (r *membershipRepository) FindOne(activityID uint64, userID uint64) (domain.Membership, error) {
+	var membership domain.Membership
+
+	err := r.db.Preload("User").Where("activity_group_id = ? AND user_id = ?", activityID, userID).Find(&membership).Error
+	if err != nil {
+		return membership, translateError(err)
+	}
+
+	if membership.ID == 0 {
+		return membership, apperror.NotFound("User with ID %d is not member of activity group with ID %d", userID, activityID)
+	}
+
+	return membership, nil
+}
+
+func (r *membershipRepository) CountByRole(activityID uint64, role string) (int64, error) {
+	var ids []uint64
+
+	// Count with FOR UPDATE is refused by PostgreSQL, so the locked rows are counted. SQLite
+	// lock the database instead
+	err := r.db.Model(&domain.Membership{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("activity_group_id = ? AND role = ?", activityID, role).Pluck("id", &ids).Error
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	return int64(len(ids)), nil
+}
+
+func (r *membershipRepository) Delete(membership domain.Membership) (bool, error) {
+	err := r.db.Delete(&domain.Membership{}, membership.ID).Error
+	if err != nil {
+		return false, translateError(err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// membershipMemoryRepository is MembershipRepository in memory, it is safe for concurrent use
+type membershipMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewRepositoryMembershipMemory(store *MemoryStore) *membershipMemoryRepository {
+	return &membershipMemoryRepository{store}
+}
+
+// withUser return copy of membership with its user, like preload
+func (r *membershipMemoryRepository) withUser(membership domain.Membership) domain.Membership {
+	membership = cloneMembership(membership)
+	membership.User = cloneUser(r.store.data.users[membership.UserID])
+	return membership
+}
+
+func (r *membershipMemoryRepository) Save(membership domain.Membership) (domain.Membership, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !domain.IsValidRole(membership.Role) {
+		return membership, errInvalidValue.Wrap(fmt.Errorf("role %s of memberships is not valid", membership.Role))
+	}
+	if _, ok := r.store.data.activities[membership.ActivityGroupID]; !ok {
+		return membership, errReferenceMissing.Wrap(fmt.Errorf("activity group %d of memberships is not exist", membership.ActivityGroupID))
+	}
+	if _, ok := r.store.data.users[membership.UserID]; !ok {
+		return membership, errReferenceMissing.Wrap(fmt.Errorf("user %d of memberships is not exist", membership.UserID))
+	}
+	// Activity group and user is unique index
+	if _, ok := r.store.data.findMembership(membership.ActivityGroupID, membership.UserID); ok {
+		return membership, errDuplicate.Wrap(fmt.Errorf("duplicate user %d of memberships", membership.UserID))
+	}
+
+	r.store.data.lastMemberID++
+	membership.ID = r.store.data.lastMemberID
+
+	now := time.Now()
+	if membership.CreatedAt == nil {
+		membership.CreatedAt = &now
+	}
+	if membership.UpdatedAt.IsZero() {
+		membership.UpdatedAt = now
+	}
+
+	r.store.data.memberships[membership.ID] = cloneMembership(membership)
+	return membership, nil
+}
+
+func (r *membershipMemoryRepository) FindByActivityID(activityID uint64) ([]domain.Membership, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	memberships := []domain.Membership{}
+	for _, membership := range r.store.data.memberships {
+		if membership.ActivityGroupID == activityID {
+			memberships = append(memberships, r.withUser(membership))
+		}
+	}
+
+	sort.Slice(memberships, func(i, j int) bool {
+		return memberships[i].ID < memberships[j].ID
+	})
+	return memberships, nil
+}
+
+func (r *membershipMemoryRepository) FindOne(activityID uint64, userID uint64) (domain.Membership, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	membership, ok := r.store.data.findMembership(activityID, userID)
+	if !ok {
+		return domain.Membership{}, apperror.NotFound("User with ID %d is not member of activity group with ID %d", userID, activityID)
+	}
+
+	return r.withUser(membership), nil
+}
+
+func (r *membershipMemoryRepository) CountByRole(activityID uint64, role string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var count int64
+	for _, membership := range r.store.data.memberships {
+		if membership.ActivityGroupID == activityID && membership.Role == role {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *membershipMemoryRepository) Delete(membership domain.Membership) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.data.memberships, membership.ID)
+	return true, nil
+}
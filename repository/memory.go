@@ -0,0 +1,360 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// MemoryStore hold rows of in-memory repositories. Repositories created with the
+// same store see the same data, like tables in one database
+type MemoryStore struct {
+	mu   sync.Mutex
+	data memoryData
+}
+
+type memoryData struct {
+	users                map[uint64]domain.User
+	apiKeys              map[uint64]domain.APIKey
+	activities           map[uint64]domain.Activity
+	memberships          map[uint64]domain.Membership
+	todos                map[uint64]domain.Todo
+	idempotencyKeys      map[uint64]domain.IdempotencyKey
+	webhooks             map[uint64]domain.Webhook
+	webhookDeliveries    map[uint64]domain.WebhookDelivery
+	outboxEvents         map[uint64]domain.OutboxEvent
+	lastUserID           uint64
+	lastAPIKeyID         uint64
+	lastActivityID       uint64
+	lastMemberID         uint64
+	lastTodoID           uint64
+	lastIdempotencyKeyID uint64
+	lastWebhookID        uint64
+	lastDeliveryID       uint64
+	lastOutboxEventID    uint64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: memoryData{
+		users:             map[uint64]domain.User{},
+		apiKeys:           map[uint64]domain.APIKey{},
+		activities:        map[uint64]domain.Activity{},
+		memberships:       map[uint64]domain.Membership{},
+		todos:             map[uint64]domain.Todo{},
+		idempotencyKeys:   map[uint64]domain.IdempotencyKey{},
+		webhooks:          map[uint64]domain.Webhook{},
+		webhookDeliveries: map[uint64]domain.WebhookDelivery{},
+		outboxEvents:      map[uint64]domain.OutboxEvent{},
+	}}
+}
+
+func (d memoryData) clone() memoryData {
+	result := d
+	result.users = make(map[uint64]domain.User, len(d.users))
+	for id, user := range d.users {
+		result.users[id] = cloneUser(user)
+	}
+	result.apiKeys = make(map[uint64]domain.APIKey, len(d.apiKeys))
+	for id, key := range d.apiKeys {
+		result.apiKeys[id] = cloneAPIKey(key)
+	}
+	result.activities = make(map[uint64]domain.Activity, len(d.activities))
+	for id, activity := range d.activities {
+		result.activities[id] = cloneActivity(activity)
+	}
+	result.memberships = make(map[uint64]domain.Membership, len(d.memberships))
+	for id, membership := range d.memberships {
+		result.memberships[id] = cloneMembership(membership)
+	}
+	result.todos = make(map[uint64]domain.Todo, len(d.todos))
+	for id, todo := range d.todos {
+		result.todos[id] = cloneTodo(todo)
+	}
+	result.idempotencyKeys = make(map[uint64]domain.IdempotencyKey, len(d.idempotencyKeys))
+	for id, key := range d.idempotencyKeys {
+		result.idempotencyKeys[id] = cloneIdempotencyKey(key)
+	}
+	result.webhooks = make(map[uint64]domain.Webhook, len(d.webhooks))
+	for id, webhook := range d.webhooks {
+		result.webhooks[id] = cloneWebhook(webhook)
+	}
+	result.webhookDeliveries = make(map[uint64]domain.WebhookDelivery, len(d.webhookDeliveries))
+	for id, delivery := range d.webhookDeliveries {
+		result.webhookDeliveries[id] = cloneWebhookDelivery(delivery)
+	}
+	result.outboxEvents = make(map[uint64]domain.OutboxEvent, len(d.outboxEvents))
+	for id, outboxEvent := range d.outboxEvents {
+		result.outboxEvents[id] = cloneOutboxEvent(outboxEvent)
+	}
+	return result
+}
+
+// isOwnedBy check user owner is member of activity group, owner 0 is not scoped
+func (d memoryData) isOwnedBy(activityID uint64, owner uint64) bool {
+	if owner == 0 {
+		return true
+	}
+	_, ok := d.findMembership(activityID, owner)
+	return ok
+}
+
+func (d memoryData) findMembership(activityID uint64, userID uint64) (domain.Membership, bool) {
+	for _, membership := range d.memberships {
+		if membership.ActivityGroupID == activityID && membership.UserID == userID {
+			return membership, true
+		}
+	}
+	return domain.Membership{}, false
+}
+
+// cloneTime copy value of pointer, so row in store is not changed by caller
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := *t
+	return &value
+}
+
+func cloneUser(user domain.User) domain.User {
+	user.CreatedAt = cloneTime(user.CreatedAt)
+	user.Activities = nil
+	user.APIKeys = nil
+	user.IdempotencyKeys = nil
+	user.Webhooks = nil
+	return user
+}
+
+func cloneAPIKey(key domain.APIKey) domain.APIKey {
+	key.LastUsedAt = cloneTime(key.LastUsedAt)
+	key.CreatedAt = cloneTime(key.CreatedAt)
+	return key
+}
+
+func cloneIdempotencyKey(key domain.IdempotencyKey) domain.IdempotencyKey {
+	key.CreatedAt = cloneTime(key.CreatedAt)
+	key.Body = append([]byte(nil), key.Body...)
+	return key
+}
+
+func cloneWebhook(webhook domain.Webhook) domain.Webhook {
+	webhook.CreatedAt = cloneTime(webhook.CreatedAt)
+	if webhook.ActivityGroupID != nil {
+		activityGroupID := *webhook.ActivityGroupID
+		webhook.ActivityGroupID = &activityGroupID
+	}
+	webhook.Deliveries = nil
+	return webhook
+}
+
+func cloneWebhookDelivery(delivery domain.WebhookDelivery) domain.WebhookDelivery {
+	delivery.DeliveredAt = cloneTime(delivery.DeliveredAt)
+	delivery.CreatedAt = cloneTime(delivery.CreatedAt)
+	delivery.Payload = append([]byte(nil), delivery.Payload...)
+	return delivery
+}
+
+func cloneOutboxEvent(outboxEvent domain.OutboxEvent) domain.OutboxEvent {
+	outboxEvent.NextAttemptAt = cloneTime(outboxEvent.NextAttemptAt)
+	outboxEvent.PublishedAt = cloneTime(outboxEvent.PublishedAt)
+	outboxEvent.CreatedAt = cloneTime(outboxEvent.CreatedAt)
+	outboxEvent.Payload = append([]byte(nil), outboxEvent.Payload...)
+	return outboxEvent
+}
+
+func cloneMembership(membership domain.Membership) domain.Membership {
+	membership.CreatedAt = cloneTime(membership.CreatedAt)
+	membership.User = domain.User{}
+	return membership
+}
+
+func cloneActivity(activity domain.Activity) domain.Activity {
+	activity.CreatedAt = cloneTime(activity.CreatedAt)
+	if activity.UserID != nil {
+		userID := *activity.UserID
+		activity.UserID = &userID
+	}
+	activity.Todos = nil
+	return activity
+}
+
+func cloneTodo(todo domain.Todo) domain.Todo {
+	todo.StartAt = cloneTime(todo.StartAt)
+	todo.DueAt = cloneTime(todo.DueAt)
+	todo.CreatedAt = cloneTime(todo.CreatedAt)
+	return todo
+}
+
+type memoryTransactor struct {
+	store *MemoryStore
+	owner uint64
+}
+
+func NewTransactorMemory(store *MemoryStore) *memoryTransactor {
+	return &memoryTransactor{store: store}
+}
+
+func (t *memoryTransactor) WithOwner(userID uint64) Transactor {
+	return &memoryTransactor{store: t.store, owner: userID}
+}
+
+// WithinTransaction run fn on a copy of the store and replace the store with it
+// if fn succeed. The store is locked until fn return, so transactions are serial
+func (t *memoryTransactor) WithinTransaction(fn func(tx Transaction) error) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	txStore := &MemoryStore{data: t.store.data.clone()}
+	err := fn(t.transaction(txStore))
+	if err != nil {
+		return err
+	}
+
+	t.store.data = txStore.data
+	return nil
+}
+
+// transaction return repositories of store, nested transaction run on a copy of it too
+func (t *memoryTransactor) transaction(store *MemoryStore) Transaction {
+	return Transaction{
+		Activity:   NewRepositoryActivityMemory(store).WithOwner(t.owner),
+		Todo:       NewRepositoryTodoMemory(store).WithOwner(t.owner),
+		Membership: NewRepositoryMembershipMemory(store),
+		Outbox:     NewRepositoryOutboxMemory(store),
+		nested: func(fn func(tx Transaction) error) error {
+			nestedStore := &MemoryStore{data: store.data.clone()}
+			err := fn(t.transaction(nestedStore))
+			if err != nil {
+				return err
+			}
+
+			store.data = nestedStore.data
+			return nil
+		},
+	}
+}
+
+// paginateMemory sort rows and apply cursor, limit and offset the same as paginate.
+// values return value of a row for each column of columns
+func paginateMemory[T any](rows []T, columns map[string]sortColumn, values map[string]func(T) interface{}, id func(T) uint64, sortBy string, page Page) ([]T, error) {
+	name := strings.TrimPrefix(sortBy, "-")
+	if name == "" {
+		name = "id"
+	}
+	column, ok := columns[name]
+	if !ok {
+		return nil, errSortNotSupported(sortBy)
+	}
+	value := values[name]
+	descending := strings.HasPrefix(sortBy, "-")
+
+	// order compare by the column then by id as tie breaker
+	order := func(aValue interface{}, aID uint64, bValue interface{}, bID uint64) int {
+		result := compareValues(aValue, bValue)
+		if result == 0 {
+			result = compareValues(aID, bID)
+		}
+		if descending {
+			result = -result
+		}
+		return result
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return order(value(rows[i]), id(rows[i]), value(rows[j]), id(rows[j])) < 0
+	})
+
+	if page.After != nil {
+		if page.After.Sort != sortBy {
+			return nil, ErrInvalidCursor
+		}
+
+		var after interface{} = page.After.ID
+		if name != "id" {
+			if column.cursorValue == nil {
+				return nil, ErrInvalidCursor
+			}
+			cursorValue, err := column.cursorValue(page.After.Value)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			after = cursorValue
+		}
+
+		var next []T
+		for _, row := range rows {
+			if order(value(row), id(row), after, page.After.ID) > 0 {
+				next = append(next, row)
+			}
+		}
+		rows = next
+	}
+
+	if page.Offset > 0 && page.After == nil {
+		if page.Offset >= len(rows) {
+			return []T{}, nil
+		}
+		rows = rows[page.Offset:]
+	}
+	if page.Limit > 0 && page.Limit < len(rows) {
+		rows = rows[:page.Limit]
+	}
+
+	return rows, nil
+}
+
+// compareValues compare value of a column, nil is NULL and it is the lowest like
+// in mysql and sqlite. String is compared by byte, not by collation of database
+func compareValues(a, b interface{}) int {
+	a, b = derefTime(a), derefTime(b)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch a := a.(type) {
+	case int:
+		return compareOrdered(a, b.(int))
+	case uint64:
+		return compareOrdered(a, b.(uint64))
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		b := b.(time.Time)
+		if a.Before(b) {
+			return -1
+		}
+		if a.After(b) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func compareOrdered[T int | uint64](a, b T) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// derefTime return time of pointer, or untyped nil for nil pointer
+func derefTime(value interface{}) interface{} {
+	if t, ok := value.(*time.Time); ok {
+		if t == nil {
+			return nil
+		}
+		return *t
+	}
+	return value
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Save(outboxEvent domain.OutboxEvent) (domain.OutboxEvent, error)
+	// FindDue return pending events due at at, oldest first. Only the oldest pending event
+	// of each aggregate is returned, so failed event hold back later events of its aggregate
+	// but not events of other aggregates
+	FindDue(at time.Time, limit int) ([]domain.OutboxEvent, error)
+	// Update store status and result of attempt of event
+	Update(outboxEvent domain.OutboxEvent) (domain.OutboxEvent, error)
+	// DeletePublished delete events published before before, and return how many are deleted
+	DeletePublished(before time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewRepositoryOutbox(db *gorm.DB) *outboxRepository {
+	return &outboxRepository{db}
+}
+
+func (r *outboxRepository) Save(outboxEvent domain.OutboxEvent) (domain.OutboxEvent, error) {
+	if outboxEvent.Status == "" {
+		outboxEvent.Status = domain.OutboxPending
+	}
+
+	err := r.db.Create(&outboxEvent).Error
+	if err != nil {
+		return outboxEvent, translateError(err)
+	}
+
+	return outboxEvent, nil
+}
+
+func (r *outboxRepository) FindDue(at time.Time, limit int) ([]domain.OutboxEvent, error) {
+	var outboxEvents []domain.OutboxEvent
+
+	err := r.db.Where("status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", domain.OutboxPending, at).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_events earlier WHERE earlier.status = ? AND earlier.aggregate_type = outbox_events.aggregate_type
+			AND earlier.aggregate_id = outbox_events.aggregate_id AND earlier.id < outbox_events.id)`, domain.OutboxPending).
+		Order("id").Limit(limit).Find(&outboxEvents).Error
+	if err != nil {
+		return outboxEvents, translateError(err)
+	}
+
+	return outboxEvents, nil
+}
+
+func (r *outboxRepository) Update(outboxEvent domain.OutboxEvent) (domain.OutboxEvent, error) {
+	err := r.db.Model(&outboxEvent).
+		Select("status", "attempts", "sent_sinks", "last_error", "next_attempt_at", "published_at").
+		Updates(&outboxEvent).Error
+	if err != nil {
+		return outboxEvent, translateError(err)
+	}
+
+	return outboxEvent, nil
+}
+
+func (r *outboxRepository) DeletePublished(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND published_at < ?", domain.OutboxPublished, before).Delete(&domain.OutboxEvent{})
+	if result.Error != nil {
+		return 0, translateError(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// outboxMemoryRepository is OutboxRepository in memory, it is safe for concurrent use
+type outboxMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewRepositoryOutboxMemory(store *MemoryStore) *outboxMemoryRepository {
+	return &outboxMemoryRepository{store}
+}
+
+// outboxAggregate identify todo or activity group of outbox event
+type outboxAggregate struct {
+	aggregateType string
+	aggregateID   uint64
+}
+
+func (r *outboxMemoryRepository) Save(outboxEvent domain.OutboxEvent) (domain.OutboxEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.data.lastOutboxEventID++
+	outboxEvent.ID = r.store.data.lastOutboxEventID
+
+	if outboxEvent.Status == "" {
+		outboxEvent.Status = domain.OutboxPending
+	}
+	if outboxEvent.CreatedAt == nil {
+		now := time.Now()
+		outboxEvent.CreatedAt = &now
+	}
+
+	r.store.data.outboxEvents[outboxEvent.ID] = cloneOutboxEvent(outboxEvent)
+	return cloneOutboxEvent(outboxEvent), nil
+}
+
+func (r *outboxMemoryRepository) FindDue(at time.Time, limit int) ([]domain.OutboxEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// The oldest pending event of each aggregate
+	oldest := map[outboxAggregate]domain.OutboxEvent{}
+	for _, outboxEvent := range r.store.data.outboxEvents {
+		if outboxEvent.Status != domain.OutboxPending {
+			continue
+		}
+		aggregate := outboxAggregate{outboxEvent.AggregateType, outboxEvent.AggregateID}
+		if found, ok := oldest[aggregate]; !ok || outboxEvent.ID < found.ID {
+			oldest[aggregate] = outboxEvent
+		}
+	}
+
+	outboxEvents := []domain.OutboxEvent{}
+	for _, outboxEvent := range oldest {
+		if outboxEvent.NextAttemptAt == nil || !outboxEvent.NextAttemptAt.After(at) {
+			outboxEvents = append(outboxEvents, cloneOutboxEvent(outboxEvent))
+		}
+	}
+
+	sort.Slice(outboxEvents, func(i, j int) bool {
+		return outboxEvents[i].ID < outboxEvents[j].ID
+	})
+	if limit > 0 && limit < len(outboxEvents) {
+		outboxEvents = outboxEvents[:limit]
+	}
+	return outboxEvents, nil
+}
+
+func (r *outboxMemoryRepository) Update(outboxEvent domain.OutboxEvent) (domain.OutboxEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.data.outboxEvents[outboxEvent.ID]
+	if ok {
+		stored.Status = outboxEvent.Status
+		stored.Attempts = outboxEvent.Attempts
+		stored.SentSinks = outboxEvent.SentSinks
+		stored.LastError = outboxEvent.LastError
+		stored.NextAttemptAt = outboxEvent.NextAttemptAt
+		stored.PublishedAt = outboxEvent.PublishedAt
+		r.store.data.outboxEvents[outboxEvent.ID] = cloneOutboxEvent(stored)
+	}
+
+	return outboxEvent, nil
+}
+
+func (r *outboxMemoryRepository) DeletePublished(before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var count int64
+	for id, outboxEvent := range r.store.data.outboxEvents {
+		if outboxEvent.Status == domain.OutboxPublished && outboxEvent.PublishedAt != nil && outboxEvent.PublishedAt.Before(before) {
+			delete(r.store.data.outboxEvents, id)
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor returned when cursor cannot be decoded or not match the sort
+var ErrInvalidCursor = apperror.Validation("cursor is invalid").WithCode("invalid_cursor")
+
+// Page hold size and position of a paginated find, zero Limit mean no limit
+type Page struct {
+	Limit  int
+	Offset int
+	// After continue find after the cursor, Offset is ignored when it is set
+	After *Cursor
+}
+
+// Cursor point to the last row of a page by the value of the sort column and the id
+type Cursor struct {
+	Sort  string `json:"s,omitempty"`
+	Value string `json:"v,omitempty"`
+	ID    uint64 `json:"id"`
+}
+
+// EncodeCursor encode cursor to opaque string for client
+func EncodeCursor(cursor Cursor) string {
+	value, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+// DecodeCursor decode opaque string from EncodeCursor
+func DecodeCursor(value string) (Cursor, error) {
+	var cursor Cursor
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	err = json.Unmarshal(decoded, &cursor)
+	if err != nil || cursor.ID == 0 {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// sortColumn is a column can be used for sort, cursorValue convert Cursor.Value
+// to the column type and nil mean the column cannot be used with cursor
+type sortColumn struct {
+	expression  string
+	cursorValue func(value string) (interface{}, error)
+}
+
+func stringCursorValue(value string) (interface{}, error) {
+	return value, nil
+}
+
+func intCursorValue(value string) (interface{}, error) {
+	return strconv.Atoi(value)
+}
+
+func timeCursorValue(value string) (interface{}, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// isValidSort check sort is one of columns, with optional prefix "-" for descending
+func isValidSort(columns map[string]sortColumn, sort string) bool {
+	if sort == "" {
+		return true
+	}
+	_, ok := columns[strings.TrimPrefix(sort, "-")]
+	return ok
+}
+
+// isCursorSort check sort can be paginated with cursor
+func isCursorSort(columns map[string]sortColumn, sort string) bool {
+	if sort == "" {
+		return true
+	}
+	column, ok := columns[strings.TrimPrefix(sort, "-")]
+	return ok && column.cursorValue != nil
+}
+
+func errSortNotSupported(sort string) error {
+	return apperror.Validation("sort %s is not supported", sort).WithCode("sort_not_supported")
+}
+
+// paginate apply order, cursor, limit and offset to query. Sort default is by id
+// and id is always used as tie breaker, so the order of a page is stable
+func paginate(query *gorm.DB, columns map[string]sortColumn, sort string, page Page) (*gorm.DB, error) {
+	name := strings.TrimPrefix(sort, "-")
+	if name == "" {
+		name = "id"
+	}
+	column, ok := columns[name]
+	if !ok {
+		return query, errSortNotSupported(sort)
+	}
+
+	direction, operator := "ASC", ">"
+	if strings.HasPrefix(sort, "-") {
+		direction, operator = "DESC", "<"
+	}
+
+	if page.After != nil {
+		if page.After.Sort != sort {
+			return query, ErrInvalidCursor
+		}
+
+		if name == "id" {
+			query = query.Where("id "+operator+" ?", page.After.ID)
+		} else {
+			if column.cursorValue == nil {
+				return query, ErrInvalidCursor
+			}
+			value, err := column.cursorValue(page.After.Value)
+			if err != nil {
+				return query, ErrInvalidCursor
+			}
+			condition := fmt.Sprintf("((%[1]s %[2]s ?) OR (%[1]s = ? AND id %[2]s ?))", column.expression, operator)
+			query = query.Where(condition, value, value, page.After.ID)
+		}
+	}
+
+	query = query.Order(column.expression + " " + direction)
+	if name != "id" {
+		query = query.Order("id " + direction)
+	}
+
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if page.Offset > 0 && page.After == nil {
+		query = query.Offset(page.Offset)
+	}
+
+	return query, nil
+}
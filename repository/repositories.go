@@ -0,0 +1,49 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Repositories are all repositories of one store, router is built from them
+type Repositories struct {
+	User            UserRepository
+	APIKey          APIKeyRepository
+	Activity        ActivityRepository
+	Membership      MembershipRepository
+	Todo            TodoRepository
+	Transactor      Transactor
+	IdempotencyKey  IdempotencyKeyRepository
+	Webhook         WebhookRepository
+	WebhookDelivery WebhookDeliveryRepository
+	Outbox          OutboxRepository
+}
+
+// NewRepositories return repositories of database db
+func NewRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		User:            NewRepositoryUser(db),
+		APIKey:          NewRepositoryAPIKey(db),
+		Activity:        NewRepositoryActivity(db),
+		Membership:      NewRepositoryMembership(db),
+		Todo:            NewRepositoryTodo(db),
+		Transactor:      NewTransactor(db),
+		IdempotencyKey:  NewRepositoryIdempotencyKey(db),
+		Webhook:         NewRepositoryWebhook(db),
+		WebhookDelivery: NewRepositoryWebhookDelivery(db),
+		Outbox:          NewRepositoryOutbox(db),
+	}
+}
+
+// NewRepositoriesMemory return in-memory repositories of store
+func NewRepositoriesMemory(store *MemoryStore) Repositories {
+	return Repositories{
+		User:            NewRepositoryUserMemory(store),
+		APIKey:          NewRepositoryAPIKeyMemory(store),
+		Activity:        NewRepositoryActivityMemory(store),
+		Membership:      NewRepositoryMembershipMemory(store),
+		Todo:            NewRepositoryTodoMemory(store),
+		Transactor:      NewTransactorMemory(store),
+		IdempotencyKey:  NewRepositoryIdempotencyKeyMemory(store),
+		Webhook:         NewRepositoryWebhookMemory(store),
+		WebhookDelivery: NewRepositoryWebhookDeliveryMemory(store),
+		Outbox:          NewRepositoryOutboxMemory(store),
+	}
+}
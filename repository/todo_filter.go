@@ -0,0 +1,564 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// maxFilterLength and maxFilterDepth limit expression parsed by ParseTodoFilter
+const (
+	maxFilterLength = 2000
+	maxFilterDepth  = 20
+)
+
+// FilterError is error of expression parsed by ParseTodoFilter, Position is position
+// of the wrong rune from 1
+type FilterError struct {
+	Position int
+	Message  string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Position)
+}
+
+// FilterExpression is condition of todos parsed by ParseTodoFilter
+type FilterExpression interface {
+	// where return SQL condition with its vars, column is from todoFilterFields only
+	where() (string, []interface{})
+	// match check todo match the condition like where, for memory repository
+	match(todo domain.Todo) bool
+}
+
+type filterKind int
+
+const (
+	filterString filterKind = iota
+	filterBool
+	filterInt
+	filterPriority
+	filterTime
+)
+
+// Operators can be used with each kind of field
+var filterOperators = map[filterKind][]string{
+	filterString:   {"=", "!=", "~", "in"},
+	filterBool:     {"=", "!="},
+	filterInt:      {"=", "!=", "<", "<=", ">", ">=", "in"},
+	filterPriority: {"=", "!=", "in"},
+	filterTime:     {"=", "!=", "<", "<=", ">", ">="},
+}
+
+type filterField struct {
+	kind     filterKind
+	nullable bool
+	// value of the field of todo, nil is NULL
+	value func(todo domain.Todo) interface{}
+}
+
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// Field can be used in filter expression, the name is its column
+var todoFilterFields = map[string]filterField{
+	"id":                {kind: filterInt, value: func(t domain.Todo) interface{} { return t.ID }},
+	"activity_group_id": {kind: filterInt, value: func(t domain.Todo) interface{} { return t.ActivityGroupID }},
+	"title":             {kind: filterString, value: func(t domain.Todo) interface{} { return t.Title }},
+	"is_active":         {kind: filterBool, value: func(t domain.Todo) interface{} { return t.IsActive }},
+	"priority":          {kind: filterPriority, value: func(t domain.Todo) interface{} { return t.Priority }},
+	"start_at":          {kind: filterTime, nullable: true, value: func(t domain.Todo) interface{} { return timeValue(t.StartAt) }},
+	"due_at":            {kind: filterTime, nullable: true, value: func(t domain.Todo) interface{} { return timeValue(t.DueAt) }},
+	"created_at":        {kind: filterTime, value: func(t domain.Todo) interface{} { return timeValue(t.CreatedAt) }},
+	"updated_at":        {kind: filterTime, value: func(t domain.Todo) interface{} { return t.UpdatedAt }},
+}
+
+// TodoFilterFields list fields can be used in filter expression
+var TodoFilterFields = []string{"id", "activity_group_id", "title", "is_active", "priority", "start_at", "due_at", "created_at", "updated_at"}
+
+type andFilter struct {
+	left, right FilterExpression
+}
+
+func (f andFilter) where() (string, []interface{}) {
+	left, leftVars := f.left.where()
+	right, rightVars := f.right.where()
+	return "(" + left + " AND " + right + ")", append(leftVars, rightVars...)
+}
+
+func (f andFilter) match(todo domain.Todo) bool {
+	return f.left.match(todo) && f.right.match(todo)
+}
+
+type orFilter struct {
+	left, right FilterExpression
+}
+
+func (f orFilter) where() (string, []interface{}) {
+	left, leftVars := f.left.where()
+	right, rightVars := f.right.where()
+	return "(" + left + " OR " + right + ")", append(leftVars, rightVars...)
+}
+
+func (f orFilter) match(todo domain.Todo) bool {
+	return f.left.match(todo) || f.right.match(todo)
+}
+
+type notFilter struct {
+	expression FilterExpression
+}
+
+func (f notFilter) where() (string, []interface{}) {
+	sql, vars := f.expression.where()
+	return "NOT " + sql, vars
+}
+
+func (f notFilter) match(todo domain.Todo) bool {
+	return !f.expression.match(todo)
+}
+
+// comparisonFilter compare field with values, nil values is NULL
+type comparisonFilter struct {
+	field    string
+	operator string
+	values   []interface{}
+}
+
+// Operator of SQL for the operators compare two values
+var sqlOperators = map[string]string{"=": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+
+// Every condition is true or false, never NULL, so NOT of it is the same in SQL and memory
+func (f comparisonFilter) where() (string, []interface{}) {
+	column := f.field
+	if f.values == nil {
+		if f.operator == "=" {
+			return "(" + column + " IS NULL)", nil
+		}
+		return "(" + column + " IS NOT NULL)", nil
+	}
+
+	var sql string
+	vars := f.values
+	switch f.operator {
+	case "~":
+		sql = "LOWER(" + column + ") LIKE ? ESCAPE '!'"
+		vars = []interface{}{containsPattern(f.values[0].(string))}
+	case "in":
+		sql = column + " IN ?"
+		vars = []interface{}{f.values}
+	default:
+		sql = column + " " + sqlOperators[f.operator] + " ?"
+	}
+
+	if !todoFilterFields[f.field].nullable {
+		return "(" + sql + ")", vars
+	}
+	if f.operator == "!=" {
+		return "(" + column + " IS NULL OR " + sql + ")", vars
+	}
+	return "(" + column + " IS NOT NULL AND " + sql + ")", vars
+}
+
+func (f comparisonFilter) match(todo domain.Todo) bool {
+	value := todoFilterFields[f.field].value(todo)
+	if f.values == nil {
+		return (value == nil) == (f.operator == "=")
+	}
+	if value == nil {
+		return f.operator == "!="
+	}
+
+	switch f.operator {
+	case "~":
+		return strings.Contains(strings.ToLower(value.(string)), strings.ToLower(f.values[0].(string)))
+	case "in":
+		for _, v := range f.values {
+			if compareFilterValue(value, v) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	c := compareFilterValue(value, f.values[0])
+	switch f.operator {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// compareFilterValue compare values of the same field, -1 if a is less than b
+func compareFilterValue(a interface{}, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case uint64:
+		switch b := b.(uint64); {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case bool:
+		if a != b.(bool) {
+			return 1
+		}
+	case time.Time:
+		b := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+	}
+	return 0
+}
+
+// containsPattern return pattern of LIKE for lowercase text contains value, escaped by !
+func containsPattern(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return "%" + replacer.Replace(strings.ToLower(value)) + "%"
+}
+
+type filterTokenKind int
+
+const (
+	tokenEOF filterTokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+type filterToken struct {
+	kind  filterTokenKind
+	text  string
+	start int
+}
+
+// tokenizeFilter split expression to tokens, start of token is position of rune from 1
+func tokenizeFilter(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{tokenOpen, "(", start})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{tokenClose, ")", start})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{tokenComma, ",", start})
+			i++
+		case r == '~' || r == '=':
+			tokens = append(tokens, filterToken{tokenOperator, string(r), start})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			operator := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				operator += "="
+			}
+			if operator == "!" {
+				return nil, &FilterError{start, `unexpected "!", use "!=" or not`}
+			}
+			tokens = append(tokens, filterToken{tokenOperator, operator, start})
+			i += len(operator)
+		case r == '"' || r == '\'':
+			// Quoted string, quote inside is escaped by backslash
+			var b strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, &FilterError{start, "string is not closed"}
+			}
+			tokens = append(tokens, filterToken{tokenString, b.String(), start})
+			i++
+		default:
+			// Word is anything until space, parenthesis, comma, quote or operator
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`(),"'~=!<>`, runes[end]) {
+				end++
+			}
+			tokens = append(tokens, filterToken{tokenWord, string(runes[i:end]), start})
+			i = end
+		}
+	}
+
+	return append(tokens, filterToken{tokenEOF, "", len(runes) + 1}), nil
+}
+
+// filterParser parse tokens with grammar:
+//
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" or ")" | comparison
+//	comparison = field operator value | field "in" "(" value { "," value } ")"
+type filterParser struct {
+	tokens []filterToken
+	next   int
+	depth  int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.next]
+}
+
+func (p *filterParser) take() filterToken {
+	token := p.tokens[p.next]
+	if token.kind != tokenEOF {
+		p.next++
+	}
+	return token
+}
+
+// isKeyword check token is word keyword, case insensitive
+func isKeyword(token filterToken, keyword string) bool {
+	return token.kind == tokenWord && strings.EqualFold(token.text, keyword)
+}
+
+func unexpected(token filterToken, expected string) error {
+	if token.kind == tokenEOF {
+		return &FilterError{token.start, "expected " + expected + " but expression ended"}
+	}
+	return &FilterError{token.start, fmt.Sprintf("expected %s but found %q", expected, token.text)}
+}
+
+func (p *filterParser) parseOr() (FilterExpression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for isKeyword(p.peek(), "or") {
+		p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (FilterExpression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for isKeyword(p.peek(), "and") {
+		p.take()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (FilterExpression, error) {
+	token := p.peek()
+	if p.depth == maxFilterDepth {
+		return nil, &FilterError{token.start, fmt.Sprintf("expression is nested more than %d times", maxFilterDepth)}
+	}
+	p.depth++
+	defer func() { p.depth-- }()
+
+	switch {
+	case isKeyword(token, "not"):
+		p.take()
+		expression, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{expression}, nil
+	case token.kind == tokenOpen:
+		p.take()
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenClose {
+			return nil, unexpected(p.peek(), `")"`)
+		}
+		p.take()
+		return expression, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *filterParser) parseComparison() (FilterExpression, error) {
+	token := p.take()
+	if token.kind != tokenWord {
+		return nil, unexpected(token, "field")
+	}
+	name := strings.ToLower(token.text)
+	field, ok := todoFilterFields[name]
+	if !ok {
+		return nil, &FilterError{token.start, fmt.Sprintf("unknown field %q, field must be one of %s", token.text, strings.Join(TodoFilterFields, ", "))}
+	}
+
+	token = p.take()
+	operator := token.text
+	if isKeyword(token, "in") {
+		operator = "in"
+	} else if token.kind != tokenOperator {
+		return nil, unexpected(token, "operator")
+	}
+	if !containsOperator(filterOperators[field.kind], operator) {
+		return nil, &FilterError{token.start, fmt.Sprintf("operator of %s must be one of %s", name, strings.Join(filterOperators[field.kind], ", "))}
+	}
+
+	if operator != "in" {
+		token = p.take()
+		if isKeyword(token, "null") {
+			if !field.nullable || (operator != "=" && operator != "!=") {
+				return nil, &FilterError{token.start, fmt.Sprintf("%s cannot be compared with null", name)}
+			}
+			return comparisonFilter{name, operator, nil}, nil
+		}
+
+		value, err := parseFilterValue(field, name, token)
+		if err != nil {
+			return nil, err
+		}
+		return comparisonFilter{name, operator, []interface{}{value}}, nil
+	}
+
+	if p.peek().kind != tokenOpen {
+		return nil, unexpected(p.peek(), `"("`)
+	}
+	p.take()
+
+	var values []interface{}
+	for {
+		value, err := parseFilterValue(field, name, p.take())
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		token = p.take()
+		if token.kind == tokenClose {
+			return comparisonFilter{name, operator, values}, nil
+		}
+		if token.kind != tokenComma {
+			return nil, unexpected(token, `"," or ")"`)
+		}
+	}
+}
+
+func containsOperator(operators []string, operator string) bool {
+	for _, o := range operators {
+		if o == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFilterValue convert token to value of field
+func parseFilterValue(field filterField, name string, token filterToken) (interface{}, error) {
+	if token.kind != tokenWord && token.kind != tokenString {
+		return nil, unexpected(token, "value")
+	}
+
+	invalid := func(expected string) error {
+		return &FilterError{token.start, fmt.Sprintf("value %q of %s must be %s", token.text, name, expected)}
+	}
+
+	switch field.kind {
+	case filterBool:
+		value, err := strconv.ParseBool(token.text)
+		if err != nil {
+			return nil, invalid("true or false")
+		}
+		return value, nil
+	case filterInt:
+		value, err := strconv.ParseUint(token.text, 10, 64)
+		if err != nil {
+			return nil, invalid("a number")
+		}
+		return value, nil
+	case filterPriority:
+		if !domain.IsValidPriority(token.text) {
+			return nil, invalid("one of " + strings.Join(domain.Priorities, ", "))
+		}
+		return token.text, nil
+	case filterTime:
+		value, err := time.Parse(time.RFC3339, token.text)
+		if err != nil {
+			value, err = time.Parse("2006-01-02", token.text)
+		}
+		if err != nil {
+			return nil, invalid("RFC3339 time or date")
+		}
+		return value, nil
+	default:
+		return token.text, nil
+	}
+}
+
+// ParseTodoFilter parse expression of filter of todos, ex:
+//
+//	is_active = true and priority in (high, very-high) and title ~ "report"
+//
+// Error is *FilterError
+func ParseTodoFilter(expression string) (FilterExpression, error) {
+	if utf8.RuneCountInString(expression) > maxFilterLength {
+		return nil, &FilterError{maxFilterLength + 1, fmt.Sprintf("expression is longer than %d characters", maxFilterLength)}
+	}
+
+	tokens, err := tokenizeFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &filterParser{tokens: tokens}
+	result, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.peek().kind != tokenEOF {
+		return nil, unexpected(parser.peek(), `"and", "or" or end of expression`)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,395 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+// Value of todo for each column of todoSortColumns
+var todoMemoryValues = map[string]func(domain.Todo) interface{}{
+	"id":         func(t domain.Todo) interface{} { return t.ID },
+	"created_at": func(t domain.Todo) interface{} { return t.CreatedAt },
+	"title":      func(t domain.Todo) interface{} { return t.Title },
+	"priority":   func(t domain.Todo) interface{} { return priorityRank(t.Priority) },
+	"due_at":     func(t domain.Todo) interface{} { return t.DueAt },
+}
+
+// todoMemoryRepository is TodoRepository in memory, it is safe for concurrent use
+type todoMemoryRepository struct {
+	store *MemoryStore
+	owner uint64
+}
+
+func NewRepositoryTodoMemory(store *MemoryStore) *todoMemoryRepository {
+	return &todoMemoryRepository{store: store}
+}
+
+func (r *todoMemoryRepository) WithOwner(userID uint64) TodoRepository {
+	return &todoMemoryRepository{store: r.store, owner: userID}
+}
+
+// isOwned check todo with id is exist and its activity group is owned by the owner
+func (r *todoMemoryRepository) isOwned(id uint64) bool {
+	todo, ok := r.store.data.todos[id]
+	return ok && r.store.data.isOwnedBy(todo.ActivityGroupID, r.owner)
+}
+
+// checkOwner return not found error if activity group is not owned by the owner
+func (r *todoMemoryRepository) checkOwner(activityID uint64) error {
+	if !r.store.data.isOwnedBy(activityID, r.owner) {
+		return apperror.NotFound("Activity with ID %d Not Found", activityID)
+	}
+	return nil
+}
+
+// find return todos of the owner matched by match ordered by id
+func (r *todoMemoryRepository) find(match func(domain.Todo) bool) []domain.Todo {
+	todos := []domain.Todo{}
+	for _, todo := range r.store.data.todos {
+		if match(todo) && r.store.data.isOwnedBy(todo.ActivityGroupID, r.owner) {
+			todos = append(todos, cloneTodo(todo))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		return todos[i].ID < todos[j].ID
+	})
+	return todos
+}
+
+func isTodoFound(todo domain.Todo) bool {
+	return !todo.DeletedAt.Valid
+}
+
+func isTodoTrashed(todo domain.Todo) bool {
+	return todo.DeletedAt.Valid
+}
+
+// check return error when todo break constraint of table todos
+func (r *todoMemoryRepository) check(todo domain.Todo) error {
+	if !domain.IsValidPriority(todo.Priority) {
+		return errInvalidValue.Wrap(fmt.Errorf("priority %s of todos is not valid", todo.Priority))
+	}
+
+	// Foreign key to activity group, deleted activity group is still exist
+	_, ok := r.store.data.activities[todo.ActivityGroupID]
+	if !ok {
+		return errReferenceMissing.Wrap(fmt.Errorf("activity group %d of todos is not exist", todo.ActivityGroupID))
+	}
+
+	return nil
+}
+
+// matchFilter check todo match condition of filter, except sort and page
+func matchFilter(filter TodoFilter) func(domain.Todo) bool {
+	return func(todo domain.Todo) bool {
+		if !isTodoFound(todo) {
+			return false
+		}
+		if filter.ActivityGroupID != 0 && todo.ActivityGroupID != filter.ActivityGroupID {
+			return false
+		}
+		if len(filter.IDs) != 0 {
+			found := false
+			for _, id := range filter.IDs {
+				found = found || todo.ID == id
+			}
+			if !found {
+				return false
+			}
+		}
+		if len(filter.Priorities) != 0 {
+			found := false
+			for _, priority := range filter.Priorities {
+				found = found || todo.Priority == priority
+			}
+			if !found {
+				return false
+			}
+		}
+		if !filter.DueBefore.IsZero() && (todo.DueAt == nil || !todo.DueAt.Before(filter.DueBefore)) {
+			return false
+		}
+		if !filter.DueAfter.IsZero() && (todo.DueAt == nil || !todo.DueAt.After(filter.DueAfter)) {
+			return false
+		}
+		if !filter.OverdueAt.IsZero() && !todo.IsOverdue(filter.OverdueAt) {
+			return false
+		}
+		if filter.IsActive != nil && todo.IsActive != *filter.IsActive {
+			return false
+		}
+		if !filter.CreatedBefore.IsZero() && (todo.CreatedAt == nil || !todo.CreatedAt.Before(filter.CreatedBefore)) {
+			return false
+		}
+		if !filter.CreatedAfter.IsZero() && (todo.CreatedAt == nil || !todo.CreatedAt.After(filter.CreatedAfter)) {
+			return false
+		}
+		if filter.TitleContains != "" && !strings.Contains(strings.ToLower(todo.Title), strings.ToLower(filter.TitleContains)) {
+			return false
+		}
+		if filter.Expression != nil && !filter.Expression.match(todo) {
+			return false
+		}
+		return true
+	}
+}
+
+func (r *todoMemoryRepository) Save(todo domain.Todo) (domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Default value of columns, gorm set them when the field is zero
+	if !todo.IsActive {
+		todo.IsActive = true
+	}
+	if todo.Priority == "" {
+		todo.Priority = domain.PriorityVeryHigh
+	}
+	todo.Version = 1
+
+	err := r.checkOwner(todo.ActivityGroupID)
+	if err != nil {
+		return todo, err
+	}
+
+	err = r.check(todo)
+	if err != nil {
+		return todo, err
+	}
+
+	if todo.ID == 0 {
+		r.store.data.lastTodoID++
+		todo.ID = r.store.data.lastTodoID
+	} else if _, ok := r.store.data.todos[todo.ID]; ok {
+		return todo, errDuplicate.Wrap(fmt.Errorf("duplicate id %d of todos", todo.ID))
+	}
+	if todo.ID > r.store.data.lastTodoID {
+		r.store.data.lastTodoID = todo.ID
+	}
+
+	now := time.Now()
+	if todo.CreatedAt == nil {
+		todo.CreatedAt = &now
+	}
+	if todo.UpdatedAt.IsZero() {
+		todo.UpdatedAt = now
+	}
+
+	r.store.data.todos[todo.ID] = cloneTodo(todo)
+	return cloneTodo(todo), nil
+}
+
+func (r *todoMemoryRepository) FindAll() ([]domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.find(isTodoFound), nil
+}
+
+func (r *todoMemoryRepository) FindByActivityID(ActivityID uint64) ([]domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.find(matchFilter(TodoFilter{ActivityGroupID: ActivityID})), nil
+}
+
+func (r *todoMemoryRepository) FindByFilter(filter TodoFilter) ([]domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := func(t domain.Todo) uint64 { return t.ID }
+	return paginateMemory(r.find(matchFilter(filter)), todoSortColumns, todoMemoryValues, id, filter.Sort, filter.Page)
+}
+
+func (r *todoMemoryRepository) CountByFilter(filter TodoFilter) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return int64(len(r.find(matchFilter(filter)))), nil
+}
+
+func (r *todoMemoryRepository) FindOne(id uint64) (domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	todo, ok := r.store.data.todos[id]
+	if !ok || !isTodoFound(todo) || !r.isOwned(id) {
+		return domain.Todo{}, apperror.NotFound("Todo with ID %d Not Found", id)
+	}
+
+	return cloneTodo(todo), nil
+}
+
+func (r *todoMemoryRepository) Update(todo domain.Todo) (domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Todo of other user is not found, instead of inserted
+	if r.owner != 0 && !r.isOwned(todo.ID) {
+		return todo, apperror.NotFound("Todo with ID %d Not Found", todo.ID)
+	}
+
+	err := r.checkOwner(todo.ActivityGroupID)
+	if err != nil {
+		return todo, err
+	}
+
+	err = r.check(todo)
+	if err != nil {
+		return todo, err
+	}
+
+	// Update only the version read, version 0 update whatever version is stored
+	stored, ok := r.store.data.todos[todo.ID]
+	if !ok || !isTodoFound(stored) || (todo.Version != 0 && todo.Version != stored.Version) {
+		return todo, versionConflict("Todo", todo.ID)
+	}
+	todo.Version = stored.Version + 1
+	todo.UpdatedAt = time.Now()
+
+	r.store.data.todos[todo.ID] = cloneTodo(todo)
+	return cloneTodo(todo), nil
+}
+
+func (r *todoMemoryRepository) Delete(todo domain.Todo) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.data.todos[todo.ID]
+	if ok && isTodoFound(stored) && r.isOwned(stored.ID) {
+		if todo.Version != 0 && todo.Version != stored.Version {
+			return false, versionConflict("Todo", todo.ID)
+		}
+		stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		r.store.data.todos[stored.ID] = stored
+	}
+
+	return true, nil
+}
+
+func (r *todoMemoryRepository) FindTrashed() ([]domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	todos := r.find(isTodoTrashed)
+	sort.SliceStable(todos, func(i, j int) bool {
+		return todos[i].DeletedAt.Time.After(todos[j].DeletedAt.Time)
+	})
+	return todos, nil
+}
+
+func (r *todoMemoryRepository) FindTrashedOne(id uint64) (domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	todo, ok := r.store.data.todos[id]
+	if !ok || !isTodoTrashed(todo) || !r.isOwned(id) {
+		return domain.Todo{}, apperror.NotFound("Todo with ID %d Not Found in trash", id)
+	}
+
+	return cloneTodo(todo), nil
+}
+
+func (r *todoMemoryRepository) Restore(todo domain.Todo) (domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	todo.DeletedAt = gorm.DeletedAt{}
+	todo.UpdatedAt = time.Now()
+
+	stored, ok := r.store.data.todos[todo.ID]
+	if ok && r.isOwned(stored.ID) {
+		stored.DeletedAt = todo.DeletedAt
+		stored.UpdatedAt = todo.UpdatedAt
+		r.store.data.todos[stored.ID] = stored
+	}
+
+	return todo, nil
+}
+
+func (r *todoMemoryRepository) Purge(todo domain.Todo) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.isOwned(todo.ID) {
+		delete(r.store.data.todos, todo.ID)
+	}
+	return true, nil
+}
+
+func (r *todoMemoryRepository) CountByActivityID(ActivityID uint64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return int64(len(r.find(matchFilter(TodoFilter{ActivityGroupID: ActivityID})))), nil
+}
+
+func (r *todoMemoryRepository) DeleteByActivityID(ActivityID uint64) ([]domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	todos := r.find(matchFilter(TodoFilter{ActivityGroupID: ActivityID}))
+	for _, todo := range todos {
+		todo = cloneTodo(todo)
+		todo.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		r.store.data.todos[todo.ID] = todo
+	}
+
+	return todos, nil
+}
+
+func (r *todoMemoryRepository) MoveActivity(fromActivityID uint64, toActivityID uint64) ([]domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	err := r.checkOwner(toActivityID)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := r.find(matchFilter(TodoFilter{ActivityGroupID: fromActivityID}))
+	if len(todos) == 0 {
+		return todos, nil
+	}
+
+	_, ok := r.store.data.activities[toActivityID]
+	if !ok {
+		return nil, errReferenceMissing.Wrap(fmt.Errorf("activity group %d of todos is not exist", toActivityID))
+	}
+
+	now := time.Now()
+	for i := range todos {
+		todos[i].ActivityGroupID = toActivityID
+		todos[i].Version++
+		todos[i].UpdatedAt = now
+		r.store.data.todos[todos[i].ID] = cloneTodo(todos[i])
+	}
+
+	return todos, nil
+}
+
+func (r *todoMemoryRepository) RestoreByActivityID(ActivityID uint64, deletedSince time.Time) ([]domain.Todo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	todos := r.find(func(todo domain.Todo) bool {
+		return todo.ActivityGroupID == ActivityID && isTodoTrashed(todo) && !todo.DeletedAt.Time.Before(deletedSince)
+	})
+
+	now := time.Now()
+	for i := range todos {
+		todos[i].DeletedAt = gorm.DeletedAt{}
+		todos[i].UpdatedAt = now
+		r.store.data.todos[todos[i].ID] = cloneTodo(todos[i])
+	}
+
+	return todos, nil
+}
@@ -1,33 +1,153 @@
 package repository
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
 	"github.com/letenk/todo-list/models/domain"
 	"gorm.io/gorm"
 )
 
+// Sort value of priority accepted by TodoFilter
+const (
+	SortPriorityAsc  = "priority"
+	SortPriorityDesc = "-priority"
+)
+
+// Column can be used by TodoFilter.Sort, prefix "-" for descending
+var todoSortColumns = map[string]sortColumn{
+	"id":         {expression: "id", cursorValue: intCursorValue},
+	"created_at": {expression: "created_at", cursorValue: timeCursorValue},
+	"title":      {expression: "title", cursorValue: stringCursorValue},
+	"priority":   {expression: priorityOrder(), cursorValue: intCursorValue},
+	// due_at is nullable, so it cannot be paginated with cursor
+	"due_at": {expression: "due_at"},
+}
+
+// TodoSorts list column can be used by TodoFilter.Sort
+var TodoSorts = []string{"id", "created_at", "title", "priority", "due_at"}
+
+// IsValidTodoSort check sort can be used by TodoFilter.Sort
+func IsValidTodoSort(sort string) bool {
+	return isValidSort(todoSortColumns, sort)
+}
+
+// IsCursorTodoSort check TodoFilter with sort can be paginated with cursor
+func IsCursorTodoSort(sort string) bool {
+	return isCursorSort(todoSortColumns, sort)
+}
+
+// TodoCursor create cursor point to todo, as the last row of a page sorted by sort
+func TodoCursor(todo domain.Todo, sort string) Cursor {
+	cursor := Cursor{Sort: sort, ID: todo.ID}
+
+	switch strings.TrimPrefix(sort, "-") {
+	case "created_at":
+		if todo.CreatedAt != nil {
+			cursor.Value = todo.CreatedAt.Format(time.RFC3339Nano)
+		}
+	case "title":
+		cursor.Value = todo.Title
+	case "priority":
+		cursor.Value = strconv.Itoa(priorityRank(todo.Priority))
+	}
+
+	return cursor
+}
+
+// TodoFilter hold condition for find todos, zero value field is ignored
+type TodoFilter struct {
+	ActivityGroupID uint64
+	// IDs find todos with one of the id only
+	IDs        []uint64
+	Priorities []string
+	Sort       string
+	DueBefore  time.Time
+	DueAfter   time.Time
+	IsActive   *bool
+	// CreatedBefore and CreatedAfter is exclusive
+	CreatedBefore time.Time
+	CreatedAfter  time.Time
+	// TitleContains find title contains it, case insensitive
+	TitleContains string
+	// Expression is parsed by ParseTodoFilter
+	Expression FilterExpression
+	// OverdueAt find active todos with due date before it
+	OverdueAt time.Time
+	Page      Page
+}
+
 type TodoRepository interface {
 	Save(todo domain.Todo) (domain.Todo, error)
 	FindAll() ([]domain.Todo, error)
 	FindByActivityID(ActivityID uint64) ([]domain.Todo, error)
+	FindByFilter(filter TodoFilter) ([]domain.Todo, error)
+	CountByFilter(filter TodoFilter) (int64, error)
 	FindOne(id uint64) (domain.Todo, error)
 	Update(todo domain.Todo) (domain.Todo, error)
 	Delete(todo domain.Todo) (bool, error)
+	CountByActivityID(ActivityID uint64) (int64, error)
+	// DeleteByActivityID, MoveActivity and RestoreByActivityID return the changed todos,
+	// before delete and after move or restore
+	DeleteByActivityID(ActivityID uint64) ([]domain.Todo, error)
+	MoveActivity(fromActivityID uint64, toActivityID uint64) ([]domain.Todo, error)
+	RestoreByActivityID(ActivityID uint64, deletedSince time.Time) ([]domain.Todo, error)
+	FindTrashed() ([]domain.Todo, error)
+	FindTrashedOne(id uint64) (domain.Todo, error)
+	Restore(todo domain.Todo) (domain.Todo, error)
+	Purge(todo domain.Todo) (bool, error)
+	// WithOwner return repository scoped to todos in activity groups user userID is member of, 0 is not scoped
+	WithOwner(userID uint64) TodoRepository
 }
 
 type todoRepository struct {
-	db *gorm.DB
+	db    *gorm.DB
+	owner uint64
 }
 
 func NewRepositoryTodo(db *gorm.DB) *todoRepository {
-	return &todoRepository{db}
+	return &todoRepository{db: db}
+}
+
+func (r *todoRepository) WithOwner(userID uint64) TodoRepository {
+	return &todoRepository{db: r.db, owner: userID}
+}
+
+// scoped return query of todos in activity groups the owner is member of
+func (r *todoRepository) scoped() *gorm.DB {
+	if r.owner == 0 {
+		return r.db
+	}
+	return r.db.Where("activity_group_id IN (?)", memberOf(r.db, r.owner))
+}
+
+// checkOwner return not found error if the owner is not member of activity group
+func (r *todoRepository) checkOwner(activityID uint64) error {
+	if r.owner == 0 {
+		return nil
+	}
+
+	var count int64
+	err := memberOf(r.db, r.owner).Where("activity_group_id = ?", activityID).Count(&count).Error
+	if err != nil {
+		return translateError(err)
+	}
+	if count == 0 {
+		return apperror.NotFound("Activity with ID %d Not Found", activityID)
+	}
+
+	return nil
 }
 
 func (r *todoRepository) FindAll() ([]domain.Todo, error) {
 	var todos []domain.Todo
 
-	err := r.db.Find(&todos).Error
+	err := r.scoped().Find(&todos).Error
 	if err != nil {
-		return todos, nil
+		return todos, translateError(err)
 	}
 
 	return todos, nil
@@ -36,48 +156,313 @@ func (r *todoRepository) FindAll() ([]domain.Todo, error) {
 func (r *todoRepository) FindByActivityID(ActivityID uint64) ([]domain.Todo, error) {
 	var todos []domain.Todo
 
-	err := r.db.Where("activity_group_id = ?", ActivityID).Find(&todos).Error
+	err := r.scoped().Where("activity_group_id = ?", ActivityID).Find(&todos).Error
+	if err != nil {
+		return todos, translateError(err)
+	}
+
+	return todos, nil
+}
+
+func (r *todoRepository) FindByFilter(filter TodoFilter) ([]domain.Todo, error) {
+	var todos []domain.Todo
+
+	query, err := paginate(r.filter(filter), todoSortColumns, filter.Sort, filter.Page)
 	if err != nil {
-		return todos, nil
+		return todos, translateError(err)
+	}
+
+	err = query.Find(&todos).Error
+	if err != nil {
+		return todos, translateError(err)
 	}
 
 	return todos, nil
 }
 
+func (r *todoRepository) CountByFilter(filter TodoFilter) (int64, error) {
+	var count int64
+
+	err := r.filter(filter).Model(&domain.Todo{}).Count(&count).Error
+	if err != nil {
+		return count, translateError(err)
+	}
+
+	return count, nil
+}
+
+// filter apply condition of filter to query, except sort and page
+func (r *todoRepository) filter(filter TodoFilter) *gorm.DB {
+	query := r.scoped()
+	if filter.ActivityGroupID != 0 {
+		query = query.Where("activity_group_id = ?", filter.ActivityGroupID)
+	}
+	if len(filter.IDs) != 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if len(filter.Priorities) != 0 {
+		query = query.Where("priority IN ?", filter.Priorities)
+	}
+	if !filter.DueBefore.IsZero() {
+		query = query.Where("due_at < ?", filter.DueBefore)
+	}
+	if !filter.DueAfter.IsZero() {
+		query = query.Where("due_at > ?", filter.DueAfter)
+	}
+	if !filter.OverdueAt.IsZero() {
+		query = query.Where("is_active = ? AND due_at < ?", true, filter.OverdueAt)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedBefore)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_at > ?", filter.CreatedAfter)
+	}
+	if filter.TitleContains != "" {
+		query = query.Where("LOWER(title) LIKE ? ESCAPE '!'", containsPattern(filter.TitleContains))
+	}
+	if filter.Expression != nil {
+		sql, vars := filter.Expression.where()
+		query = query.Where(sql, vars...)
+	}
+	return query
+}
+
+// priorityOrder rank column priority from the most urgent, so it can be sorted
+// without depend on the database enum order
+func priorityOrder() string {
+	var order strings.Builder
+	order.WriteString("CASE priority")
+	for i, priority := range domain.Priorities {
+		order.WriteString(fmt.Sprintf(" WHEN '%s' THEN %d", priority, i+1))
+	}
+	order.WriteString(" END")
+	return order.String()
+}
+
+// priorityRank return position of priority in domain.Priorities, start from 1
+func priorityRank(priority string) int {
+	for i, p := range domain.Priorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
 func (r *todoRepository) FindOne(id uint64) (domain.Todo, error) {
 	var todo domain.Todo
 
-	err := r.db.Where("id = ?", id).Find(&todo).Error
+	err := r.scoped().Where("id = ?", id).Find(&todo).Error
 	if err != nil {
-		return todo, nil
+		return todo, translateError(err)
+	}
+
+	if todo.ID == 0 {
+		return todo, apperror.NotFound("Todo with ID %d Not Found", id)
 	}
 
 	return todo, nil
 }
 
 func (r *todoRepository) Save(todo domain.Todo) (domain.Todo, error) {
-	err := r.db.Create(&todo).Error
+	err := r.checkOwner(todo.ActivityGroupID)
 	if err != nil {
 		return todo, err
 	}
 
+	// Default of column is not read back by Create
+	todo.Version = 1
+	err = r.db.Create(&todo).Error
+	if err != nil {
+		return todo, translateError(err)
+	}
+
 	return todo, nil
 }
 
 func (r *todoRepository) Update(todo domain.Todo) (domain.Todo, error) {
-	err := r.db.Save(&todo).Error
+	// Todo of other user is not found, instead of changed by other request
+	if r.owner != 0 {
+		var count int64
+		err := r.scoped().Unscoped().Model(&domain.Todo{}).Where("id = ?", todo.ID).Count(&count).Error
+		if err != nil {
+			return todo, translateError(err)
+		}
+		if count == 0 {
+			return todo, apperror.NotFound("Todo with ID %d Not Found", todo.ID)
+		}
+	}
+
+	err := r.checkOwner(todo.ActivityGroupID)
 	if err != nil {
 		return todo, err
 	}
 
+	updated, err := updateVersion(r.db, &todo, todo.ID, &todo.Version)
+	if err != nil {
+		return todo, translateError(err)
+	}
+	if !updated {
+		return todo, versionConflict("Todo", todo.ID)
+	}
+
 	return todo, nil
 }
 
 func (r *todoRepository) Delete(todo domain.Todo) (bool, error) {
-	err := r.db.Delete(&todo).Error
+	db := r.scoped()
+	// Version 0 delete whatever version is stored
+	if todo.Version != 0 {
+		db = db.Where("version = ?", todo.Version)
+	}
+
+	result := db.Delete(&todo)
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+	if todo.Version != 0 && result.RowsAffected == 0 {
+		// Not found is deleted already, only other version is refused
+		var count int64
+		err := r.scoped().Model(&domain.Todo{}).Where("id = ?", todo.ID).Count(&count).Error
+		if err != nil {
+			return false, translateError(err)
+		}
+		if count != 0 {
+			return false, versionConflict("Todo", todo.ID)
+		}
+	}
+
+	return true, nil
+}
+
+func (r *todoRepository) FindTrashed() ([]domain.Todo, error) {
+	var todos []domain.Todo
+
+	err := r.scoped().Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&todos).Error
+	if err != nil {
+		return todos, translateError(err)
+	}
+
+	return todos, nil
+}
+
+func (r *todoRepository) FindTrashedOne(id uint64) (domain.Todo, error) {
+	var todo domain.Todo
+
+	err := r.scoped().Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Find(&todo).Error
 	if err != nil {
-		return false, err
+		return todo, translateError(err)
+	}
+
+	if todo.ID == 0 {
+		return todo, apperror.NotFound("Todo with ID %d Not Found in trash", id)
+	}
+
+	return todo, nil
+}
+
+func (r *todoRepository) Restore(todo domain.Todo) (domain.Todo, error) {
+	err := r.scoped().Unscoped().Model(&todo).Update("deleted_at", nil).Error
+	if err != nil {
+		return todo, translateError(err)
+	}
+
+	todo.DeletedAt = gorm.DeletedAt{}
+	return todo, nil
+}
+
+func (r *todoRepository) Purge(todo domain.Todo) (bool, error) {
+	err := r.scoped().Unscoped().Delete(&todo).Error
+	if err != nil {
+		return false, translateError(err)
 	}
 
 	return true, nil
 }
+
+func (r *todoRepository) CountByActivityID(ActivityID uint64) (int64, error) {
+	var count int64
+
+	err := r.scoped().Model(&domain.Todo{}).Where("activity_group_id = ?", ActivityID).Count(&count).Error
+	if err != nil {
+		return count, translateError(err)
+	}
+
+	return count, nil
+}
+
+func (r *todoRepository) DeleteByActivityID(ActivityID uint64) ([]domain.Todo, error) {
+	todos, err := r.FindByActivityID(ActivityID)
+	if err != nil || len(todos) == 0 {
+		return todos, err
+	}
+
+	// Only the found todos are deleted, so they are the returned ones
+	err = r.db.Where("id IN ?", todoIDs(todos)).Delete(&domain.Todo{}).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return todos, nil
+}
+
+func (r *todoRepository) MoveActivity(fromActivityID uint64, toActivityID uint64) ([]domain.Todo, error) {
+	err := r.checkOwner(toActivityID)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := r.FindByActivityID(fromActivityID)
+	if err != nil || len(todos) == 0 {
+		return todos, err
+	}
+
+	ids := todoIDs(todos)
+	err = r.db.Model(&domain.Todo{}).Where("id IN ?", ids).
+		Updates(map[string]interface{}{"activity_group_id": toActivityID, "version": gorm.Expr("version + 1")}).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return r.findByIDs(ids)
+}
+
+func (r *todoRepository) RestoreByActivityID(ActivityID uint64, deletedSince time.Time) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	err := r.scoped().Unscoped().Where("activity_group_id = ? AND deleted_at >= ?", ActivityID, deletedSince).Find(&todos).Error
+	if err != nil || len(todos) == 0 {
+		return todos, translateError(err)
+	}
+
+	ids := todoIDs(todos)
+	err = r.db.Unscoped().Model(&domain.Todo{}).Where("id IN ?", ids).Update("deleted_at", nil).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return r.findByIDs(ids)
+}
+
+// findByIDs return todos of ids ordered by ID
+func (r *todoRepository) findByIDs(ids []uint64) ([]domain.Todo, error) {
+	var todos []domain.Todo
+	err := r.db.Where("id IN ?", ids).Order("id").Find(&todos).Error
+	if err != nil {
+		return todos, translateError(err)
+	}
+
+	return todos, nil
+}
+
+// todoIDs return ID of every todo
+func todoIDs(todos []domain.Todo) []uint64 {
+	ids := make([]uint64, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
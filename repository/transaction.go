@@ -0,0 +1,64 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Transaction hold repositories share one database transaction
+type Transaction struct {
+	Activity ActivityRepository
+	Todo     TodoRepository
+	// Membership is not scoped, service check role of the owner
+	Membership MembershipRepository
+	// Outbox store events of changes, they are committed together with the changes
+	Outbox OutboxRepository
+	nested func(fn func(tx Transaction) error) error
+}
+
+// Nested run fn in a savepoint of the transaction, only changes of fn are rolled back
+// if it return error
+func (tx Transaction) Nested(fn func(tx Transaction) error) error {
+	return tx.nested(fn)
+}
+
+type Transactor interface {
+	// WithinTransaction run fn in one database transaction, it is rolled back if fn return error
+	WithinTransaction(fn func(tx Transaction) error) error
+	// WithOwner return transactor with repositories scoped to user userID, 0 is not scoped
+	WithOwner(userID uint64) Transactor
+}
+
+type transactor struct {
+	db    *gorm.DB
+	owner uint64
+}
+
+func NewTransactor(db *gorm.DB) *transactor {
+	return &transactor{db: db}
+}
+
+func (t *transactor) WithOwner(userID uint64) Transactor {
+	return &transactor{db: t.db, owner: userID}
+}
+
+func (t *transactor) WithinTransaction(fn func(tx Transaction) error) error {
+	err := t.db.Transaction(func(db *gorm.DB) error {
+		return fn(t.transaction(db))
+	})
+	return translateError(err)
+}
+
+// transaction return repositories of db in transaction, gorm use savepoint for
+// transaction inside it
+func (t *transactor) transaction(db *gorm.DB) Transaction {
+	return Transaction{
+		Activity:   NewRepositoryActivity(db).WithOwner(t.owner),
+		Todo:       NewRepositoryTodo(db).WithOwner(t.owner),
+		Membership: NewRepositoryMembership(db),
+		Outbox:     NewRepositoryOutbox(db),
+		nested: func(fn func(tx Transaction) error) error {
+			err := db.Transaction(func(db *gorm.DB) error {
+				return fn(t.transaction(db))
+			})
+			return translateError(err)
+		},
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	Save(user domain.User) (domain.User, error)
+	FindOne(id uint64) (domain.User, error)
+	FindByEmail(email string) (domain.User, error)
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewRepositoryUser(db *gorm.DB) *userRepository {
+	return &userRepository{db}
+}
+
+func (r *userRepository) Save(user domain.User) (domain.User, error) {
+	err := r.db.Create(&user).Error
+	if err != nil {
+		return user, translateError(err)
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) FindOne(id uint64) (domain.User, error) {
+	var user domain.User
+
+	err := r.db.Where("id = ?", id).Find(&user).Error
+	if err != nil {
+		return user, translateError(err)
+	}
+
+	if user.ID == 0 {
+		return user, apperror.NotFound("User with ID %d Not Found", id)
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) FindByEmail(email string) (domain.User, error) {
+	var user domain.User
+
+	err := r.db.Where("email = ?", email).Find(&user).Error
+	if err != nil {
+		return user, translateError(err)
+	}
+
+	if user.ID == 0 {
+		return user, apperror.NotFound("User with email %s Not Found", email)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// userMemoryRepository is UserRepository in memory, it is safe for concurrent use
+type userMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewRepositoryUserMemory(store *MemoryStore) *userMemoryRepository {
+	return &userMemoryRepository{store}
+}
+
+func (r *userMemoryRepository) Save(user domain.User) (domain.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Email is unique index
+	for _, stored := range r.store.data.users {
+		if stored.Email == user.Email {
+			return user, errDuplicate.Wrap(fmt.Errorf("duplicate email %s of users", user.Email))
+		}
+	}
+
+	if user.ID == 0 {
+		r.store.data.lastUserID++
+		user.ID = r.store.data.lastUserID
+	} else if _, ok := r.store.data.users[user.ID]; ok {
+		return user, errDuplicate.Wrap(fmt.Errorf("duplicate id %d of users", user.ID))
+	}
+	if user.ID > r.store.data.lastUserID {
+		r.store.data.lastUserID = user.ID
+	}
+
+	now := time.Now()
+	if user.CreatedAt == nil {
+		user.CreatedAt = &now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+
+	r.store.data.users[user.ID] = cloneUser(user)
+	return cloneUser(user), nil
+}
+
+func (r *userMemoryRepository) FindOne(id uint64) (domain.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.data.users[id]
+	if !ok {
+		return domain.User{}, apperror.NotFound("User with ID %d Not Found", id)
+	}
+
+	return cloneUser(user), nil
+}
+
+func (r *userMemoryRepository) FindByEmail(email string) (domain.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, user := range r.store.data.users {
+		if user.Email == email {
+			return cloneUser(user), nil
+		}
+	}
+
+	return domain.User{}, apperror.NotFound("User with email %s Not Found", email)
+}
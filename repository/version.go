@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/letenk/todo-list/apperror"
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is cause of error of update or delete data changed by other request
+var ErrVersionConflict = errors.New("version conflict")
+
+// versionConflict create error of data with ID id changed since version is read
+func versionConflict(name string, id uint64) error {
+	return apperror.Conflict("%s with ID %d is changed by other request, get it again", name, id).
+		WithCode("version_conflict").
+		Wrap(ErrVersionConflict)
+}
+
+// updateVersion save all fields of value with ID id when the stored version is still
+// *version, then increment *version. Version 0 update whatever version is stored.
+// Updated is false when row is changed in between or not exist
+func updateVersion(db *gorm.DB, value interface{}, id uint64, version *uint64) (updated bool, err error) {
+	if *version == 0 {
+		err = db.Model(value).Select("version").Where("id = ?", id).Scan(version).Error
+		if err != nil {
+			return false, err
+		}
+	}
+
+	expected := *version
+	*version++
+	result := db.Model(value).Where("version = ?", expected).Select("*").Updates(value)
+	if result.Error != nil || result.RowsAffected == 0 {
+		*version = expected
+	}
+
+	return result.RowsAffected != 0, result.Error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	Save(webhook domain.Webhook) (domain.Webhook, error)
+	// FindAll return webhooks of every user, for deliver events
+	FindAll() ([]domain.Webhook, error)
+	FindByUserID(userID uint64) ([]domain.Webhook, error)
+	// FindOne find webhook of user userID, webhook of other user is not found. 0 is any user
+	FindOne(userID uint64, id uint64) (domain.Webhook, error)
+	Update(webhook domain.Webhook) (domain.Webhook, error)
+	// Delete delete webhook of user userID with its deliveries, webhook of other user is not found
+	Delete(userID uint64, id uint64) (bool, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewRepositoryWebhook(db *gorm.DB) *webhookRepository {
+	return &webhookRepository{db}
+}
+
+func (r *webhookRepository) Save(webhook domain.Webhook) (domain.Webhook, error) {
+	err := r.db.Create(&webhook).Error
+	if err != nil {
+		return webhook, translateError(err)
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) FindAll() ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+
+	err := r.db.Order("id").Find(&webhooks).Error
+	if err != nil {
+		return webhooks, translateError(err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) FindByUserID(userID uint64) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&webhooks).Error
+	if err != nil {
+		return webhooks, translateError(err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) FindOne(userID uint64, id uint64) (domain.Webhook, error) {
+	var webhook domain.Webhook
+
+	query := r.db.Where("id = ?", id)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Find(&webhook).Error
+	if err != nil {
+		return webhook, translateError(err)
+	}
+
+	if webhook.ID == 0 {
+		return webhook, apperror.NotFound("Webhook with ID %d Not Found", id)
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) Update(webhook domain.Webhook) (domain.Webhook, error) {
+	err := r.db.Model(&webhook).Select("url", "secret", "event_types", "activity_group_id", "updated_at").Updates(&webhook).Error
+	if err != nil {
+		return webhook, translateError(err)
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) Delete(userID uint64, id uint64) (bool, error) {
+	// Deliveries are deleted by foreign key
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Webhook{})
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return false, apperror.NotFound("Webhook with ID %d Not Found", id)
+	}
+
+	return true, nil
+}
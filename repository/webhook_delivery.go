@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+)
+
+type WebhookDeliveryRepository interface {
+	Save(delivery domain.WebhookDelivery) (domain.WebhookDelivery, error)
+	// FindByWebhookID return the latest limit deliveries of webhook, newest first. Status
+	// only return deliveries of the status when it is not empty
+	FindByWebhookID(webhookID uint64, status string, limit int) ([]domain.WebhookDelivery, error)
+	FindOne(webhookID uint64, id uint64) (domain.WebhookDelivery, error)
+	// FindDue return pending deliveries of next attempt at or before at, oldest first
+	FindDue(at time.Time, limit int) ([]domain.WebhookDelivery, error)
+	// Claim set next attempt of due delivery id to until, so other worker does not find it
+	// due anymore. It is false when the delivery is not due at at, it is claimed already
+	Claim(id uint64, at time.Time, until time.Time) (bool, error)
+	// Update store status and result of attempt of delivery
+	Update(delivery domain.WebhookDelivery) (domain.WebhookDelivery, error)
+}
+
+type webhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+func NewRepositoryWebhookDelivery(db *gorm.DB) *webhookDeliveryRepository {
+	return &webhookDeliveryRepository{db}
+}
+
+func (r *webhookDeliveryRepository) Save(delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	err := r.db.Create(&delivery).Error
+	if err != nil {
+		return delivery, translateError(err)
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookDeliveryRepository) FindByWebhookID(webhookID uint64, status string, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+
+	query := r.db.Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return deliveries, translateError(err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepository) FindOne(webhookID uint64, id uint64) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+
+	err := r.db.Where("id = ? AND webhook_id = ?", id, webhookID).Find(&delivery).Error
+	if err != nil {
+		return delivery, translateError(err)
+	}
+
+	if delivery.ID == 0 {
+		return delivery, apperror.NotFound("Delivery with ID %d Not Found", id)
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookDeliveryRepository) FindDue(at time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", domain.DeliveryPending, at).
+		Order("next_attempt_at, id").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return deliveries, translateError(err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepository) Claim(id uint64, at time.Time, until time.Time) (bool, error) {
+	result := r.db.Model(&domain.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, domain.DeliveryPending, at).
+		UpdateColumn("next_attempt_at", until)
+	if result.Error != nil {
+		return false, translateError(result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *webhookDeliveryRepository) Update(delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	err := r.db.Model(&delivery).
+		Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at", "updated_at").
+		Updates(&delivery).Error
+	if err != nil {
+		return delivery, translateError(err)
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// webhookDeliveryMemoryRepository is WebhookDeliveryRepository in memory, it is safe for concurrent use
+type webhookDeliveryMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewRepositoryWebhookDeliveryMemory(store *MemoryStore) *webhookDeliveryMemoryRepository {
+	return &webhookDeliveryMemoryRepository{store}
+}
+
+func (r *webhookDeliveryMemoryRepository) Save(delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Webhook is foreign key
+	_, ok := r.store.data.webhooks[delivery.WebhookID]
+	if !ok {
+		return delivery, errReferenceMissing.Wrap(fmt.Errorf("webhook %d of webhook deliveries is not exist", delivery.WebhookID))
+	}
+	for _, other := range r.store.data.webhookDeliveries {
+		if other.WebhookID == delivery.WebhookID && other.EventID == delivery.EventID {
+			return delivery, errDuplicate.Wrap(fmt.Errorf("delivery of event %d to webhook %d already exists", delivery.EventID, delivery.WebhookID))
+		}
+	}
+
+	r.store.data.lastDeliveryID++
+	delivery.ID = r.store.data.lastDeliveryID
+
+	now := time.Now()
+	if delivery.CreatedAt == nil {
+		delivery.CreatedAt = &now
+	}
+	if delivery.UpdatedAt.IsZero() {
+		delivery.UpdatedAt = now
+	}
+
+	r.store.data.webhookDeliveries[delivery.ID] = cloneWebhookDelivery(delivery)
+	return cloneWebhookDelivery(delivery), nil
+}
+
+func (r *webhookDeliveryMemoryRepository) FindByWebhookID(webhookID uint64, status string, limit int) ([]domain.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, delivery := range r.store.data.webhookDeliveries {
+		if delivery.WebhookID == webhookID && (status == "" || delivery.Status == status) {
+			deliveries = append(deliveries, cloneWebhookDelivery(delivery))
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if limit > 0 && limit < len(deliveries) {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryMemoryRepository) FindOne(webhookID uint64, id uint64) (domain.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delivery, ok := r.store.data.webhookDeliveries[id]
+	if !ok || delivery.WebhookID != webhookID {
+		return domain.WebhookDelivery{}, apperror.NotFound("Delivery with ID %d Not Found", id)
+	}
+
+	return cloneWebhookDelivery(delivery), nil
+}
+
+func (r *webhookDeliveryMemoryRepository) FindDue(at time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, delivery := range r.store.data.webhookDeliveries {
+		if delivery.Status == domain.DeliveryPending && !delivery.NextAttemptAt.After(at) {
+			deliveries = append(deliveries, cloneWebhookDelivery(delivery))
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].NextAttemptAt.Equal(deliveries[j].NextAttemptAt) {
+			return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	if limit > 0 && limit < len(deliveries) {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryMemoryRepository) Claim(id uint64, at time.Time, until time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delivery, ok := r.store.data.webhookDeliveries[id]
+	if !ok || delivery.Status != domain.DeliveryPending || delivery.NextAttemptAt.After(at) {
+		return false, nil
+	}
+
+	delivery.NextAttemptAt = until
+	r.store.data.webhookDeliveries[id] = delivery
+	return true, nil
+}
+
+func (r *webhookDeliveryMemoryRepository) Update(delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.data.webhookDeliveries[delivery.ID]
+	if ok {
+		stored.Status = delivery.Status
+		stored.Attempts = delivery.Attempts
+		stored.NextAttemptAt = delivery.NextAttemptAt
+		stored.LastStatusCode = delivery.LastStatusCode
+		stored.LastError = delivery.LastError
+		stored.DeliveredAt = delivery.DeliveredAt
+		stored.UpdatedAt = time.Now()
+		r.store.data.webhookDeliveries[delivery.ID] = cloneWebhookDelivery(stored)
+	}
+
+	return delivery, nil
+}
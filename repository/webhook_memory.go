@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+)
+
+// webhookMemoryRepository is WebhookRepository in memory, it is safe for concurrent use
+type webhookMemoryRepository struct {
+	store *MemoryStore
+}
+
+func NewRepositoryWebhookMemory(store *MemoryStore) *webhookMemoryRepository {
+	return &webhookMemoryRepository{store}
+}
+
+func (r *webhookMemoryRepository) Save(webhook domain.Webhook) (domain.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// User is foreign key
+	_, ok := r.store.data.users[webhook.UserID]
+	if !ok {
+		return webhook, errReferenceMissing.Wrap(fmt.Errorf("user %d of webhooks is not exist", webhook.UserID))
+	}
+
+	r.store.data.lastWebhookID++
+	webhook.ID = r.store.data.lastWebhookID
+
+	now := time.Now()
+	if webhook.CreatedAt == nil {
+		webhook.CreatedAt = &now
+	}
+	if webhook.UpdatedAt.IsZero() {
+		webhook.UpdatedAt = now
+	}
+
+	r.store.data.webhooks[webhook.ID] = cloneWebhook(webhook)
+	return cloneWebhook(webhook), nil
+}
+
+// findWebhooks return webhooks matched by match ordered by id
+func (r *webhookMemoryRepository) findWebhooks(match func(domain.Webhook) bool) []domain.Webhook {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	webhooks := []domain.Webhook{}
+	for _, webhook := range r.store.data.webhooks {
+		if match(webhook) {
+			webhooks = append(webhooks, cloneWebhook(webhook))
+		}
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].ID < webhooks[j].ID
+	})
+	return webhooks
+}
+
+func (r *webhookMemoryRepository) FindAll() ([]domain.Webhook, error) {
+	return r.findWebhooks(func(domain.Webhook) bool { return true }), nil
+}
+
+func (r *webhookMemoryRepository) FindByUserID(userID uint64) ([]domain.Webhook, error) {
+	return r.findWebhooks(func(webhook domain.Webhook) bool { return webhook.UserID == userID }), nil
+}
+
+func (r *webhookMemoryRepository) FindOne(userID uint64, id uint64) (domain.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	webhook, ok := r.store.data.webhooks[id]
+	if !ok || (userID != 0 && webhook.UserID != userID) {
+		return domain.Webhook{}, apperror.NotFound("Webhook with ID %d Not Found", id)
+	}
+
+	return cloneWebhook(webhook), nil
+}
+
+func (r *webhookMemoryRepository) Update(webhook domain.Webhook) (domain.Webhook, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.data.webhooks[webhook.ID]
+	if ok {
+		stored.URL = webhook.URL
+		stored.Secret = webhook.Secret
+		stored.EventTypes = webhook.EventTypes
+		stored.ActivityGroupID = webhook.ActivityGroupID
+		stored.UpdatedAt = time.Now()
+		r.store.data.webhooks[webhook.ID] = cloneWebhook(stored)
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookMemoryRepository) Delete(userID uint64, id uint64) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	webhook, ok := r.store.data.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return false, apperror.NotFound("Webhook with ID %d Not Found", id)
+	}
+
+	// Deliveries are deleted like by foreign key
+	delete(r.store.data.webhooks, id)
+	for deliveryID, delivery := range r.store.data.webhookDeliveries {
+		if delivery.WebhookID == id {
+			delete(r.store.data.webhookDeliveries, deliveryID)
+		}
+	}
+
+	return true, nil
+}
@@ -3,45 +3,134 @@ package router
 import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/cache"
+	"github.com/letenk/todo-list/event"
 	"github.com/letenk/todo-list/handler"
+	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/search"
 	"github.com/letenk/todo-list/service"
-	"gorm.io/gorm"
 )
 
-func SetupRouter(db *gorm.DB) *gin.Engine {
-	router := gin.Default()
+func SetupRouter(repositories repository.Repositories, cache cache.Cache, tokens service.TokenService, index search.Index, bus event.Bus, websocketOrigins []string) *gin.Engine {
+	handler.SetupValidator()
+
+	// Like gin.Default, token of query is removed before the request is logged
+	router := gin.New()
+	router.Use(handler.RedactQueryToken, gin.Logger(), gin.Recovery())
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"https://*", "http://*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Link"},
+		AllowWildcard:    true,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "Idempotency-Key", "If-Match", "If-None-Match", "Last-Event-ID"},
+		ExposeHeaders:    []string{"ETag", "Idempotent-Replayed", "Link", "X-Total-Count"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	repositoryActivity := repository.NewRepositoryActivity(db)
-	serviceActivity := service.NewServiceActivity(repositoryActivity)
+	repositoryUser := repositories.User
+	serviceAuth := service.NewServiceAuth(repositoryUser, tokens)
+	serviceAPIKey := service.NewServiceAPIKey(repositories.APIKey, repositoryUser)
+	handlerAuth := handler.NewAuthHandler(serviceAuth, serviceAPIKey)
+
+	// Route auth, only /auth/me require token
+	auth := router.Group("/auth")
+	auth.POST("/register", handlerAuth.Register)
+	auth.POST("/login", handlerAuth.Login)
+	auth.GET("/me", handlerAuth.Authenticate, handlerAuth.Me)
+
+	handlerAPIKey := handler.NewAPIKeyHandler(serviceAPIKey)
+
+	// Route API keys, they are managed after login only
+	apiKey := router.Group("/api-keys", handlerAuth.Authenticate, handler.RequireLogin)
+	apiKey.GET("", handlerAPIKey.GetAll)
+	apiKey.POST("", handlerAPIKey.Create)
+	apiKey.DELETE("/:id", handlerAPIKey.Revoke)
+
+	transactor := repositories.Transactor
+
+	// Create of activity group and todo is run once for each Idempotency-Key
+	handlerIdempotency := handler.NewIdempotencyHandler(service.NewServiceIdempotency(repositories.IdempotencyKey, service.IdempotencyWindow))
+
+	repositoryActivity := repositories.Activity
+	repositoryTodo := repositories.Todo
+	serviceActivity := service.NewServiceActivityCached(service.NewServiceActivityIndexed(service.NewServiceActivity(repositoryActivity, transactor), index, repositoryTodo), cache)
 	handlerActivity := handler.NewActivityHandler(serviceActivity)
 
-	// Route activity groups
-	Activity := router.Group("/activity-groups")
+	// Route activity groups, every route below is scoped to activity groups the authenticated user is member of
+	Activity := router.Group("/activity-groups", handlerAuth.Authenticate,
+		handler.RequireScope([]string{domain.ScopeTodosRead, domain.ScopeTodosWrite, domain.ScopeGroupsAdmin}, []string{domain.ScopeGroupsAdmin}))
 	Activity.GET("", handlerActivity.GetAll)
 	Activity.GET("/:id", handlerActivity.GetOne)
-	Activity.POST("", handlerActivity.Create)
+	Activity.POST("", handlerIdempotency.Idempotent, handlerActivity.Create)
 	Activity.PATCH("/:id", handlerActivity.Update)
 	Activity.DELETE("/:id", handlerActivity.Delete)
+	Activity.POST("/:id/restore", handlerActivity.Restore)
+
+	repositoryMembership := repositories.Membership
+	serviceMembership := service.NewServiceMembershipCached(service.NewServiceMembership(repositoryMembership, repositoryActivity, repositoryUser, transactor), cache)
+	handlerMembership := handler.NewMembershipHandler(serviceMembership)
 
-	repositoryTodo := repository.NewRepositoryTodo(db)
-	serviceTodo := service.NewServiceTodo(repositoryTodo)
+	// Route members of activity group
+	Activity.GET("/:id/members", handlerMembership.GetAll)
+	Activity.POST("/:id/members", handlerMembership.Invite)
+	Activity.DELETE("/:id/members/:user_id", handlerMembership.Remove)
+
+	serviceTodo := service.NewServiceTodoCached(service.NewServiceTodoIndexed(service.NewServiceTodo(repositoryTodo, repositoryActivity, transactor), index), cache)
 	handlerTodo := handler.NewTodoHandler(serviceTodo)
 
 	// Route todo
-	todo := router.Group("/todo-items")
+	todo := router.Group("/todo-items", handlerAuth.Authenticate,
+		handler.RequireScope([]string{domain.ScopeTodosRead, domain.ScopeTodosWrite}, []string{domain.ScopeTodosWrite}))
 	todo.GET("", handlerTodo.GetAll)
 	todo.GET("/:id", handlerTodo.GetOne)
-	todo.POST("", handlerTodo.Create)
+	todo.POST("", handlerIdempotency.Idempotent, handlerTodo.Create)
 	todo.PATCH("/:id", handlerTodo.Update)
 	todo.DELETE("/:id", handlerTodo.Delete)
+	todo.POST("/bulk", handlerTodo.Bulk)
+	todo.POST("/:id/restore", handlerTodo.Restore)
+
+	handlerTrash := handler.NewTrashHandler(serviceActivity, serviceTodo)
+
+	// Route trash
+	trash := router.Group("/trash", handlerAuth.Authenticate,
+		handler.RequireScope([]string{domain.ScopeGroupsAdmin}, []string{domain.ScopeGroupsAdmin}))
+	trash.GET("", handlerTrash.GetAll)
+	trash.DELETE("", handlerTrash.Purge)
+	trash.DELETE("/activity-groups/:id", handlerTrash.PurgeActivity)
+	trash.DELETE("/todo-items/:id", handlerTrash.PurgeTodo)
+
+	handlerSearch := handler.NewSearchHandler(service.NewServiceSearch(index, repositoryActivity, repositoryTodo))
+
+	// Route search, read of todos or activity groups is enough
+	router.GET("/search", handlerAuth.Authenticate,
+		handler.RequireScope([]string{domain.ScopeTodosRead, domain.ScopeTodosWrite, domain.ScopeGroupsAdmin}, nil), handlerSearch.Search)
+
+	handlerEvent := handler.NewEventHandler(service.NewServiceEvent(bus, repositoryActivity))
+
+	// Route events, stream of changes of todos and activity groups
+	router.GET("/events", handlerAuth.Authenticate,
+		handler.RequireScope([]string{domain.ScopeTodosRead, domain.ScopeTodosWrite, domain.ScopeGroupsAdmin}, nil), handlerEvent.Stream)
+
+	handlerWebhook := handler.NewWebhookHandler(service.NewServiceWebhook(repositories.Webhook, repositories.WebhookDelivery, repositoryActivity))
+
+	// Route webhooks, events are posted to them by WebhookDispatcher
+	webhook := router.Group("/webhooks", handlerAuth.Authenticate,
+		handler.RequireScope([]string{domain.ScopeGroupsAdmin}, []string{domain.ScopeGroupsAdmin}))
+	webhook.GET("", handlerWebhook.GetAll)
+	webhook.GET("/:id", handlerWebhook.GetOne)
+	webhook.POST("", handlerWebhook.Create)
+	webhook.PATCH("/:id", handlerWebhook.Update)
+	webhook.DELETE("/:id", handlerWebhook.Delete)
+	webhook.GET("/:id/deliveries", handlerWebhook.GetDeliveries)
+	webhook.POST("/:id/deliveries/:delivery_id/redeliver", handlerWebhook.Redeliver)
+
+	handlerWebsocket := handler.NewWebsocketHandler(serviceTodo, service.NewServiceEvent(bus, repositoryActivity), websocketOrigins)
+
+	// Route websocket, subscribe to events and send commands of todos. Browser cannot set
+	// header of websocket, so token can be sent as subprotocol bearer or query access_token
+	router.GET("/ws", handler.TokenFromQuery, handlerAuth.Authenticate,
+		handler.RequireScope([]string{domain.ScopeTodosRead, domain.ScopeTodosWrite}, nil), handlerWebsocket.Connect)
+
 	return router
 }
@@ -0,0 +1,231 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/letenk/todo-list/models/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// candidateLimit is the most candidates of each kind found by database
+const candidateLimit = 500
+
+// candidatePrefix is how many first runes of a term must be exact in database, the
+// rest is compared by Rank so typo after it is tolerated
+const candidatePrefix = 3
+
+// databaseIndex find documents by FULLTEXT index of MySQL or PostgreSQL created by
+// migration, it is always up to date with the tables. Term with typo in its first runes
+// is not found by the index, so titles are scanned when the index find nothing
+type databaseIndex struct {
+	db   *gorm.DB
+	scan *scanIndex
+}
+
+// NewIndexDatabase create index of db, ErrUnsupported when db is not MySQL or PostgreSQL
+func NewIndexDatabase(db *gorm.DB) (*databaseIndex, error) {
+	switch db.Dialector.Name() {
+	case "mysql", "postgres":
+		return &databaseIndex{db, NewIndexScan(db)}, nil
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// Put do nothing, row is indexed by database on write
+func (d *databaseIndex) Put(docs ...Document) error {
+	return nil
+}
+
+// Delete do nothing, row is indexed by database on write
+func (d *databaseIndex) Delete(kind string, ids ...uint64) error {
+	return nil
+}
+
+func (d *databaseIndex) Find(query Query) ([]Candidate, error) {
+	candidates := []Candidate{}
+	if len(query.Terms) == 0 {
+		return candidates, nil
+	}
+
+	for _, kind := range Kinds {
+		if query.Kind != "" && query.Kind != kind {
+			continue
+		}
+
+		var ids []uint64
+		err := d.match(kind, query).Limit(candidateLimit).Pluck("id", &ids).Error
+		if err != nil {
+			return candidates, err
+		}
+		if len(ids) == 0 && hasTypoTerm(query.Terms) {
+			ids, err = d.scan.find(kind, query)
+			if err != nil {
+				return candidates, err
+			}
+		}
+
+		for _, id := range ids {
+			candidates = append(candidates, Candidate{kind, id})
+		}
+	}
+
+	return candidates, nil
+}
+
+// hasTypoTerm check some term may have typo, so it can be missed by prefix of the index
+func hasTypoTerm(terms []string) bool {
+	for _, term := range terms {
+		if maxTypos(term) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// documents return query of rows of kind in activity groups of query
+func documents(db *gorm.DB, kind string, query Query) *gorm.DB {
+	switch kind {
+	case KindTodo:
+		db = db.Model(&domain.Todo{})
+		if query.ActivityGroupIDs != nil {
+			db = db.Where("activity_group_id IN ?", query.ActivityGroupIDs)
+		}
+	default:
+		db = db.Model(&domain.Activity{})
+		if query.ActivityGroupIDs != nil {
+			db = db.Where("id IN ?", query.ActivityGroupIDs)
+		}
+	}
+	return db
+}
+
+// match return query of id of kind match every term by prefix, ordered by relevance
+func (d *databaseIndex) match(kind string, query Query) *gorm.DB {
+	db := documents(d.db, kind, query)
+
+	prefixes := make([]string, len(query.Terms))
+	for i, term := range query.Terms {
+		prefixes[i] = term
+		if maxTypos(term) != 0 {
+			prefixes[i] = string([]rune(term)[:candidatePrefix])
+		}
+	}
+
+	if d.db.Dialector.Name() == "postgres" {
+		tsquery := strings.Join(prefixes, ":* & ") + ":*"
+		return db.Where("to_tsvector('simple', title) @@ to_tsquery('simple', ?)", tsquery).
+			Clauses(orderBy("ts_rank(to_tsvector('simple', title), to_tsquery('simple', ?)) DESC", tsquery))
+	}
+
+	against := "+" + strings.Join(prefixes, "* +") + "*"
+	return db.Where("MATCH(title) AGAINST(? IN BOOLEAN MODE)", against).
+		Clauses(orderBy("MATCH(title) AGAINST(? IN BOOLEAN MODE) DESC", against))
+}
+
+// scanIndex find documents by ranking every title of the tables, it works on every
+// database but read every row
+type scanIndex struct {
+	db *gorm.DB
+}
+
+// NewIndexScan create index of db, it is always up to date with the tables
+func NewIndexScan(db *gorm.DB) *scanIndex {
+	return &scanIndex{db}
+}
+
+// Put do nothing, row is read on find
+func (s *scanIndex) Put(docs ...Document) error {
+	return nil
+}
+
+// Delete do nothing, row is read on find
+func (s *scanIndex) Delete(kind string, ids ...uint64) error {
+	return nil
+}
+
+func (s *scanIndex) Find(query Query) ([]Candidate, error) {
+	candidates := []Candidate{}
+	if len(query.Terms) == 0 {
+		return candidates, nil
+	}
+
+	for _, kind := range Kinds {
+		if query.Kind != "" && query.Kind != kind {
+			continue
+		}
+
+		ids, err := s.find(kind, query)
+		if err != nil {
+			return candidates, err
+		}
+
+		for _, id := range ids {
+			candidates = append(candidates, Candidate{kind, id})
+		}
+	}
+
+	return candidates, nil
+}
+
+// find return id of rows of kind match every term, titles are read by candidateLimit rows
+func (s *scanIndex) find(kind string, query Query) ([]uint64, error) {
+	var ids []uint64
+	var lastID uint64
+	for len(ids) < candidateLimit {
+		var rows []struct {
+			ID    uint64
+			Title string
+		}
+		err := documents(s.db, kind, query).Select("id", "title").Where("id > ?", lastID).
+			Order("id").Limit(candidateLimit).Find(&rows).Error
+		if err != nil {
+			return ids, err
+		}
+
+		for _, row := range rows {
+			if _, _, ok := Rank(row.Title, query.Terms); ok && len(ids) < candidateLimit {
+				ids = append(ids, row.ID)
+			}
+		}
+		if len(rows) < candidateLimit {
+			break
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+
+	return ids, nil
+}
+
+// orderBy create ORDER BY of expression with vars, Order accept no vars
+func orderBy(sql string, vars ...interface{}) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}}
+}
+
+// Reindex put every todo and activity group of db to index, deleted included so they
+// are found after restore
+func Reindex(index Index, db *gorm.DB) error {
+	var todos []domain.Todo
+	err := db.Unscoped().Select("id", "activity_group_id", "title").Find(&todos).Error
+	if err != nil {
+		return fmt.Errorf("search: reindex todos: %w", err)
+	}
+
+	var activities []domain.Activity
+	err = db.Unscoped().Select("id", "title").Find(&activities).Error
+	if err != nil {
+		return fmt.Errorf("search: reindex activity groups: %w", err)
+	}
+
+	docs := make([]Document, 0, len(todos)+len(activities))
+	for _, todo := range todos {
+		docs = append(docs, Document{KindTodo, todo.ID, todo.ActivityGroupID, todo.Title})
+	}
+	for _, activity := range activities {
+		docs = append(docs, Document{KindActivityGroup, activity.ID, activity.ID, activity.Title})
+	}
+
+	return index.Put(docs...)
+}
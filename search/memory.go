@@ -0,0 +1,125 @@
+package search
+
+import (
+	"sort"
+	"sync"
+)
+
+// memoryIndex is inverted index of words to documents kept in memory, every
+// instance has its own index
+type memoryIndex struct {
+	mu sync.RWMutex
+	// words of every document
+	documents map[Candidate][]string
+	// activity group of every document
+	activityGroups map[Candidate]uint64
+	// documents of every word
+	postings map[string]map[Candidate]struct{}
+}
+
+func NewIndexMemory() *memoryIndex {
+	return &memoryIndex{
+		documents:      map[Candidate][]string{},
+		activityGroups: map[Candidate]uint64{},
+		postings:       map[string]map[Candidate]struct{}{},
+	}
+}
+
+func (m *memoryIndex) Put(docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, doc := range docs {
+		key := Candidate{doc.Kind, doc.ID}
+		m.remove(key)
+		m.activityGroups[key] = doc.ActivityGroupID
+
+		unique := map[string]bool{}
+		for _, w := range Tokenize(doc.Text) {
+			if unique[w] {
+				continue
+			}
+			unique[w] = true
+
+			if m.postings[w] == nil {
+				m.postings[w] = map[Candidate]struct{}{}
+			}
+			m.postings[w][key] = struct{}{}
+			m.documents[key] = append(m.documents[key], w)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryIndex) Delete(kind string, ids ...uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		m.remove(Candidate{kind, id})
+	}
+
+	return nil
+}
+
+// remove delete document key from postings of its words
+func (m *memoryIndex) remove(key Candidate) {
+	for _, w := range m.documents[key] {
+		delete(m.postings[w], key)
+		if len(m.postings[w]) == 0 {
+			delete(m.postings, w)
+		}
+	}
+	delete(m.documents, key)
+	delete(m.activityGroups, key)
+}
+
+// Find match every term with every word of the index
+func (m *memoryIndex) Find(query Query) ([]Candidate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var activityGroups map[uint64]bool
+	if query.ActivityGroupIDs != nil {
+		activityGroups = map[uint64]bool{}
+		for _, id := range query.ActivityGroupIDs {
+			activityGroups[id] = true
+		}
+	}
+
+	var found map[Candidate]bool
+	for _, term := range query.Terms {
+		matched := map[Candidate]bool{}
+		for w, docs := range m.postings {
+			if matchTerm(term, w) == 0 {
+				continue
+			}
+			for key := range docs {
+				if (query.Kind == "" || key.Kind == query.Kind) && (activityGroups == nil || activityGroups[m.activityGroups[key]]) {
+					matched[key] = found == nil || found[key]
+				}
+			}
+		}
+
+		found = map[Candidate]bool{}
+		for key, ok := range matched {
+			if ok {
+				found[key] = true
+			}
+		}
+	}
+
+	candidates := []Candidate{}
+	for key := range found {
+		candidates = append(candidates, key)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Kind != candidates[j].Kind {
+			return candidates[i].Kind < candidates[j].Kind
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	return candidates, nil
+}
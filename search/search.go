@@ -0,0 +1,221 @@
+package search
+
+import (
+	"errors"
+	"html"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind of document
+const (
+	KindTodo          = "todo"
+	KindActivityGroup = "activity_group"
+)
+
+// Kinds list all kinds of document
+var Kinds = []string{KindTodo, KindActivityGroup}
+
+// ErrUnsupported returned by NewIndexDatabase when database has no full text index
+var ErrUnsupported = errors.New("search: database has no full text index")
+
+// Document is searchable text of a todo or an activity group
+type Document struct {
+	Kind string
+	ID   uint64
+	// ActivityGroupID is activity group of the todo, or the activity group itself
+	ActivityGroupID uint64
+	Text            string
+}
+
+// Candidate is document may match a query, it is ranked with Rank afterward
+type Candidate struct {
+	Kind string
+	ID   uint64
+}
+
+// Query of Index.Find, Terms is created by Tokenize
+type Query struct {
+	Terms []string
+	// Kind find documents of the kind only, empty is all kinds
+	Kind string
+	// ActivityGroupIDs narrow documents to the activity groups and their todos. Index
+	// may ignore it, so the caller still check access to every candidate
+	ActivityGroupIDs []uint64
+}
+
+// Index find documents by their words, it is safe for concurrent use
+type Index interface {
+	// Put add or replace documents
+	Put(docs ...Document) error
+	Delete(kind string, ids ...uint64) error
+	// Find return candidates match every term exactly, by prefix or with typo
+	Find(query Query) ([]Candidate, error)
+}
+
+// word is lowercase word of text, start and end is its position in text
+type word struct {
+	text       string
+	start, end int
+}
+
+// words split text to words of letters and digits
+func words(text string) []word {
+	var result []word
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			result = append(result, word{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		result = append(result, word{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return result
+}
+
+// Tokenize split text to lowercase words of letters and digits
+func Tokenize(text string) []string {
+	var result []string
+	for _, w := range words(text) {
+		result = append(result, w.text)
+	}
+	return result
+}
+
+// maxTypos is edit distance tolerated for term, short term must be exact
+func maxTypos(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchTerm score how word match term, 0 is not match
+func matchTerm(term string, word string) float64 {
+	if word == term {
+		return 1
+	}
+	if strings.HasPrefix(word, term) {
+		return 0.8
+	}
+
+	typos := maxTypos(term)
+	if typos == 0 {
+		return 0
+	}
+
+	t, w := []rune(term), []rune(word)
+	if distance(t, w) <= typos {
+		return 0.6
+	}
+	// Prefix with typo, ex: "grocr" of "groceries"
+	if len(w) > len(t) && distance(t, w[:len(t)]) <= typos {
+		return 0.4
+	}
+	return 0
+}
+
+// distance count insertion, deletion, substitution and transposition of adjacent
+// runes to change a to b
+func distance(a []rune, b []rune) int {
+	// Rows of the previous two runes of a and the current one
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(prev[j]+1, current[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, current = prev, current, prev2
+	}
+
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
+
+// Rank score text by terms, ok is false when any term does not match. Highlight is
+// text escaped as HTML with the matched words in <mark>
+func Rank(text string, terms []string) (score float64, highlight string, ok bool) {
+	if len(terms) == 0 {
+		return 0, "", false
+	}
+
+	textWords := words(text)
+	marked := make([]bool, len(textWords))
+	for _, term := range terms {
+		best := 0.0
+		for i, w := range textWords {
+			s := matchTerm(term, w.text)
+			if s > 0 {
+				marked[i] = true
+			}
+			if s > best {
+				best = s
+			}
+		}
+		if best == 0 {
+			return 0, "", false
+		}
+		score += best
+	}
+
+	// The whole query in order is the best match
+	if len(terms) > 1 && strings.Contains(" "+strings.Join(Tokenize(text), " ")+" ", " "+strings.Join(terms, " ")+" ") {
+		score++
+	}
+	// Text with less other words is more relevant
+	score += float64(len(terms)) / float64(len(textWords))
+
+	return math.Round(score*1000) / 1000, mark(text, textWords, marked), true
+}
+
+// mark escape text as HTML and wrap the marked words in <mark>
+func mark(text string, textWords []word, marked []bool) string {
+	var b strings.Builder
+	last := 0
+	for i, w := range textWords {
+		if !marked[i] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:w.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[w.start:w.end]))
+		b.WriteString("</mark>")
+		last = w.end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
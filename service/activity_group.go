@@ -2,9 +2,10 @@ package service
 
 import (
 	"errors"
-	"fmt"
 	"time"
 
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/event"
 	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/models/web"
 	"github.com/letenk/todo-list/repository"
@@ -12,24 +13,69 @@ import (
 
 type ActivityService interface {
 	Create(req web.ActivityRequest) (domain.Activity, error)
-	GetAll() ([]domain.Activity, error)
+	GetAll(query web.ActivityQuery) ([]domain.Activity, error)
+	Count() (int64, error)
 	GetOne(id uint64) (domain.Activity, error)
 	Update(id uint64, req web.ActivityUpdateRequest) (domain.Activity, error)
 	Delete(id uint64) (bool, error)
+	DeleteWithPolicy(id uint64, query web.ActivityDeleteQuery) (bool, error)
+	GetTrashed() ([]domain.Activity, error)
+	GetTrashedOne(id uint64) (domain.Activity, error)
+	Restore(id uint64) (domain.Activity, error)
+	Purge(id uint64) (bool, error)
+	PurgeTrashed() (int64, error)
+	// WithOwner return service of activity groups owned by user userID, 0 is not scoped
+	WithOwner(userID uint64) ActivityService
 }
 
+// Error of delete activity group with policy
+var (
+	ErrActivityHasTodos   = errors.New("activity group still has todos")
+	ErrMoveToNotFound     = errors.New("activity group of move_to not found")
+	ErrMoveToSameActivity = errors.New("activity group of move_to is the deleted activity group")
+)
+
 type activityService struct {
 	repository repository.ActivityRepository
+	transactor repository.Transactor
 }
 
-func NewServiceActivity(repository repository.ActivityRepository) *activityService {
-	return &activityService{repository}
+func NewServiceActivity(repository repository.ActivityRepository, transactor repository.Transactor) *activityService {
+	return &activityService{repository, transactor}
 }
 
-func (s *activityService) GetAll() ([]domain.Activity, error) {
-	// Find all
-	Activitys, err := s.repository.FindAll()
+func (s *activityService) WithOwner(userID uint64) ActivityService {
+	return &activityService{s.repository.WithOwner(userID), s.transactor.WithOwner(userID)}
+}
 
+// requireRole return forbidden error if the owner of repository has no role required in
+// activity group, not member is not found
+func requireRole(repository repository.ActivityRepository, activityID uint64, required string) error {
+	role, err := repository.FindRole(activityID)
+	if err != nil {
+		return err
+	}
+
+	if !domain.HasRole(role, required) {
+		return apperror.Forbidden("Role %s of activity group with ID %d is required", required, activityID).WithCode("insufficient_role")
+	}
+
+	return nil
+}
+
+func (s *activityService) GetAll(query web.ActivityQuery) ([]domain.Activity, error) {
+	page, err := newPage(query.PageQuery)
+	if err != nil {
+		return []domain.Activity{}, err
+	}
+
+	filter := repository.ActivityFilter{
+		Sort: query.Sort,
+		Page: page,
+	}
+
+	// Find by filter
+	Activitys, err := s.repository.FindByFilter(filter)
 	if err != nil {
 		return Activitys, err
 	}
@@ -37,6 +83,16 @@ func (s *activityService) GetAll() ([]domain.Activity, error) {
 	return Activitys, nil
 }
 
+func (s *activityService) Count() (int64, error) {
+	// Count all
+	count, err := s.repository.Count()
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
 func (s *activityService) GetOne(id uint64) (domain.Activity, error) {
 	// Find one
 	Activity, err := s.repository.FindOne(id)
@@ -55,7 +111,20 @@ func (s *activityService) Create(req web.ActivityRequest) (domain.Activity, erro
 	}
 
 	// Save
-	newActivity, err := s.repository.Save(Activity)
+	var newActivity domain.Activity
+	err := s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		var err error
+		newActivity, err = tx.Activity.Save(Activity)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx.Outbox, event.ActivityGroupCreated, newActivity)
+	})
+	// Email is used by other activity group, maybe of other user, so the email is not told
+	if errors.Is(err, apperror.ErrConflict) {
+		return newActivity, apperror.Conflict("Email is already used by other activity group").WithCode("duplicate_email").Wrap(err)
+	}
+
 	if err != nil {
 		return newActivity, err
 	}
@@ -66,12 +135,16 @@ func (s *activityService) Create(req web.ActivityRequest) (domain.Activity, erro
 func (s *activityService) Update(id uint64, req web.ActivityUpdateRequest) (domain.Activity, error) {
 	// Find one
 	Activity, err := s.repository.FindOne(id)
-	// If activity group not found
-	if Activity.ID == 0 {
-		message := fmt.Sprintf("Activity with ID %d Not Found", id)
-		return Activity, errors.New(message)
+	if err != nil {
+		return Activity, err
 	}
 
+	err = checkVersion("Activity", id, req.Version, Activity.Version)
+	if err != nil {
+		return Activity, err
+	}
+
+	err = requireRole(s.repository, id, domain.RoleEditor)
 	if err != nil {
 		return Activity, err
 	}
@@ -82,31 +155,213 @@ func (s *activityService) Update(id uint64, req web.ActivityUpdateRequest) (doma
 	Activity.UpdatedAt = time.Now()
 
 	// Update
-	updatedActivity, err := s.repository.Update(Activity)
+	var updatedActivity domain.Activity
+	err = s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		updatedActivity, err = tx.Activity.Update(Activity)
+		if err != nil {
+			return err
+		}
+		return recordEvent(tx.Outbox, event.ActivityGroupUpdated, updatedActivity)
+	})
 	if err != nil {
-		return Activity, err
+		return Activity, versionError("Activity", id, req.Version, err)
 	}
 
 	return updatedActivity, nil
 }
 
 func (s *activityService) Delete(id uint64) (bool, error) {
-	// Find one
-	Activity, err := s.repository.FindOne(id)
-	// If activity group not found
-	if Activity.ID == 0 {
-		message := fmt.Sprintf("Activity with ID %d Not Found", id)
-		return false, errors.New(message)
+	// Delete with the todos
+	return s.DeleteWithPolicy(id, web.ActivityDeleteQuery{Policy: web.DeletePolicyCascade})
+}
+
+func (s *activityService) DeleteWithPolicy(id uint64, query web.ActivityDeleteQuery) (bool, error) {
+	if query.MoveTo == id {
+		return false, apperror.Validation("move_to cannot be the deleted activity group").WithCode("move_to_same_activity").Wrap(ErrMoveToSameActivity)
 	}
 
+	var ok bool
+	err := s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		// Find one
+		Activity, err := tx.Activity.FindOne(id)
+		if err != nil {
+			return err
+		}
+
+		err = checkVersion("Activity", id, query.Version, Activity.Version)
+		if err != nil {
+			return err
+		}
+
+		err = requireRole(tx.Activity, id, domain.RoleOwner)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case query.MoveTo != 0:
+			// Move todos to other activity group
+			target, err := tx.Activity.FindOne(query.MoveTo)
+			if errors.Is(err, apperror.ErrNotFound) {
+				return apperror.Validation("Activity with ID %d Not Found", query.MoveTo).WithCode("move_to_not_found").Wrap(ErrMoveToNotFound)
+			}
+			if err != nil {
+				return err
+			}
+
+			// Todos is moved in, so it is changed
+			err = requireRole(tx.Activity, target.ID, domain.RoleEditor)
+			if err != nil {
+				return err
+			}
+
+			moved, err := tx.Todo.MoveActivity(Activity.ID, target.ID)
+			if err != nil {
+				return err
+			}
+			err = recordTodoEvents(tx.Outbox, event.TodoUpdated, moved)
+			if err != nil {
+				return err
+			}
+		case query.Policy == web.DeletePolicyRestrict:
+			count, err := tx.Todo.CountByActivityID(Activity.ID)
+			if err != nil {
+				return err
+			}
+			if count != 0 {
+				return apperror.Conflict("Activity with ID %d still has todos", Activity.ID).WithCode("activity_has_todos").Wrap(ErrActivityHasTodos)
+			}
+		}
+
+		ok, err = tx.Activity.Delete(Activity)
+		if err != nil {
+			return versionError("Activity", id, query.Version, err)
+		}
+
+		// Delete todos after the activity group, so restore can find them by deleted time
+		if query.MoveTo == 0 && query.Policy != web.DeletePolicyRestrict {
+			deleted, err := tx.Todo.DeleteByActivityID(Activity.ID)
+			if err != nil {
+				return err
+			}
+			err = recordTodoEvents(tx.Outbox, event.TodoDeleted, deleted)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Event has the activity group before it is deleted
+		return recordEvent(tx.Outbox, event.ActivityGroupDeleted, Activity)
+	})
 	if err != nil {
 		return false, err
 	}
 
-	ok, err := s.repository.Delete(Activity)
+	return ok, nil
+}
+
+func (s *activityService) GetTrashed() ([]domain.Activity, error) {
+	// Find all deleted
+	Activitys, err := s.repository.FindTrashed()
+	if err != nil {
+		return Activitys, err
+	}
+
+	return Activitys, nil
+}
+
+func (s *activityService) GetTrashedOne(id uint64) (domain.Activity, error) {
+	// Find one deleted
+	Activity, err := s.repository.FindTrashedOne(id)
+	if err != nil {
+		return Activity, err
+	}
+
+	return Activity, nil
+}
+
+func (s *activityService) Restore(id uint64) (domain.Activity, error) {
+	var restoredActivity domain.Activity
+	err := s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		// Find one deleted
+		Activity, err := tx.Activity.FindTrashedOne(id)
+		if err != nil {
+			return err
+		}
+
+		err = requireRole(tx.Activity, id, domain.RoleOwner)
+		if err != nil {
+			return err
+		}
+
+		restoredActivity, err = tx.Activity.Restore(Activity)
+		if err != nil {
+			return err
+		}
+
+		// Restored activity group is back in the list, so it is created again for subscribers
+		err = recordEvent(tx.Outbox, event.ActivityGroupCreated, restoredActivity)
+		if err != nil {
+			return err
+		}
+
+		// Restore todos deleted together with the activity group, they are created again too
+		restored, err := tx.Todo.RestoreByActivityID(Activity.ID, Activity.DeletedAt.Time)
+		if err != nil {
+			return err
+		}
+		return recordTodoEvents(tx.Outbox, event.TodoCreated, restored)
+	})
+	if err != nil {
+		return restoredActivity, err
+	}
+
+	return restoredActivity, nil
+}
+
+func (s *activityService) Purge(id uint64) (bool, error) {
+	// Find one deleted
+	Activity, err := s.repository.FindTrashedOne(id)
+	if err != nil {
+		return false, err
+	}
+
+	err = requireRole(s.repository, id, domain.RoleOwner)
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := s.repository.Purge(Activity)
 	if err != nil {
 		return false, err
 	}
 
 	return ok, nil
 }
+
+func (s *activityService) PurgeTrashed() (int64, error) {
+	activities, err := s.repository.FindTrashed()
+	if err != nil {
+		return 0, err
+	}
+
+	// Delete permanently all deleted the owner can delete, the others are kept
+	var count int64
+	for _, activity := range activities {
+		role, err := s.repository.FindRole(activity.ID)
+		if err != nil {
+			return count, err
+		}
+		if !domain.HasRole(role, domain.RoleOwner) {
+			continue
+		}
+
+		_, err = s.repository.Purge(activity)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
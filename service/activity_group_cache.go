@@ -0,0 +1,105 @@
+package service
+
+import (
+	"github.com/letenk/todo-list/cache"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+)
+
+// cachedActivityService cache the result of ActivityService with cache aside,
+// every write remove the cached data it changes
+type cachedActivityService struct {
+	ActivityService
+	store cacheStore
+}
+
+func NewServiceActivityCached(service ActivityService, cache cache.Cache) *cachedActivityService {
+	return &cachedActivityService{service, cacheStore{cache: cache}}
+}
+
+func (s *cachedActivityService) WithOwner(userID uint64) ActivityService {
+	return &cachedActivityService{s.ActivityService.WithOwner(userID), s.store.withOwner(userID)}
+}
+
+func (s *cachedActivityService) GetAll(query web.ActivityQuery) ([]domain.Activity, error) {
+	// Sorted or paginated activities is not cached
+	if query.IsFiltered() {
+		return s.ActivityService.GetAll(query)
+	}
+
+	var activities []domain.Activity
+	if s.store.get(activitiesKey, &activities) {
+		return activities, nil
+	}
+
+	activities, err := s.ActivityService.GetAll(query)
+	if err != nil {
+		return activities, err
+	}
+
+	s.store.set(activitiesKey, activities)
+	return activities, nil
+}
+
+func (s *cachedActivityService) GetOne(id uint64) (domain.Activity, error) {
+	var activity domain.Activity
+	if s.store.get(activityKey(id), &activity) {
+		return activity, nil
+	}
+
+	activity, err := s.ActivityService.GetOne(id)
+	if err != nil {
+		return activity, err
+	}
+
+	// Not found is returned as error, so it is not cached
+	s.store.set(activityKey(id), activity)
+	return activity, nil
+}
+
+func (s *cachedActivityService) Create(req web.ActivityRequest) (domain.Activity, error) {
+	activity, err := s.ActivityService.Create(req)
+	if err == nil {
+		s.store.delete(activitiesKey)
+	}
+	return activity, err
+}
+
+func (s *cachedActivityService) Update(id uint64, req web.ActivityUpdateRequest) (domain.Activity, error) {
+	activity, err := s.ActivityService.Update(id, req)
+	if err == nil {
+		s.store.delete(activitiesKey, activityKey(id))
+	}
+	return activity, err
+}
+
+func (s *cachedActivityService) Delete(id uint64) (bool, error) {
+	return s.DeleteWithPolicy(id, web.ActivityDeleteQuery{Policy: web.DeletePolicyCascade})
+}
+
+func (s *cachedActivityService) DeleteWithPolicy(id uint64, query web.ActivityDeleteQuery) (bool, error) {
+	ok, err := s.ActivityService.DeleteWithPolicy(id, query)
+	if err == nil {
+		s.store.delete(activitiesKey, activityKey(id))
+		// Todos of activity group is deleted or moved too
+		s.deleteTodos()
+	}
+	return ok, err
+}
+
+func (s *cachedActivityService) Restore(id uint64) (domain.Activity, error) {
+	activity, err := s.ActivityService.Restore(id)
+	if err == nil {
+		s.store.delete(activitiesKey, activityKey(id))
+		// Todos of activity group is restored too
+		s.deleteTodos()
+	}
+	return activity, err
+}
+
+// deleteTodos remove all cached todos, it is used when todos of an activity group
+// is changed without knowing their id
+func (s *cachedActivityService) deleteTodos() {
+	s.store.deletePrefix(todosPrefix)
+	s.store.deletePrefix(todoPrefix)
+}
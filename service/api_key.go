@@ -0,0 +1,144 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+)
+
+// APIKeyPrefix is start of every API key, so it is told apart from bearer token of login
+const APIKeyPrefix = "tdl_"
+
+// apiKeyTouchInterval is the least time between two writes of last used time of a key,
+// so a busy script does not write on every request
+const apiKeyTouchInterval = time.Minute
+
+// ErrInvalidAPIKey returned when API key is not exist or revoked
+var ErrInvalidAPIKey = apperror.Unauthorized("API key is invalid or revoked").WithCode("invalid_api_key")
+
+type APIKeyService interface {
+	// Create create key of user userID, the key is returned only here
+	Create(userID uint64, req web.APIKeyRequest) (domain.APIKey, string, error)
+	GetAll(userID uint64) ([]domain.APIKey, error)
+	Revoke(userID uint64, id uint64) (bool, error)
+	// Authenticate return stored key of key and record it is used
+	Authenticate(key string) (domain.APIKey, error)
+}
+
+type apiKeyService struct {
+	repository     repository.APIKeyRepository
+	userRepository repository.UserRepository
+}
+
+func NewServiceAPIKey(repository repository.APIKeyRepository, userRepository repository.UserRepository) *apiKeyService {
+	return &apiKeyService{repository, userRepository}
+}
+
+// IsAPIKey check token is API key instead of bearer token of login
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// hashAPIKey return SHA-256 of key, key is random so it need no salt
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *apiKeyService) Create(userID uint64, req web.APIKeyRequest) (domain.APIKey, string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return domain.APIKey{}, "", err
+	}
+	key := APIKeyPrefix + hex.EncodeToString(secret)
+
+	// Keep the first given order, without duplicate
+	var scopes []string
+	for _, scope := range req.Scopes {
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	apiKey := domain.APIKey{
+		UserID:  userID,
+		Name:    req.Name,
+		Prefix:  key[:len(APIKeyPrefix)+8],
+		KeyHash: hashAPIKey(key),
+		Scopes:  strings.Join(scopes, " "),
+	}
+
+	newAPIKey, err := s.repository.Save(apiKey)
+	if err != nil {
+		return newAPIKey, "", err
+	}
+
+	return newAPIKey, key, nil
+}
+
+func (s *apiKeyService) GetAll(userID uint64) ([]domain.APIKey, error) {
+	// Find by user
+	keys, err := s.repository.FindByUserID(userID)
+	if err != nil {
+		return keys, err
+	}
+
+	return keys, nil
+}
+
+func (s *apiKeyService) Revoke(userID uint64, id uint64) (bool, error) {
+	// Delete, key of other user is not found
+	ok, err := s.repository.Delete(userID, id)
+	if err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+func (s *apiKeyService) Authenticate(key string) (domain.APIKey, error) {
+	apiKey, err := s.repository.FindByHash(hashAPIKey(key))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apiKey, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return apiKey, err
+	}
+
+	// Key of deleted user is not valid, like token of login
+	_, err = s.userRepository.FindOne(apiKey.UserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return domain.APIKey{}, ErrInvalidAPIKey.Wrap(err)
+	}
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	// Failed to record last used time does not fail the request
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if s.repository.Touch(apiKey.ID, now) == nil {
+			apiKey.LastUsedAt = &now
+		}
+	}
+
+	return apiKey, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AuthService interface {
+	// Register create user and return it with its token
+	Register(req web.RegisterRequest) (domain.User, string, time.Time, error)
+	// Login check email and password, then return user with its token
+	Login(req web.LoginRequest) (domain.User, string, time.Time, error)
+	// Authenticate return id of user of token, the user must be still exist
+	Authenticate(token string) (uint64, error)
+	GetUser(id uint64) (domain.User, error)
+}
+
+// errInvalidCredential is same for unknown email and wrong password, so email of users is not leaked
+var errInvalidCredential = apperror.Unauthorized("email or password is wrong").WithCode("invalid_credentials")
+
+type authService struct {
+	repository repository.UserRepository
+	tokens     TokenService
+}
+
+func NewServiceAuth(repository repository.UserRepository, tokens TokenService) *authService {
+	return &authService{repository, tokens}
+}
+
+func (s *authService) Register(req web.RegisterRequest) (domain.User, string, time.Time, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return domain.User{}, "", time.Time{}, err
+	}
+
+	user := domain.User{
+		Email:        strings.ToLower(req.Email),
+		Name:         req.Name,
+		PasswordHash: string(hash),
+	}
+
+	newUser, err := s.repository.Save(user)
+	if errors.Is(err, apperror.ErrConflict) {
+		return newUser, "", time.Time{}, apperror.Conflict("User with email %s already exists", user.Email).
+			WithCode("duplicate_email").
+			WithField("email", user.Email).
+			Wrap(err)
+	}
+	if err != nil {
+		return newUser, "", time.Time{}, err
+	}
+
+	token, expiresAt, err := s.tokens.Issue(newUser.ID)
+	if err != nil {
+		return newUser, "", time.Time{}, err
+	}
+
+	return newUser, token, expiresAt, nil
+}
+
+func (s *authService) Login(req web.LoginRequest) (domain.User, string, time.Time, error) {
+	user, err := s.repository.FindByEmail(strings.ToLower(req.Email))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return domain.User{}, "", time.Time{}, errInvalidCredential
+	}
+	if err != nil {
+		return domain.User{}, "", time.Time{}, err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
+	if err != nil {
+		return domain.User{}, "", time.Time{}, errInvalidCredential
+	}
+
+	token, expiresAt, err := s.tokens.Issue(user.ID)
+	if err != nil {
+		return user, "", time.Time{}, err
+	}
+
+	return user, token, expiresAt, nil
+}
+
+func (s *authService) Authenticate(token string) (uint64, error) {
+	userID, err := s.tokens.Verify(token)
+	if err != nil {
+		return 0, err
+	}
+
+	// Token of deleted user is still valid until it is expired
+	_, err = s.repository.FindOne(userID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return 0, ErrInvalidToken.Wrap(err)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (s *authService) GetUser(id uint64) (domain.User, error) {
+	// Find one
+	user, err := s.repository.FindOne(id)
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/letenk/todo-list/cache"
+)
+
+// cacheTTL is how long cached data is kept
+const cacheTTL = time.Hour
+
+// cacheKey is key of cached data, create it with the functions below only
+type cacheKey string
+
+const (
+	activitiesKey cacheKey = "activities"
+	// todosPrefix is prefix of todosKey for all activity groups
+	todosPrefix cacheKey = "todos:"
+	// todoPrefix is prefix of todoKey for all todos
+	todoPrefix cacheKey = "todo:"
+)
+
+func activityKey(id uint64) cacheKey {
+	return cacheKey(fmt.Sprintf("activity:%d", id))
+}
+
+// todosKey is key of todos by activity group, 0 mean all todos
+func todosKey(activityGroupID uint64) cacheKey {
+	return todosPrefix + cacheKey(fmt.Sprintf("%d", activityGroupID))
+}
+
+func todoKey(id uint64) cacheKey {
+	return todoPrefix + cacheKey(fmt.Sprintf("%d", id))
+}
+
+// cacheStore encode value to json for cache.Cache, error of cache is ignored
+// so the service still work from database when cache is down
+type cacheStore struct {
+	cache cache.Cache
+	// suffix separate cached data of each user, so data of activity group shared by
+	// members is removed for all of them by its key
+	suffix cacheKey
+}
+
+// userSeparator separate key and suffix of user
+const userSeparator = "@"
+
+// withOwner return store of cached data of user userID, 0 is not scoped
+func (s cacheStore) withOwner(userID uint64) cacheStore {
+	if userID == 0 {
+		return cacheStore{cache: s.cache}
+	}
+	return cacheStore{cache: s.cache, suffix: cacheKey(fmt.Sprintf("%suser:%d", userSeparator, userID))}
+}
+
+// get decode cached value of key to value, return false when it is missed
+func (s cacheStore) get(key cacheKey, value interface{}) bool {
+	data, err := s.cache.Get(string(key + s.suffix))
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(data, value) == nil
+}
+
+func (s cacheStore) set(key cacheKey, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	s.cache.Set(string(key+s.suffix), data, cacheTTL)
+}
+
+// delete remove keys cached for every user
+func (s cacheStore) delete(keys ...cacheKey) {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = string(key)
+	}
+
+	s.cache.Delete(values...)
+	for _, key := range keys {
+		s.cache.DeletePrefix(string(key) + userSeparator)
+	}
+}
+
+// deletePrefix remove keys start with prefix cached for every user
+func (s cacheStore) deletePrefix(prefix cacheKey) {
+	s.cache.DeletePrefix(string(prefix))
+}
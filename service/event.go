@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/repository"
+)
+
+type EventService interface {
+	// Subscribe return stream of events after event lastEventID, 0 is only the new events.
+	// Only events of activity group activityGroupID when it is not 0
+	Subscribe(lastEventID uint64, activityGroupID uint64) (*EventStream, error)
+	// WithOwner return service of events of activity groups user userID is member of, 0 is not scoped
+	WithOwner(userID uint64) EventService
+}
+
+// EventStream is subscription of events, check each event with Visible before it is sent
+type EventStream struct {
+	*event.Subscription
+	activityGroupID    uint64
+	activityRepository repository.ActivityRepository
+
+	mu sync.Mutex
+	// visible is whether the owner can see events of activity group, it is found once
+	// until members of the activity group is changed
+	visible map[uint64]bool
+}
+
+// Visible check event is of activity group of the stream and the owner is still member
+// of its activity group, so removed member stop receiving its events
+func (s *EventStream) Visible(e event.Event) bool {
+	if s.activityGroupID != 0 && e.ActivityGroupID != s.activityGroupID {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Creator of activity group is its first owner
+	switch e.Type {
+	case event.ActivityGroupCreated, event.MemberAdded, event.MemberRemoved:
+		delete(s.visible, e.ActivityGroupID)
+	}
+
+	visible, ok := s.visible[e.ActivityGroupID]
+	if ok {
+		return visible
+	}
+
+	// Membership is kept with deleted activity group, so its delete event is visible
+	role, err := s.activityRepository.FindRole(e.ActivityGroupID)
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		// Not cached, it is found again for the next event
+		return false
+	}
+
+	visible = err == nil && domain.HasRole(role, domain.RoleViewer)
+	s.visible[e.ActivityGroupID] = visible
+	return visible
+}
+
+type eventService struct {
+	bus                event.Bus
+	activityRepository repository.ActivityRepository
+}
+
+func NewServiceEvent(bus event.Bus, activityRepository repository.ActivityRepository) *eventService {
+	return &eventService{bus, activityRepository}
+}
+
+func (s *eventService) WithOwner(userID uint64) EventService {
+	return &eventService{s.bus, s.activityRepository.WithOwner(userID)}
+}
+
+func (s *eventService) Subscribe(lastEventID uint64, activityGroupID uint64) (*EventStream, error) {
+	if activityGroupID != 0 {
+		_, err := s.activityRepository.FindOne(activityGroupID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &EventStream{
+		Subscription:       s.bus.Subscribe(lastEventID),
+		activityGroupID:    activityGroupID,
+		activityRepository: s.activityRepository,
+		visible:            map[uint64]bool{},
+	}, nil
+}
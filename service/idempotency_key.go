@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/repository"
+)
+
+// IdempotencyWindow is how long response of idempotency key is replayed, the key can be
+// used again after it
+const IdempotencyWindow = 24 * time.Hour
+
+// idempotencyCleanInterval is how often expired keys are deleted by Begin, expired key
+// found before it is deleted is used again
+const idempotencyCleanInterval = time.Minute
+
+// Error of idempotency key sent again
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key is used by other request")
+	ErrIdempotencyKeyInProgress = errors.New("request of idempotency key is in progress")
+)
+
+type IdempotencyService interface {
+	// Begin reserve key of user for request of fingerprint. Replay is true when the key is
+	// used by the same request before, the returned key has its response
+	Begin(userID uint64, key string, fingerprint string) (idempotencyKey domain.IdempotencyKey, replay bool, err error)
+	// Complete store response of request of key
+	Complete(key domain.IdempotencyKey, statusCode int, contentType string, body []byte) error
+	// Release delete key, so the request can be retried with it
+	Release(key domain.IdempotencyKey) error
+}
+
+type idempotencyService struct {
+	repository repository.IdempotencyKeyRepository
+	window     time.Duration
+
+	mu        sync.Mutex
+	cleanedAt time.Time
+}
+
+func NewServiceIdempotency(repository repository.IdempotencyKeyRepository, window time.Duration) *idempotencyService {
+	return &idempotencyService{repository: repository, window: window}
+}
+
+func (s *idempotencyService) Begin(userID uint64, key string, fingerprint string) (domain.IdempotencyKey, bool, error) {
+	now := time.Now()
+	s.deleteExpired(now)
+
+	// Key is unique, only one request can reserve it
+	idempotencyKey, err := s.reserve(userID, key, fingerprint, now)
+	if err == nil {
+		return idempotencyKey, false, nil
+	}
+	if !errors.Is(err, apperror.ErrConflict) {
+		return idempotencyKey, false, err
+	}
+
+	// Key is used before
+	idempotencyKey, err = s.repository.FindByKey(userID, key)
+	if errors.Is(err, apperror.ErrNotFound) {
+		// Released by the first request in between
+		return idempotencyKey, false, idempotencyInProgress(key)
+	}
+	if err != nil {
+		return idempotencyKey, false, err
+	}
+
+	// Expired key is not deleted yet, it is used again
+	if idempotencyKey.ExpiresAt.Before(now) {
+		_, err = s.repository.Delete(idempotencyKey.ID)
+		if err != nil {
+			return idempotencyKey, false, err
+		}
+
+		idempotencyKey, err = s.reserve(userID, key, fingerprint, now)
+		if errors.Is(err, apperror.ErrConflict) {
+			// Reserved by other request in between
+			return idempotencyKey, false, idempotencyInProgress(key)
+		}
+		return idempotencyKey, false, err
+	}
+
+	if idempotencyKey.Fingerprint != fingerprint {
+		return idempotencyKey, false, apperror.Validation("Idempotency-Key %s is already used by other request", key).
+			WithCode("idempotency_key_reused").
+			Wrap(ErrIdempotencyKeyReused)
+	}
+	if !idempotencyKey.IsCompleted() {
+		return idempotencyKey, false, idempotencyInProgress(key)
+	}
+
+	return idempotencyKey, true, nil
+}
+
+// reserve save key of user for request of fingerprint, conflict error when it is used
+func (s *idempotencyService) reserve(userID uint64, key string, fingerprint string, now time.Time) (domain.IdempotencyKey, error) {
+	return s.repository.Save(domain.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(s.window),
+	})
+}
+
+// deleteExpired delete expired keys at most once every idempotencyCleanInterval, error is
+// logged since the keys are deleted again later
+func (s *idempotencyService) deleteExpired(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.cleanedAt) < idempotencyCleanInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.cleanedAt = now
+	s.mu.Unlock()
+
+	_, err := s.repository.DeleteExpired(now)
+	if err != nil {
+		log.Printf("Failed to delete expired idempotency keys %v", err)
+	}
+}
+
+// idempotencyInProgress create error of request of key is not responded yet
+func idempotencyInProgress(key string) error {
+	return apperror.Conflict("Request with Idempotency-Key %s is still in progress, retry later", key).
+		WithCode("idempotency_key_in_progress").
+		Wrap(ErrIdempotencyKeyInProgress)
+}
+
+func (s *idempotencyService) Complete(key domain.IdempotencyKey, statusCode int, contentType string, body []byte) error {
+	key.StatusCode = statusCode
+	key.ContentType = contentType
+	key.Body = body
+
+	_, err := s.repository.Update(key)
+	return err
+}
+
+func (s *idempotencyService) Release(key domain.IdempotencyKey) error {
+	_, err := s.repository.Delete(key.ID)
+	return err
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+)
+
+type MembershipService interface {
+	GetAll(activityID uint64) ([]domain.Membership, error)
+	// Invite add registered user as member of activity group, only owner can invite
+	Invite(activityID uint64, req web.MemberInviteRequest) (domain.Membership, error)
+	// Remove remove member of activity group, only owner can remove other member
+	// and anyone can leave
+	Remove(activityID uint64, userID uint64) (bool, error)
+	// WithOwner return service act as user userID, 0 is not scoped
+	WithOwner(userID uint64) MembershipService
+}
+
+type membershipService struct {
+	repository         repository.MembershipRepository
+	activityRepository repository.ActivityRepository
+	userRepository     repository.UserRepository
+	transactor         repository.Transactor
+	owner              uint64
+}
+
+func NewServiceMembership(repository repository.MembershipRepository, activityRepository repository.ActivityRepository, userRepository repository.UserRepository, transactor repository.Transactor) *membershipService {
+	return &membershipService{repository: repository, activityRepository: activityRepository, userRepository: userRepository, transactor: transactor}
+}
+
+func (s *membershipService) WithOwner(userID uint64) MembershipService {
+	return &membershipService{s.repository, s.activityRepository.WithOwner(userID), s.userRepository, s.transactor.WithOwner(userID), userID}
+}
+
+func (s *membershipService) GetAll(activityID uint64) ([]domain.Membership, error) {
+	// Any member can see the others
+	_, err := s.activityRepository.FindOne(activityID)
+	if err != nil {
+		return []domain.Membership{}, err
+	}
+
+	memberships, err := s.repository.FindByActivityID(activityID)
+	if err != nil {
+		return memberships, err
+	}
+
+	return memberships, nil
+}
+
+func (s *membershipService) Invite(activityID uint64, req web.MemberInviteRequest) (domain.Membership, error) {
+	_, err := s.activityRepository.FindOne(activityID)
+	if err != nil {
+		return domain.Membership{}, err
+	}
+
+	err = requireRole(s.activityRepository, activityID, domain.RoleOwner)
+	if err != nil {
+		return domain.Membership{}, err
+	}
+
+	email := strings.ToLower(req.Email)
+	user, err := s.userRepository.FindByEmail(email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return domain.Membership{}, apperror.Validation("User with email %s Not Found", email).WithCode("user_not_found").WithField("email", email).Wrap(err)
+	}
+	if err != nil {
+		return domain.Membership{}, err
+	}
+
+	var membership domain.Membership
+	err = s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		membership, err = tx.Membership.Save(domain.Membership{ActivityGroupID: activityID, UserID: user.ID, Role: req.Role})
+		if err != nil {
+			return err
+		}
+
+		membership.User = user
+		return recordEvent(tx.Outbox, event.MemberAdded, membership)
+	})
+	if errors.Is(err, apperror.ErrConflict) {
+		return membership, apperror.Conflict("User with email %s is already member", email).WithCode("already_member").WithField("email", email).Wrap(err)
+	}
+	if err != nil {
+		return membership, err
+	}
+
+	return membership, nil
+}
+
+func (s *membershipService) Remove(activityID uint64, userID uint64) (bool, error) {
+	_, err := s.activityRepository.FindOne(activityID)
+	if err != nil {
+		return false, err
+	}
+
+	// Member can leave by itself
+	if userID != s.owner {
+		err = requireRole(s.activityRepository, activityID, domain.RoleOwner)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var ok bool
+	err = s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		membership, err := tx.Membership.FindOne(activityID, userID)
+		if err != nil {
+			return err
+		}
+
+		// Activity group always has an owner, owners are locked so owner removed at the
+		// same time is counted
+		if membership.Role == domain.RoleOwner {
+			count, err := tx.Membership.CountByRole(activityID, domain.RoleOwner)
+			if err != nil {
+				return err
+			}
+			if count <= 1 {
+				return apperror.Conflict("Activity with ID %d must have an owner", activityID).WithCode("last_owner")
+			}
+		}
+
+		ok, err = tx.Membership.Delete(membership)
+		if err != nil {
+			return err
+		}
+
+		// Event has the member before it is removed
+		return recordEvent(tx.Outbox, event.MemberRemoved, membership)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"github.com/letenk/todo-list/cache"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+)
+
+// cachedMembershipService remove cached data of activity group when its members is changed,
+// so removed member cannot read it from cache and new member see it. Members is not cached
+type cachedMembershipService struct {
+	MembershipService
+	store cacheStore
+}
+
+func NewServiceMembershipCached(service MembershipService, cache cache.Cache) *cachedMembershipService {
+	return &cachedMembershipService{service, cacheStore{cache: cache}}
+}
+
+func (s *cachedMembershipService) WithOwner(userID uint64) MembershipService {
+	return &cachedMembershipService{s.MembershipService.WithOwner(userID), s.store}
+}
+
+func (s *cachedMembershipService) Invite(activityID uint64, req web.MemberInviteRequest) (domain.Membership, error) {
+	membership, err := s.MembershipService.Invite(activityID, req)
+	if err == nil {
+		s.deleteActivity(activityID)
+	}
+	return membership, err
+}
+
+func (s *cachedMembershipService) Remove(activityID uint64, userID uint64) (bool, error) {
+	ok, err := s.MembershipService.Remove(activityID, userID)
+	if err == nil {
+		s.deleteActivity(activityID)
+	}
+	return ok, err
+}
+
+// deleteActivity remove cached activity group and lists may include it or its todos
+func (s *cachedMembershipService) deleteActivity(activityID uint64) {
+	s.store.delete(activitiesKey, activityKey(activityID))
+	s.store.deletePrefix(todosPrefix)
+	s.store.deletePrefix(todoPrefix)
+}
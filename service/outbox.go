@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/repository"
+)
+
+// Default retry of outbox event, delay is doubled for every next attempt so the last
+// attempt is about 17 minutes after the first with the defaults
+const (
+	OutboxBackoff     = time.Second
+	OutboxMaxAttempts = 10
+)
+
+const (
+	// outboxPollInterval is how often relay look for unpublished events
+	outboxPollInterval = 100 * time.Millisecond
+	// outboxBatch is the most events sent by one relay
+	outboxBatch = 100
+	// outboxErrorLength is the longest error stored of a failed attempt
+	outboxErrorLength = 1024
+	// OutboxRetention is how long published events are kept before they are deleted
+	OutboxRetention = 24 * time.Hour
+)
+
+// recordEvent write event of todo, activity group or membership data to outbox, outbox must
+// be of the transaction of the change
+func recordEvent(outbox repository.OutboxRepository, eventType string, data interface{}) error {
+	outboxEvent := domain.OutboxEvent{EventType: eventType}
+	switch data := data.(type) {
+	case domain.Todo:
+		outboxEvent.AggregateType, outboxEvent.AggregateID, outboxEvent.ActivityGroupID = domain.AggregateTodo, data.ID, data.ActivityGroupID
+	case domain.Activity:
+		outboxEvent.AggregateType, outboxEvent.AggregateID, outboxEvent.ActivityGroupID = domain.AggregateActivityGroup, data.ID, data.ID
+	case domain.Membership:
+		outboxEvent.AggregateType, outboxEvent.AggregateID, outboxEvent.ActivityGroupID = domain.AggregateMembership, data.ID, data.ActivityGroupID
+		// Only the user fields of the response are kept, not its password
+		data.User = domain.User{ID: data.User.ID, Email: data.User.Email, Name: data.User.Name}
+	default:
+		return fmt.Errorf("unknown data %T of event %s", data, eventType)
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	outboxEvent.Payload = payload
+
+	_, err = outbox.Save(outboxEvent)
+	return err
+}
+
+// recordTodoEvents write event of every todo changed together, like recordEvent
+func recordTodoEvents(outbox repository.OutboxRepository, eventType string, todos []domain.Todo) error {
+	for _, todo := range todos {
+		err := recordEvent(outbox, eventType, todo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EventSink receive events relayed from outbox. Sink is not sent again the event it
+// received, but it may be when the process stopped before that is stored, sink dedupe by
+// ID of event when it matter
+type EventSink interface {
+	Send(e event.Event) error
+}
+
+type busSink struct {
+	bus event.Bus
+}
+
+// NewBusSink publish relayed events to bus, ID of event is replaced by ID of bus
+func NewBusSink(bus event.Bus) *busSink {
+	return &busSink{bus}
+}
+
+func (s *busSink) Send(e event.Event) error {
+	e.ID = 0
+	s.bus.Publish(e)
+	return nil
+}
+
+type logSink struct{}
+
+// NewLogSink write relayed events to log
+func NewLogSink() *logSink {
+	return &logSink{}
+}
+
+func (s *logSink) Send(e event.Event) error {
+	log.Printf("Event %d %s of activity group %d", e.ID, e.Type, e.ActivityGroupID)
+	return nil
+}
+
+// OutboxRelay send events of outbox to sinks, at least once and in order for each todo
+// or activity group. Failed event is retried with exponential backoff until it is dead.
+// Only one relay should run for a database
+type OutboxRelay struct {
+	repository  repository.OutboxRepository
+	backoff     time.Duration
+	maxAttempts int
+	sinks       []EventSink
+}
+
+func NewServiceOutboxRelay(repository repository.OutboxRepository, backoff time.Duration, maxAttempts int, sinks ...EventSink) *OutboxRelay {
+	return &OutboxRelay{repository, backoff, maxAttempts, sinks}
+}
+
+// Relay send events due at at to every sink, and return how many are published. Only the
+// oldest pending event of each aggregate is sent, the next one is sent by the next call
+// after it is published or dead
+func (r *OutboxRelay) Relay(at time.Time) (int, error) {
+	outboxEvents, err := r.repository.FindDue(at, outboxBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, outboxEvent := range outboxEvents {
+		outboxEvent.Attempts++
+		outboxEvent.SentSinks, err = r.send(outboxEvent)
+
+		switch {
+		case err == nil:
+			outboxEvent.Status = domain.OutboxPublished
+			outboxEvent.LastError = ""
+			outboxEvent.PublishedAt = &at
+			published++
+		case outboxEvent.Attempts >= r.maxAttempts:
+			// Later events of its aggregate are relayed without it
+			outboxEvent.Status = domain.OutboxDead
+			outboxEvent.LastError = err.Error()
+			log.Printf("Event %d of outbox is dead after %d attempts %v", outboxEvent.ID, outboxEvent.Attempts, err)
+		default:
+			outboxEvent.LastError = err.Error()
+			nextAttemptAt := at.Add(r.backoff << (outboxEvent.Attempts - 1))
+			outboxEvent.NextAttemptAt = &nextAttemptAt
+		}
+		if len(outboxEvent.LastError) > outboxErrorLength {
+			outboxEvent.LastError = outboxEvent.LastError[:outboxErrorLength]
+		}
+
+		_, err = r.repository.Update(outboxEvent)
+		if err != nil {
+			return published, err
+		}
+	}
+
+	return published, nil
+}
+
+// send decode event of outbox and send it to sinks after the ones already received it, ID
+// of outbox is ID of the event. It return how many sinks received the event
+func (r *OutboxRelay) send(outboxEvent domain.OutboxEvent) (int, error) {
+	var data interface{}
+	var err error
+	switch outboxEvent.AggregateType {
+	case domain.AggregateTodo:
+		var todo domain.Todo
+		err = json.Unmarshal(outboxEvent.Payload, &todo)
+		data = todo
+	case domain.AggregateActivityGroup:
+		var activity domain.Activity
+		err = json.Unmarshal(outboxEvent.Payload, &activity)
+		data = activity
+	case domain.AggregateMembership:
+		var membership domain.Membership
+		err = json.Unmarshal(outboxEvent.Payload, &membership)
+		data = membership
+	default:
+		err = fmt.Errorf("unknown aggregate %s", outboxEvent.AggregateType)
+	}
+	if err != nil {
+		return outboxEvent.SentSinks, err
+	}
+
+	e := event.Event{
+		ID:              outboxEvent.ID,
+		Type:            outboxEvent.EventType,
+		ActivityGroupID: outboxEvent.ActivityGroupID,
+		Data:            data,
+	}
+	if outboxEvent.CreatedAt != nil {
+		e.CreatedAt = *outboxEvent.CreatedAt
+	}
+
+	sent := outboxEvent.SentSinks
+	for ; sent < len(r.sinks); sent++ {
+		err = r.sinks[sent].Send(e)
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// Run relay events every poll interval until ctx is done, published events older than
+// OutboxRetention are deleted every hour
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	var cleanedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Relay again while events are published, the next events of their aggregates are due
+		now := time.Now()
+		for ctx.Err() == nil {
+			published, err := r.Relay(now)
+			if err != nil {
+				log.Printf("Failed to relay events of outbox %v", err)
+			}
+			if err != nil || published == 0 {
+				break
+			}
+		}
+
+		if now.Sub(cleanedAt) >= time.Hour {
+			cleanedAt = now
+			_, err := r.repository.DeletePublished(now.Add(-OutboxRetention))
+			if err != nil {
+				log.Printf("Failed to delete published events of outbox %v", err)
+			}
+		}
+	}
+}
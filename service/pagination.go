@@ -0,0 +1,24 @@
+package service
+
+import (
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+)
+
+// newPage convert query of pagination to repository page
+func newPage(query web.PageQuery) (repository.Page, error) {
+	page := repository.Page{
+		Limit:  query.PageLimit(),
+		Offset: query.Offset,
+	}
+
+	if query.Cursor != "" {
+		cursor, err := repository.DecodeCursor(query.Cursor)
+		if err != nil {
+			return page, err
+		}
+		page.After = &cursor
+	}
+
+	return page, nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/search"
+)
+
+// searchLoadSize is how many todos found by index are loaded by one query
+const searchLoadSize = 500
+
+type SearchService interface {
+	// Search find todos and activity groups by title, ranked by relevance
+	Search(query web.SearchQuery) ([]domain.SearchResult, error)
+	// WithOwner return service search in activity groups user userID is member of, 0 is not scoped
+	WithOwner(userID uint64) SearchService
+}
+
+type searchService struct {
+	index              search.Index
+	activityRepository repository.ActivityRepository
+	todoRepository     repository.TodoRepository
+	owner              uint64
+}
+
+func NewServiceSearch(index search.Index, activityRepository repository.ActivityRepository, todoRepository repository.TodoRepository) *searchService {
+	return &searchService{index: index, activityRepository: activityRepository, todoRepository: todoRepository}
+}
+
+func (s *searchService) WithOwner(userID uint64) SearchService {
+	return &searchService{s.index, s.activityRepository.WithOwner(userID), s.todoRepository.WithOwner(userID), userID}
+}
+
+func (s *searchService) Search(query web.SearchQuery) ([]domain.SearchResult, error) {
+	results := []domain.SearchResult{}
+	terms := search.Tokenize(query.Q)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	// Activity groups the owner can read
+	activities, err := s.activityRepository.FindAll()
+	if err != nil {
+		return results, err
+	}
+	if len(activities) == 0 {
+		return results, nil
+	}
+
+	activityByID := map[uint64]domain.Activity{}
+	var activityIDs []uint64
+	for _, activity := range activities {
+		activityByID[activity.ID] = activity
+		activityIDs = append(activityIDs, activity.ID)
+	}
+	if s.owner == 0 {
+		activityIDs = nil
+	}
+
+	candidates, err := s.index.Find(search.Query{Terms: terms, Kind: query.Type, ActivityGroupIDs: activityIDs})
+	if err != nil {
+		return results, err
+	}
+
+	// Candidate is loaded again, so deleted, changed or not owned one is dropped
+	var todoIDs []uint64
+	for _, candidate := range candidates {
+		switch candidate.Kind {
+		case search.KindActivityGroup:
+			activity, ok := activityByID[candidate.ID]
+			if !ok {
+				continue
+			}
+			results = appendRanked(results, terms, domain.SearchResult{
+				Kind:            search.KindActivityGroup,
+				ID:              activity.ID,
+				ActivityGroupID: activity.ID,
+				Title:           activity.Title,
+			})
+		case search.KindTodo:
+			todoIDs = append(todoIDs, candidate.ID)
+		}
+	}
+
+	for start := 0; start < len(todoIDs); start += searchLoadSize {
+		end := start + searchLoadSize
+		if end > len(todoIDs) {
+			end = len(todoIDs)
+		}
+
+		todos, err := s.todoRepository.FindByFilter(repository.TodoFilter{IDs: todoIDs[start:end]})
+		if err != nil {
+			return results, err
+		}
+
+		for _, todo := range todos {
+			results = appendRanked(results, terms, domain.SearchResult{
+				Kind:            search.KindTodo,
+				ID:              todo.ID,
+				ActivityGroupID: todo.ActivityGroupID,
+				Title:           todo.Title,
+			})
+		}
+	}
+
+	// The most relevant first, then the newest
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID > results[j].ID
+	})
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = web.DefaultSearchLimit
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// appendRanked append result to results when its title match terms
+func appendRanked(results []domain.SearchResult, terms []string, result domain.SearchResult) []domain.SearchResult {
+	score, highlight, ok := search.Rank(result.Title, terms)
+	if !ok {
+		return results
+	}
+
+	result.Score = score
+	result.Highlight = highlight
+	return append(results, result)
+}
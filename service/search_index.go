@@ -0,0 +1,118 @@
+package service
+
+import (
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/search"
+)
+
+func todoDocument(todo domain.Todo) search.Document {
+	return search.Document{Kind: search.KindTodo, ID: todo.ID, ActivityGroupID: todo.ActivityGroupID, Text: todo.Title}
+}
+
+// indexedTodoService put todos written by TodoService to search index. Deleted todo
+// is kept in index so it is found after restore, search drop it when it is loaded.
+// Error of index is ignored, the todo is already saved
+type indexedTodoService struct {
+	TodoService
+	index search.Index
+}
+
+func NewServiceTodoIndexed(service TodoService, index search.Index) *indexedTodoService {
+	return &indexedTodoService{service, index}
+}
+
+func (s *indexedTodoService) WithOwner(userID uint64) TodoService {
+	return &indexedTodoService{s.TodoService.WithOwner(userID), s.index}
+}
+
+func (s *indexedTodoService) Create(req web.TodoCreateRequest) (domain.Todo, error) {
+	todo, err := s.TodoService.Create(req)
+	if err == nil {
+		s.index.Put(todoDocument(todo))
+	}
+	return todo, err
+}
+
+func (s *indexedTodoService) Update(id uint64, req web.TodoUpdateRequest) (domain.Todo, error) {
+	todo, err := s.TodoService.Update(id, req)
+	if err == nil {
+		s.index.Put(todoDocument(todo))
+	}
+	return todo, err
+}
+
+// Bulk put todos updated by bulk, they may be moved to other activity group
+func (s *indexedTodoService) Bulk(req web.TodoBulkRequest) ([]domain.TodoBulkResult, error) {
+	results, err := s.TodoService.Bulk(req)
+	for _, result := range results {
+		if result.Err == nil && result.Action == domain.BulkActionUpdate {
+			s.index.Put(todoDocument(result.Todo))
+		}
+	}
+	return results, err
+}
+
+func (s *indexedTodoService) Purge(id uint64) (bool, error) {
+	ok, err := s.TodoService.Purge(id)
+	if err == nil {
+		s.index.Delete(search.KindTodo, id)
+	}
+	return ok, err
+}
+
+// indexedActivityService put activity groups written by ActivityService to search
+// index, like indexedTodoService. Todos moved by delete are put again, todos deleted or
+// restored with activity group keep their documents
+type indexedActivityService struct {
+	ActivityService
+	index          search.Index
+	todoRepository repository.TodoRepository
+}
+
+func NewServiceActivityIndexed(service ActivityService, index search.Index, todoRepository repository.TodoRepository) *indexedActivityService {
+	return &indexedActivityService{service, index, todoRepository}
+}
+
+func (s *indexedActivityService) WithOwner(userID uint64) ActivityService {
+	return &indexedActivityService{s.ActivityService.WithOwner(userID), s.index, s.todoRepository}
+}
+
+func (s *indexedActivityService) Create(req web.ActivityRequest) (domain.Activity, error) {
+	activity, err := s.ActivityService.Create(req)
+	if err == nil {
+		s.index.Put(search.Document{Kind: search.KindActivityGroup, ID: activity.ID, ActivityGroupID: activity.ID, Text: activity.Title})
+	}
+	return activity, err
+}
+
+func (s *indexedActivityService) Update(id uint64, req web.ActivityUpdateRequest) (domain.Activity, error) {
+	activity, err := s.ActivityService.Update(id, req)
+	if err == nil {
+		s.index.Put(search.Document{Kind: search.KindActivityGroup, ID: activity.ID, ActivityGroupID: activity.ID, Text: activity.Title})
+	}
+	return activity, err
+}
+
+func (s *indexedActivityService) DeleteWithPolicy(id uint64, query web.ActivityDeleteQuery) (bool, error) {
+	ok, err := s.ActivityService.DeleteWithPolicy(id, query)
+	if err == nil && query.MoveTo != 0 {
+		// Todos of move_to include the moved ones
+		todos, err := s.todoRepository.FindByActivityID(query.MoveTo)
+		if err == nil {
+			for _, todo := range todos {
+				s.index.Put(todoDocument(todo))
+			}
+		}
+	}
+	return ok, err
+}
+
+func (s *indexedActivityService) Purge(id uint64) (bool, error) {
+	ok, err := s.ActivityService.Purge(id)
+	if err == nil {
+		s.index.Delete(search.KindActivityGroup, id)
+	}
+	return ok, err
+}
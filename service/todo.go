@@ -3,8 +3,11 @@ package service
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/event"
 	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/models/web"
 	"github.com/letenk/todo-list/repository"
@@ -12,47 +15,142 @@ import (
 
 type TodoService interface {
 	Create(req web.TodoCreateRequest) (domain.Todo, error)
-	GetAll(ActivityID uint64) ([]domain.Todo, error)
+	GetAll(query web.TodoQuery) ([]domain.Todo, error)
+	Count(query web.TodoQuery) (int64, error)
 	GetOne(id uint64) (domain.Todo, error)
 	Update(id uint64, req web.TodoUpdateRequest) (domain.Todo, error)
-	Delete(id uint64) (bool, error)
+	// Delete todo of version, 0 delete whatever version is stored
+	Delete(id uint64, version uint64) (bool, error)
+	GetTrashed() ([]domain.Todo, error)
+	GetTrashedOne(id uint64) (domain.Todo, error)
+	Restore(id uint64) (domain.Todo, error)
+	Purge(id uint64) (bool, error)
+	PurgeTrashed() (int64, error)
+	// Bulk run actions in one transaction, with result of each action. Error is *BulkError
+	// when atomic bulk is rolled back
+	Bulk(req web.TodoBulkRequest) ([]domain.TodoBulkResult, error)
+	// WithOwner return service of todos in activity groups owned by user userID, 0 is not scoped
+	WithOwner(userID uint64) TodoService
+}
+
+// ErrActivityGroupNotFound returned when activity_group_id of todo is not exist
+var ErrActivityGroupNotFound = errors.New("activity group not found")
+
+// PriorityErrorMessage is message of invalid value of priority
+var PriorityErrorMessage = fmt.Sprintf("priority must be one of %s", strings.Join(domain.Priorities, ", "))
+
+// ErrBulkNotApplied is error of action of atomic bulk rolled back by other action
+var ErrBulkNotApplied = errors.New("action is not applied")
+
+// BulkError returned by Bulk when atomic bulk is rolled back because action Index failed
+type BulkError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("action %d failed: %s", e.Index, e.Err)
+}
+
+func (e *BulkError) Unwrap() error {
+	return e.Err
 }
 
 type todoService struct {
-	repository repository.TodoRepository
+	repository         repository.TodoRepository
+	activityRepository repository.ActivityRepository
+	transactor         repository.Transactor
+	// outbox is only set for service in transaction, see withinTransaction
+	outbox repository.OutboxRepository
+}
+
+func NewServiceTodo(repository repository.TodoRepository, activityRepository repository.ActivityRepository, transactor repository.Transactor) *todoService {
+	return &todoService{repository: repository, activityRepository: activityRepository, transactor: transactor}
 }
 
-func NewServiceTodo(repository repository.TodoRepository) *todoService {
-	return &todoService{repository}
+func (s *todoService) WithOwner(userID uint64) TodoService {
+	return &todoService{repository: s.repository.WithOwner(userID), activityRepository: s.activityRepository.WithOwner(userID), transactor: s.transactor.WithOwner(userID)}
+}
+
+// checkActivityGroup return validation error wrap ErrActivityGroupNotFound if activity group is not exist
+func (s *todoService) checkActivityGroup(ActivityID uint64) error {
+	_, err := s.activityRepository.FindOne(ActivityID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Validation("Activity with ID %d Not Found", ActivityID).
+			WithCode("activity_group_not_found").
+			WithField("activity_group_id", ActivityID).
+			Wrap(ErrActivityGroupNotFound)
+	}
+
+	return err
+}
+
+// checkTodo return validation error when priority of todo is not one of domain.Priorities,
+// or it start after it is due. Empty priority is the default of the column
+func checkTodo(todo domain.Todo) error {
+	if todo.Priority != "" && !domain.IsValidPriority(todo.Priority) {
+		return apperror.Validation(PriorityErrorMessage).WithCode("invalid_priority").WithField("priority", todo.Priority)
+	}
+
+	if todo.StartAt != nil && todo.DueAt != nil && todo.StartAt.After(*todo.DueAt) {
+		return apperror.Validation("start_at cannot be after due_at").WithCode("start_after_due").WithField("start_at", todo.StartAt)
+	}
+
+	return nil
 }
 
 func (s *todoService) Create(req web.TodoCreateRequest) (domain.Todo, error) {
 	todo := domain.Todo{
 		ActivityGroupID: req.ActivityGroupID,
 		Title:           req.Title,
+		Priority:        req.Priority,
+		StartAt:         req.StartAt,
+		DueAt:           req.DueAt,
+	}
+	err := checkTodo(todo)
+	if err != nil {
+		return domain.Todo{}, err
 	}
 
-	newTodo, err := s.repository.Save(todo)
+	err = s.checkActivityGroup(req.ActivityGroupID)
 	if err != nil {
-		return newTodo, err
+		return domain.Todo{}, err
 	}
 
-	return newTodo, err
-}
+	err = requireRole(s.activityRepository, req.ActivityGroupID, domain.RoleEditor)
+	if err != nil {
+		return domain.Todo{}, err
+	}
 
-func (s *todoService) GetAll(ActivityID uint64) ([]domain.Todo, error) {
-	if ActivityID != 0 {
-		// Find by activity group id
-		todos, err := s.repository.FindByActivityID(ActivityID)
+	var newTodo domain.Todo
+	err = s.withinTransaction(func(s *todoService) error {
+		newTodo, err = s.repository.Save(todo)
 		if err != nil {
-			return todos, err
+			return err
 		}
-		return todos, nil
+		return recordEvent(s.outbox, event.TodoCreated, newTodo)
+	})
+	if err != nil {
+		return newTodo, err
 	}
 
-	// Find all
-	todos, err := s.repository.FindAll()
+	return newTodo, nil
+}
 
+func (s *todoService) GetAll(query web.TodoQuery) ([]domain.Todo, error) {
+	filter, err := newTodoFilter(query)
+	if err != nil {
+		return []domain.Todo{}, err
+	}
+
+	page, err := newPage(query.PageQuery)
+	if err != nil {
+		return []domain.Todo{}, err
+	}
+	filter.Page = page
+
+	// Find by filter
+	todos, err := s.repository.FindByFilter(filter)
 	if err != nil {
 		return todos, err
 	}
@@ -60,6 +158,50 @@ func (s *todoService) GetAll(ActivityID uint64) ([]domain.Todo, error) {
 	return todos, nil
 }
 
+func (s *todoService) Count(query web.TodoQuery) (int64, error) {
+	filter, err := newTodoFilter(query)
+	if err != nil {
+		return 0, err
+	}
+
+	// Count by filter, without page
+	count, err := s.repository.CountByFilter(filter)
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// newTodoFilter convert query to repository filter, without page
+func newTodoFilter(query web.TodoQuery) (repository.TodoFilter, error) {
+	filter := repository.TodoFilter{
+		ActivityGroupID: query.ActivityGroupID,
+		Priorities:      query.Priorities(),
+		Sort:            query.Sort,
+		DueBefore:       query.DueBefore,
+		DueAfter:        query.DueAfter,
+		IsActive:        query.IsActive,
+		CreatedBefore:   query.CreatedBefore,
+		CreatedAfter:    query.CreatedAfter,
+		TitleContains:   query.TitleContains,
+	}
+	if query.Overdue {
+		filter.OverdueAt = time.Now()
+	}
+
+	if query.Filter != "" {
+		// Expression is checked by handler
+		expression, err := repository.ParseTodoFilter(query.Filter)
+		if err != nil {
+			return filter, err
+		}
+		filter.Expression = expression
+	}
+
+	return filter, nil
+}
+
 func (s *todoService) GetOne(id uint64) (domain.Todo, error) {
 	// Find all
 	todo, err := s.repository.FindOne(id)
@@ -74,12 +216,16 @@ func (s *todoService) GetOne(id uint64) (domain.Todo, error) {
 func (s *todoService) Update(id uint64, req web.TodoUpdateRequest) (domain.Todo, error) {
 	// Find all
 	todo, err := s.repository.FindOne(id)
-	// If activity group not found
-	if todo.ID == 0 {
-		message := fmt.Sprintf("Todo with ID %d Not Found", id)
-		return todo, errors.New(message)
+	if err != nil {
+		return todo, err
+	}
+
+	err = checkVersion("Todo", id, req.Version, todo.Version)
+	if err != nil {
+		return todo, err
 	}
 
+	err = requireRole(s.activityRepository, todo.ActivityGroupID, domain.RoleEditor)
 	if err != nil {
 		return todo, err
 	}
@@ -88,42 +234,275 @@ func (s *todoService) Update(id uint64, req web.TodoUpdateRequest) (domain.Todo,
 	if req.Title != "" {
 		todo.Title = req.Title
 	}
-	// Change field is active, if value req.IsActive is true
-	if !req.IsActive {
-		todo.IsActive = req.IsActive
-	} else {
-		todo.IsActive = true
-
+	// Change field is active, only if is_active is sent
+	if req.IsActive != nil {
+		todo.IsActive = *req.IsActive
+	}
+	// Change field priority
+	if req.Priority != "" {
+		todo.Priority = req.Priority
+	}
+	// Change field start at and due at
+	if req.StartAt != nil {
+		todo.StartAt = req.StartAt
+	}
+	if req.DueAt != nil {
+		todo.DueAt = req.DueAt
+	}
+	// Start at and due at are checked with the current value of the other
+	err = checkTodo(todo)
+	if err != nil {
+		return todo, err
+	}
+	// Move to other activity group
+	if req.ActivityGroupID != 0 && req.ActivityGroupID != todo.ActivityGroupID {
+		err = s.checkActivityGroup(req.ActivityGroupID)
+		if err != nil {
+			return todo, err
+		}
+		err = requireRole(s.activityRepository, req.ActivityGroupID, domain.RoleEditor)
+		if err != nil {
+			return todo, err
+		}
+		todo.ActivityGroupID = req.ActivityGroupID
 	}
 
 	todo.UpdatedAt = time.Now()
 
 	// Update
-	updatedTodo, err := s.repository.Update(todo)
+	var updatedTodo domain.Todo
+	err = s.withinTransaction(func(s *todoService) error {
+		updatedTodo, err = s.repository.Update(todo)
+		if err != nil {
+			return err
+		}
+		return recordEvent(s.outbox, event.TodoUpdated, updatedTodo)
+	})
 	if err != nil {
-		return updatedTodo, err
+		return updatedTodo, versionError("Todo", id, req.Version, err)
 	}
 
 	return updatedTodo, nil
 }
 
-func (s *todoService) Delete(id uint64) (bool, error) {
+func (s *todoService) Delete(id uint64, version uint64) (bool, error) {
 	// Find one
 	todo, err := s.repository.FindOne(id)
-	// If activity group not found
-	if todo.ID == 0 {
-		message := fmt.Sprintf("Todo with ID %d Not Found", id)
-		return false, errors.New(message)
+	if err != nil {
+		return false, err
 	}
 
+	err = checkVersion("Todo", id, version, todo.Version)
 	if err != nil {
 		return false, err
 	}
 
-	ok, err := s.repository.Delete(todo)
+	err = requireRole(s.activityRepository, todo.ActivityGroupID, domain.RoleEditor)
 	if err != nil {
 		return false, err
 	}
 
+	var ok bool
+	err = s.withinTransaction(func(s *todoService) error {
+		ok, err = s.repository.Delete(todo)
+		if err != nil {
+			return err
+		}
+		// Event has the todo before it is deleted
+		return recordEvent(s.outbox, event.TodoDeleted, todo)
+	})
+	if err != nil {
+		return false, versionError("Todo", id, version, err)
+	}
+
 	return ok, nil
 }
+
+func (s *todoService) GetTrashed() ([]domain.Todo, error) {
+	// Find all deleted
+	todos, err := s.repository.FindTrashed()
+	if err != nil {
+		return todos, err
+	}
+
+	return todos, nil
+}
+
+func (s *todoService) GetTrashedOne(id uint64) (domain.Todo, error) {
+	// Find one deleted
+	todo, err := s.repository.FindTrashedOne(id)
+	if err != nil {
+		return todo, err
+	}
+
+	return todo, nil
+}
+
+func (s *todoService) Restore(id uint64) (domain.Todo, error) {
+	// Find one deleted
+	todo, err := s.repository.FindTrashedOne(id)
+	if err != nil {
+		return todo, err
+	}
+
+	err = requireRole(s.activityRepository, todo.ActivityGroupID, domain.RoleEditor)
+	if err != nil {
+		return todo, err
+	}
+
+	// Activity group must be restored first
+	err = s.checkActivityGroup(todo.ActivityGroupID)
+	if errors.Is(err, ErrActivityGroupNotFound) {
+		return todo, apperror.Conflict("Activity with ID %d is deleted, restore it first", todo.ActivityGroupID).WithCode("activity_group_deleted").Wrap(ErrActivityGroupNotFound)
+	}
+
+	if err != nil {
+		return todo, err
+	}
+
+	// Restored todo is back in the list, so it is created again for subscribers
+	var restoredTodo domain.Todo
+	err = s.withinTransaction(func(s *todoService) error {
+		restoredTodo, err = s.repository.Restore(todo)
+		if err != nil {
+			return err
+		}
+		return recordEvent(s.outbox, event.TodoCreated, restoredTodo)
+	})
+	if err != nil {
+		return restoredTodo, err
+	}
+
+	return restoredTodo, nil
+}
+
+func (s *todoService) Purge(id uint64) (bool, error) {
+	// Find one deleted
+	todo, err := s.repository.FindTrashedOne(id)
+	if err != nil {
+		return false, err
+	}
+
+	err = requireRole(s.activityRepository, todo.ActivityGroupID, domain.RoleEditor)
+	if err != nil {
+		return false, err
+	}
+
+	ok, err := s.repository.Purge(todo)
+	if err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+func (s *todoService) PurgeTrashed() (int64, error) {
+	todos, err := s.repository.FindTrashed()
+	if err != nil {
+		return 0, err
+	}
+
+	// Delete permanently all deleted the owner can change, the others are kept
+	var count int64
+	roles := map[uint64]string{}
+	for _, todo := range todos {
+		role, ok := roles[todo.ActivityGroupID]
+		if !ok {
+			role, err = s.activityRepository.FindRole(todo.ActivityGroupID)
+			if err != nil {
+				return count, err
+			}
+			roles[todo.ActivityGroupID] = role
+		}
+		if !domain.HasRole(role, domain.RoleEditor) {
+			continue
+		}
+
+		_, err = s.repository.Purge(todo)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (s *todoService) Bulk(req web.TodoBulkRequest) ([]domain.TodoBulkResult, error) {
+	results := make([]domain.TodoBulkResult, len(req.Actions))
+	for i, action := range req.Actions {
+		results[i] = domain.TodoBulkResult{ID: action.ID, Action: action.Action}
+	}
+
+	err := s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		for i, action := range req.Actions {
+			if req.Atomic {
+				todo, err := s.inTransaction(tx).bulkAction(action)
+				if err != nil {
+					return &BulkError{i, err}
+				}
+				results[i].Todo = todo
+				continue
+			}
+
+			// Failed action is rolled back alone
+			results[i].Err = tx.Nested(func(tx repository.Transaction) error {
+				todo, err := s.inTransaction(tx).bulkAction(action)
+				results[i].Todo = todo
+				return err
+			})
+		}
+		return nil
+	})
+
+	var bulkErr *BulkError
+	if errors.As(err, &bulkErr) {
+		for i := range results {
+			results[i].Todo = domain.Todo{}
+			results[i].Err = ErrBulkNotApplied
+		}
+		results[bulkErr.Index].Err = bulkErr.Err
+		return results, bulkErr
+	}
+	if err != nil {
+		return results, err
+	}
+
+	return results, nil
+}
+
+// inTransaction return service use repositories of tx
+func (s *todoService) inTransaction(tx repository.Transaction) *todoService {
+	return &todoService{tx.Todo, tx.Activity, s.transactor, tx.Outbox}
+}
+
+// withinTransaction run fn with service in transaction, so change of fn and its event in
+// outbox are committed together. Service already in transaction run fn in it
+func (s *todoService) withinTransaction(fn func(s *todoService) error) error {
+	if s.outbox != nil {
+		return fn(s)
+	}
+
+	return s.transactor.WithinTransaction(func(tx repository.Transaction) error {
+		return fn(s.inTransaction(tx))
+	})
+}
+
+// bulkAction run one action of bulk, return the updated todo or the deleted one
+func (s *todoService) bulkAction(action web.TodoBulkAction) (domain.Todo, error) {
+	if action.Action == domain.BulkActionDelete {
+		todo, err := s.repository.FindOne(action.ID)
+		if err != nil {
+			return todo, err
+		}
+
+		_, err = s.Delete(action.ID, 0)
+		return todo, err
+	}
+
+	return s.Update(action.ID, web.TodoUpdateRequest{
+		IsActive:        action.IsActive,
+		Priority:        action.Priority,
+		ActivityGroupID: action.ActivityGroupID,
+	})
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"github.com/letenk/todo-list/cache"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+)
+
+// cachedTodoService cache the result of TodoService with cache aside,
+// every write remove the cached data it changes
+type cachedTodoService struct {
+	TodoService
+	store cacheStore
+}
+
+func NewServiceTodoCached(service TodoService, cache cache.Cache) *cachedTodoService {
+	return &cachedTodoService{service, cacheStore{cache: cache}}
+}
+
+func (s *cachedTodoService) WithOwner(userID uint64) TodoService {
+	return &cachedTodoService{s.TodoService.WithOwner(userID), s.store.withOwner(userID)}
+}
+
+func (s *cachedTodoService) GetAll(query web.TodoQuery) ([]domain.Todo, error) {
+	// Only todos by activity group is cached
+	if query.IsFiltered() {
+		return s.TodoService.GetAll(query)
+	}
+
+	key := todosKey(query.ActivityGroupID)
+	var todos []domain.Todo
+	if s.store.get(key, &todos) {
+		return todos, nil
+	}
+
+	todos, err := s.TodoService.GetAll(query)
+	if err != nil {
+		return todos, err
+	}
+
+	s.store.set(key, todos)
+	return todos, nil
+}
+
+func (s *cachedTodoService) GetOne(id uint64) (domain.Todo, error) {
+	var todo domain.Todo
+	if s.store.get(todoKey(id), &todo) {
+		return todo, nil
+	}
+
+	todo, err := s.TodoService.GetOne(id)
+	if err != nil {
+		return todo, err
+	}
+
+	// Not found is returned as error, so it is not cached
+	s.store.set(todoKey(id), todo)
+	return todo, nil
+}
+
+func (s *cachedTodoService) Create(req web.TodoCreateRequest) (domain.Todo, error) {
+	todo, err := s.TodoService.Create(req)
+	if err == nil {
+		s.store.deletePrefix(todosPrefix)
+	}
+	return todo, err
+}
+
+func (s *cachedTodoService) Update(id uint64, req web.TodoUpdateRequest) (domain.Todo, error) {
+	todo, err := s.TodoService.Update(id, req)
+	if err == nil {
+		s.store.delete(todoKey(id))
+		// Todo may be moved to other activity group
+		s.store.deletePrefix(todosPrefix)
+	}
+	return todo, err
+}
+
+func (s *cachedTodoService) Delete(id uint64, version uint64) (bool, error) {
+	ok, err := s.TodoService.Delete(id, version)
+	if err == nil {
+		s.store.delete(todoKey(id))
+		s.store.deletePrefix(todosPrefix)
+	}
+	return ok, err
+}
+
+func (s *cachedTodoService) Restore(id uint64) (domain.Todo, error) {
+	todo, err := s.TodoService.Restore(id)
+	if err == nil {
+		s.store.delete(todoKey(id))
+		s.store.deletePrefix(todosPrefix)
+	}
+	return todo, err
+}
+
+// Bulk remove cached data once for the whole batch
+func (s *cachedTodoService) Bulk(req web.TodoBulkRequest) ([]domain.TodoBulkResult, error) {
+	results, err := s.TodoService.Bulk(req)
+
+	var keys []cacheKey
+	for _, result := range results {
+		if result.Err == nil {
+			keys = append(keys, todoKey(result.ID))
+		}
+	}
+	if len(keys) != 0 {
+		s.store.delete(keys...)
+		s.store.deletePrefix(todosPrefix)
+	}
+
+	return results, err
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/letenk/todo-list/apperror"
+)
+
+// ErrInvalidToken returned when bearer token is malformed, expired or not signed by this app
+var ErrInvalidToken = apperror.Unauthorized("token is invalid or expired").WithCode("invalid_token")
+
+type TokenService interface {
+	// Issue sign token of user userID, it is expired at the returned time
+	Issue(userID uint64) (string, time.Time, error)
+	// Verify check signature and expiry of token, then return its user id
+	Verify(token string) (uint64, error)
+}
+
+// tokenService sign token as JWT with HMAC SHA-256, user id is the subject
+type tokenService struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewServiceToken(secret []byte, ttl time.Duration) *tokenService {
+	return &tokenService{secret, ttl}
+}
+
+func (s *tokenService) Issue(userID uint64) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatUint(userID, 10),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", expiresAt, err
+	}
+
+	return token, expiresAt, nil
+}
+
+func (s *tokenService) Verify(token string) (uint64, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, ErrInvalidToken.Wrap(err)
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return 0, ErrInvalidToken.Wrap(errors.New("subject of token is not a user id"))
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/repository"
+)
+
+// ErrVersionMismatch returned when data is not of version expected by request
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// checkVersion return precondition failed error when version expected is not the current
+// version, expected 0 is not checked
+func checkVersion(name string, id uint64, expected uint64, current uint64) error {
+	if expected == 0 || expected == current {
+		return nil
+	}
+
+	return apperror.PreconditionFailed("%s with ID %d is changed, it does not match If-Match", name, id).
+		WithCode("version_mismatch").
+		Wrap(ErrVersionMismatch)
+}
+
+// versionError return precondition failed error when other request change data between
+// it is checked by checkVersion and written, otherwise err as is
+func versionError(name string, id uint64, expected uint64, err error) error {
+	if expected != 0 && errors.Is(err, repository.ErrVersionConflict) {
+		return checkVersion(name, id, expected, 0)
+	}
+
+	return err
+}
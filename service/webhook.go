@@ -0,0 +1,171 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+)
+
+// WebhookSecretPrefix is start of generated secret of webhook
+const WebhookSecretPrefix = "whsec_"
+
+type WebhookService interface {
+	// Create create webhook of user userID, secret is generated when it is not sent
+	Create(userID uint64, req web.WebhookRequest) (domain.Webhook, error)
+	GetAll(userID uint64) ([]domain.Webhook, error)
+	GetOne(userID uint64, id uint64) (domain.Webhook, error)
+	Update(userID uint64, id uint64, req web.WebhookUpdateRequest) (domain.Webhook, error)
+	Delete(userID uint64, id uint64) (bool, error)
+	// GetDeliveries return delivery log of webhook, newest first
+	GetDeliveries(userID uint64, id uint64, query web.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error)
+	// Redeliver send delivery again with every attempt, delivery in dead-letter list included
+	Redeliver(userID uint64, id uint64, deliveryID uint64) (domain.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repository         repository.WebhookRepository
+	deliveryRepository repository.WebhookDeliveryRepository
+	activityRepository repository.ActivityRepository
+}
+
+func NewServiceWebhook(repository repository.WebhookRepository, deliveryRepository repository.WebhookDeliveryRepository, activityRepository repository.ActivityRepository) *webhookService {
+	return &webhookService{repository, deliveryRepository, activityRepository}
+}
+
+// checkActivityGroup check user userID is member of activity group of filter of webhook
+func (s *webhookService) checkActivityGroup(userID uint64, activityID uint64) error {
+	_, err := s.activityRepository.WithOwner(userID).FindOne(activityID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Validation("Activity with ID %d Not Found", activityID).
+			WithCode("activity_group_not_found").
+			WithField("activity_group_id", activityID).
+			Wrap(ErrActivityGroupNotFound)
+	}
+
+	return err
+}
+
+// eventTypes join types without duplicate, in the first given order
+func eventTypes(types []string) string {
+	var result []string
+	for _, t := range types {
+		if !containsString(result, t) {
+			result = append(result, t)
+		}
+	}
+	return strings.Join(result, " ")
+}
+
+func (s *webhookService) Create(userID uint64, req web.WebhookRequest) (domain.Webhook, error) {
+	webhook := domain.Webhook{
+		UserID:     userID,
+		URL:        req.URL,
+		Secret:     req.Secret,
+		EventTypes: eventTypes(req.EventTypes),
+	}
+
+	if req.ActivityGroupID != 0 {
+		err := s.checkActivityGroup(userID, req.ActivityGroupID)
+		if err != nil {
+			return webhook, err
+		}
+		webhook.ActivityGroupID = &req.ActivityGroupID
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 24)
+		_, err := rand.Read(secret)
+		if err != nil {
+			return webhook, err
+		}
+		webhook.Secret = WebhookSecretPrefix + hex.EncodeToString(secret)
+	}
+
+	return s.repository.Save(webhook)
+}
+
+func (s *webhookService) GetAll(userID uint64) ([]domain.Webhook, error) {
+	return s.repository.FindByUserID(userID)
+}
+
+func (s *webhookService) GetOne(userID uint64, id uint64) (domain.Webhook, error) {
+	return s.repository.FindOne(userID, id)
+}
+
+func (s *webhookService) Update(userID uint64, id uint64, req web.WebhookUpdateRequest) (domain.Webhook, error) {
+	// Get one, webhook of other user is not found
+	webhook, err := s.repository.FindOne(userID, id)
+	if err != nil {
+		return webhook, err
+	}
+
+	if req.URL != "" {
+		webhook.URL = req.URL
+	}
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if len(req.EventTypes) != 0 {
+		webhook.EventTypes = eventTypes(req.EventTypes)
+	}
+	if req.ActivityGroupID != nil {
+		webhook.ActivityGroupID = nil
+		if *req.ActivityGroupID != 0 {
+			err = s.checkActivityGroup(userID, *req.ActivityGroupID)
+			if err != nil {
+				return webhook, err
+			}
+			webhook.ActivityGroupID = req.ActivityGroupID
+		}
+	}
+	webhook.UpdatedAt = time.Now()
+
+	return s.repository.Update(webhook)
+}
+
+func (s *webhookService) Delete(userID uint64, id uint64) (bool, error) {
+	// Delete, webhook of other user is not found
+	return s.repository.Delete(userID, id)
+}
+
+func (s *webhookService) GetDeliveries(userID uint64, id uint64, query web.WebhookDeliveryQuery) ([]domain.WebhookDelivery, error) {
+	webhook, err := s.repository.FindOne(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = web.DefaultDeliveryLimit
+	}
+	return s.deliveryRepository.FindByWebhookID(webhook.ID, query.Status, limit)
+}
+
+func (s *webhookService) Redeliver(userID uint64, id uint64, deliveryID uint64) (domain.WebhookDelivery, error) {
+	webhook, err := s.repository.FindOne(userID, id)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.deliveryRepository.FindOne(webhook.ID, deliveryID)
+	if err != nil {
+		return delivery, err
+	}
+
+	if delivery.Status == domain.DeliveryPending {
+		return delivery, apperror.Conflict("Delivery with ID %d is pending, it is sent already", deliveryID).WithCode("delivery_pending")
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	return s.deliveryRepository.Update(delivery)
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+)
+
+// Default retry of webhook delivery, delay is doubled for every next attempt so the
+// last attempt is about 1 hour after the first with the defaults
+const (
+	WebhookBackoff     = 30 * time.Second
+	WebhookMaxAttempts = 8
+)
+
+const (
+	// webhookTimeout is the longest wait of response of webhook
+	webhookTimeout = 10 * time.Second
+	// webhookPollInterval is interval of check of deliveries to retry
+	webhookPollInterval = time.Second
+	// webhookBatch is the most deliveries sent by one check
+	webhookBatch = 100
+	// webhookErrorLength is the longest error stored of an attempt
+	webhookErrorLength = 1024
+)
+
+// SignWebhook return signature of header X-Webhook-Signature, it is HMAC-SHA256 with
+// secret of webhook of header X-Webhook-Timestamp and body joined by "."
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrWebhookAddressNotAllowed is error of webhook connect to loopback, private, link-local
+// or unspecified address, so webhook cannot reach service of the internal network
+var ErrWebhookAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// internalNetworks are ranges not reachable from the internet that net.IP has no check of,
+// shared address space of carrier-grade NAT and "this network"
+var internalNetworks = []*net.IPNet{
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+}
+
+// isInternalIP check ip is loopback, private, link-local, multicast, unspecified, shared
+// address space or "this network"
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewWebhookClient create client of webhook. Address is checked when the connection is
+// dialed, after the host is resolved, so host resolved to internal address is refused too.
+// allowInternal skip the check, only for test. Redirect is not followed and proxy is not used
+func NewWebhookClient(allowInternal bool) *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout}
+	if !allowInternal {
+		dialer.Control = func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isInternalIP(ip) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout, MaxIdleConnsPerHost: 2},
+		// Redirect can point to internal address, response of redirect is failed delivery
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// WebhookDispatcher create delivery of events for subscribed webhooks and post them,
+// failed delivery is retried with exponential backoff until it is dead
+type WebhookDispatcher struct {
+	repository         repository.WebhookRepository
+	deliveryRepository repository.WebhookDeliveryRepository
+	activityRepository repository.ActivityRepository
+	client             *http.Client
+	backoff            time.Duration
+	maxAttempts        int
+	// wake start delivery of new deliveries before the next poll
+	wake chan struct{}
+}
+
+func NewServiceWebhookDispatcher(repository repository.WebhookRepository, deliveryRepository repository.WebhookDeliveryRepository, activityRepository repository.ActivityRepository, backoff time.Duration, maxAttempts int) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repository:         repository,
+		deliveryRepository: deliveryRepository,
+		activityRepository: activityRepository,
+		client:             NewWebhookClient(false),
+		backoff:            backoff,
+		maxAttempts:        maxAttempts,
+		wake:               make(chan struct{}, 1),
+	}
+}
+
+// WithClient return dispatcher post deliveries with client
+func (d *WebhookDispatcher) WithClient(client *http.Client) *WebhookDispatcher {
+	dispatcher := *d
+	dispatcher.client = client
+	return &dispatcher
+}
+
+// Send create delivery of event for every webhook subscribed to it, it is EventSink of
+// outbox. Owner of webhook must be member of activity group of the event, like subscriber
+// of events
+func (d *WebhookDispatcher) Send(e event.Event) error {
+	webhooks, err := d.repository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.IsSubscribed(e.Type, e.ActivityGroupID) {
+			continue
+		}
+
+		role, err := d.activityRepository.WithOwner(webhook.UserID).FindRole(e.ActivityGroupID)
+		if errors.Is(err, apperror.ErrNotFound) || (err == nil && !domain.HasRole(role, domain.RoleViewer)) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		// Every delivery of the event post the same body
+		if payload == nil {
+			payload, err = json.Marshal(web.FormatEvent(e))
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = d.deliveryRepository.Save(domain.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       payload,
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+		// Webhook deleted meanwhile has no delivery. Delivery of the event exists already when
+		// the outbox send the event again after failed send, the webhook got it already
+		if err != nil && !errors.Is(err, apperror.ErrValidation) && !errors.Is(err, apperror.ErrConflict) {
+			return err
+		}
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// DeliverDue post deliveries due at at, and return the number of posted deliveries
+func (d *WebhookDispatcher) DeliverDue(at time.Time) (int, error) {
+	deliveries, err := d.deliveryRepository.FindDue(at, webhookBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, delivery := range deliveries {
+		// Claimed delivery is retried after the lease when the worker stop before it is posted
+		claimed, err := d.deliveryRepository.Claim(delivery.ID, at, at.Add(2*webhookTimeout))
+		if err != nil {
+			return count, err
+		}
+		if !claimed {
+			continue
+		}
+
+		err = d.deliver(delivery)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// deliver post delivery to its webhook once, and store the result of the attempt
+func (d *WebhookDispatcher) deliver(delivery domain.WebhookDelivery) error {
+	webhook, err := d.repository.FindOne(0, delivery.WebhookID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	delivery.Attempts++
+	statusCode, err := d.post(webhook, delivery)
+	delivery.LastStatusCode = statusCode
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = domain.DeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(d.backoff << (delivery.Attempts - 1))
+	}
+	if len(delivery.LastError) > webhookErrorLength {
+		delivery.LastError = delivery.LastError[:webhookErrorLength]
+	}
+
+	_, err = d.deliveryRepository.Update(delivery)
+	return err
+}
+
+// post send payload of delivery signed by secret of webhook, response other than 2xx is error
+func (d *WebhookDispatcher) post(webhook domain.Webhook, delivery domain.WebhookDelivery) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "todo-list-webhook")
+	request.Header.Set("X-Webhook-ID", strconv.FormatUint(webhook.ID, 10))
+	request.Header.Set("X-Webhook-Delivery", strconv.FormatUint(delivery.ID, 10))
+	request.Header.Set("X-Webhook-Event", delivery.EventType)
+	request.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	request.Header.Set("X-Webhook-Signature", SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	// Read some of the body, so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("webhook responded status %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// Run post due deliveries when it is waked by new delivery, or every poll interval for
+// retry, until ctx is done
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+
+		_, err := d.DeliverDue(time.Now())
+		if err != nil {
+			log.Printf("Failed to deliver webhooks %v", err)
+		}
+	}
+}
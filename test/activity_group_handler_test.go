@@ -336,3 +336,170 @@ func TestDeleteActivity(t *testing.T) {
 		require.Empty(t, responseBody["data"])
 	})
 }
+
+func TestGetAllPageActivityHandler(t *testing.T) {
+	t.Parallel()
+	for i := 0; i < 3; i++ {
+		createRandomActivityHandler(t)
+	}
+
+	t.Run("Get first page", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3030/activity-groups?limit=2&sort=-id", nil)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		var contextData = responseBody["data"].([]interface{})
+		require.Equal(t, 2, len(contextData))
+
+		require.NotEmpty(t, response.Header.Get("X-Total-Count"))
+		link := response.Header.Get("Link")
+		require.Contains(t, link, `rel="first"`)
+		require.Contains(t, link, `rel="next"`)
+		require.Contains(t, link, "cursor=")
+
+		// Follow link next
+		next := strings.Split(link, ", ")[1]
+		next = next[strings.Index(next, "<")+1 : strings.Index(next, ">")]
+		request = httptest.NewRequest(http.MethodGet, "http://localhost:3030"+next, nil)
+		recorder = httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response = recorder.Result()
+		body, _ = io.ReadAll(response.Body)
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		nextData := responseBody["data"].([]interface{})
+		require.Equal(t, 2, len(nextData))
+
+		// Sorted by id descending without duplicate
+		lastID := contextData[1].(map[string]interface{})["id"].(float64)
+		for _, data := range nextData {
+			require.Less(t, data.(map[string]interface{})["id"].(float64), lastID)
+		}
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3030/activity-groups?limit=1000", nil)
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 400, response.StatusCode)
+		require.Equal(t, "limit must be between 1 and 100", responseBody["message"])
+	})
+
+	t.Run("Invalid sort", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3030/activity-groups?sort=email", nil)
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		require.Equal(t, 400, response.StatusCode)
+	})
+}
+
+func TestDeleteWithPolicyActivityHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Delete restrict with todos", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+
+		id := fmt.Sprintf("%d", newTodo.ActivityID)
+		request := httptest.NewRequest(http.MethodDelete, "http://localhost:3030/activity-groups/"+id+"?policy=restrict", nil)
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 409, response.StatusCode)
+		require.Equal(t, "Conflict", responseBody["status"])
+		message := fmt.Sprintf("Activity with ID %s still has todos", id)
+		require.Equal(t, message, responseBody["message"])
+	})
+
+	t.Run("Delete move to not found", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+
+		id := fmt.Sprintf("%d", newTodo.ActivityID)
+		request := httptest.NewRequest(http.MethodDelete, "http://localhost:3030/activity-groups/"+id+"?move_to=999999", nil)
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 422, response.StatusCode)
+		require.Equal(t, "Activity with ID 999999 Not Found", responseBody["message"])
+	})
+
+	t.Run("Delete move to other activity group", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+		target := createRandomActivityHandler(t)
+
+		url := fmt.Sprintf("http://localhost:3030/activity-groups/%d?move_to=%d", newTodo.ActivityID, target.ID)
+		request := httptest.NewRequest(http.MethodDelete, url, nil)
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		require.Equal(t, 200, recorder.Result().StatusCode)
+
+		url = fmt.Sprintf("http://localhost:3030/todo-items?activity_group_id=%d&sort=id", target.ID)
+		request = httptest.NewRequest(http.MethodGet, url, nil)
+		recorder = httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		contextData := responseBody["data"].([]interface{})
+		require.Equal(t, 1, len(contextData))
+		require.Equal(t, newTodo.ID, uint64(contextData[0].(map[string]interface{})["id"].(float64)))
+	})
+
+	t.Run("Delete with invalid policy", func(t *testing.T) {
+		newActivity := createRandomActivityHandler(t)
+
+		id := fmt.Sprintf("%d", newActivity.ID)
+		request := httptest.NewRequest(http.MethodDelete, "http://localhost:3030/activity-groups/"+id+"?policy=orphan", nil)
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		require.Equal(t, 400, recorder.Result().StatusCode)
+	})
+}
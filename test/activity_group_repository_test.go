@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/letenk/todo-list/apperror"
 	"github.com/letenk/todo-list/helper"
 	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/repository"
@@ -102,7 +103,6 @@ func TestUpdateActivityRepository(t *testing.T) {
 		Email:     jabufaker.RandomEmail(),
 		CreatedAt: newActivity.CreatedAt,
 		UpdatedAt: time.Now(),
-		DeletedAt: nil,
 	}
 
 	// update
@@ -128,6 +128,6 @@ func TestDeleteActivityRepository(t *testing.T) {
 	require.True(t, ok)
 
 	Activity, err := ActivityRepository.FindOne(newActivity.ID)
-	helper.ErrLogPanic(err)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
 	require.Equal(t, 0, int(Activity.ID))
 }
@@ -5,18 +5,19 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/letenk/todo-list/apperror"
 	"github.com/letenk/todo-list/helper"
 	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/models/web"
-	"github.com/letenk/todo-list/repository"
 	"github.com/letenk/todo-list/service"
 	"github.com/rizkydarmawan-letenk/jabufaker"
 	"github.com/stretchr/testify/require"
 )
 
 func createRandomActivityService(t *testing.T) domain.Activity {
-	repository := repository.NewRepositoryActivity(ConnTest)
-	service := service.NewServiceActivity(repository)
+	transactor := Repositories.Transactor
+	repository := Repositories.Activity
+	service := service.NewServiceActivity(repository, transactor)
 
 	data := web.ActivityRequest{
 		Title: jabufaker.RandomString(20),
@@ -33,7 +34,7 @@ func createRandomActivityService(t *testing.T) domain.Activity {
 	require.NotEmpty(t, newActivity.ID)
 	require.NotEmpty(t, newActivity.CreatedAt)
 	require.NotEmpty(t, newActivity.UpdatedAt)
-	require.Nil(t, newActivity.DeletedAt)
+	require.False(t, newActivity.DeletedAt.Valid)
 
 	return newActivity
 }
@@ -58,11 +59,12 @@ func TestGetAllServices(t *testing.T) {
 
 	t.Parallel()
 
-	repository := repository.NewRepositoryActivity(ConnTest)
-	service := service.NewServiceActivity(repository)
+	transactor := Repositories.Transactor
+	repository := Repositories.Activity
+	service := service.NewServiceActivity(repository, transactor)
 
 	// Get activity groups
-	Activitys, err := service.GetAll()
+	Activitys, err := service.GetAll(web.ActivityQuery{})
 	helper.ErrLogPanic(err)
 
 	for _, data := range Activitys {
@@ -71,7 +73,7 @@ func TestGetAllServices(t *testing.T) {
 		require.NotEmpty(t, data.Email)
 		require.NotEmpty(t, data.CreatedAt)
 		require.NotEmpty(t, data.UpdatedAt)
-		require.Nil(t, data.DeletedAt)
+		require.False(t, data.DeletedAt.Valid)
 	}
 
 }
@@ -82,8 +84,9 @@ func TestGetOneService(t *testing.T) {
 	newActivity := createRandomActivityService(t)
 
 	t.Parallel()
-	repository := repository.NewRepositoryActivity(ConnTest)
-	service := service.NewServiceActivity(repository)
+	transactor := Repositories.Transactor
+	repository := Repositories.Activity
+	service := service.NewServiceActivity(repository, transactor)
 
 	// Find all
 	Activity, err := service.GetOne(newActivity.ID)
@@ -94,7 +97,7 @@ func TestGetOneService(t *testing.T) {
 	require.Equal(t, newActivity.Email, Activity.Email)
 	require.NotEmpty(t, Activity.CreatedAt)
 	require.NotEmpty(t, Activity.UpdatedAt)
-	require.Nil(t, Activity.DeletedAt)
+	require.False(t, Activity.DeletedAt.Valid)
 }
 
 func TestUpdateActivityService(t *testing.T) {
@@ -103,8 +106,9 @@ func TestUpdateActivityService(t *testing.T) {
 	newActivity := createRandomActivityService(t)
 
 	t.Parallel()
-	repository := repository.NewRepositoryActivity(ConnTest)
-	service := service.NewServiceActivity(repository)
+	transactor := Repositories.Transactor
+	repository := Repositories.Activity
+	service := service.NewServiceActivity(repository, transactor)
 
 	dataUpdated := web.ActivityUpdateRequest{
 		Title: jabufaker.RandomString(20),
@@ -122,7 +126,7 @@ func TestUpdateActivityService(t *testing.T) {
 		require.NotEqual(t, newActivity.UpdatedAt, updatedActivity.UpdatedAt)
 
 		require.NotEmpty(t, updatedActivity.CreatedAt)
-		require.Nil(t, updatedActivity.DeletedAt)
+		require.False(t, updatedActivity.DeletedAt.Valid)
 
 	})
 
@@ -142,8 +146,9 @@ func TestDeleteActivityService(t *testing.T) {
 	newActivity := createRandomActivityService(t)
 
 	t.Parallel()
-	repository := repository.NewRepositoryActivity(ConnTest)
-	service := service.NewServiceActivity(repository)
+	transactor := Repositories.Transactor
+	repository := Repositories.Activity
+	service := service.NewServiceActivity(repository, transactor)
 
 	t.Run("Delete success", func(t *testing.T) {
 
@@ -164,3 +169,77 @@ func TestDeleteActivityService(t *testing.T) {
 
 	})
 }
+
+func TestDeleteWithPolicyActivityService(t *testing.T) {
+	t.Parallel()
+	transactor := Repositories.Transactor
+	todoRepository := Repositories.Todo
+	activityRepository := Repositories.Activity
+	activityService := service.NewServiceActivity(activityRepository, transactor)
+
+	t.Run("Delete cascade and restore", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+
+		ok, err := activityService.DeleteWithPolicy(newTodo.ActivityGroupID, web.ActivityDeleteQuery{Policy: web.DeletePolicyCascade})
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+
+		// Todo is deleted with activity group
+		todo, err := todoRepository.FindOne(newTodo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, uint64(0), todo.ID)
+
+		// Todo is restored with activity group
+		_, err = activityService.Restore(newTodo.ActivityGroupID)
+		helper.ErrLogPanic(err)
+
+		todo, err = todoRepository.FindOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, newTodo.ID, todo.ID)
+	})
+
+	t.Run("Delete restrict", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+
+		ok, err := activityService.DeleteWithPolicy(newTodo.ActivityGroupID, web.ActivityDeleteQuery{Policy: web.DeletePolicyRestrict})
+		require.ErrorIs(t, err, service.ErrActivityHasTodos)
+		require.False(t, ok)
+
+		// Activity group is not deleted
+		activity, err := activityRepository.FindOne(newTodo.ActivityGroupID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, newTodo.ActivityGroupID, activity.ID)
+
+		// Activity group without todos can be deleted
+		newActivity := createRandomActivityService(t)
+		ok, err = activityService.DeleteWithPolicy(newActivity.ID, web.ActivityDeleteQuery{Policy: web.DeletePolicyRestrict})
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+	})
+
+	t.Run("Delete move todos", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+		target := createRandomActivityService(t)
+
+		ok, err := activityService.DeleteWithPolicy(newTodo.ActivityGroupID, web.ActivityDeleteQuery{MoveTo: target.ID})
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+
+		todo, err := todoRepository.FindOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, target.ID, todo.ActivityGroupID)
+	})
+
+	t.Run("Delete move todos to not found activity group", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+
+		ok, err := activityService.DeleteWithPolicy(newTodo.ActivityGroupID, web.ActivityDeleteQuery{MoveTo: 7329323})
+		require.ErrorIs(t, err, service.ErrMoveToNotFound)
+		require.False(t, ok)
+
+		// Rolled back
+		todo, err := todoRepository.FindOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, newTodo.ActivityGroupID, todo.ActivityGroupID)
+	})
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+// createAPIKeyHandler create API key of user of token with scopes, return its id and key
+func createAPIKeyHandler(t *testing.T, token string, scopes string) (uint64, string) {
+	body := fmt.Sprintf(`{"name": "%s", "scopes": [%s]}`, jabufaker.RandomString(10), scopes)
+	response, responseBody := requestAuth(http.MethodPost, "/api-keys", body, token)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+
+	data := responseBody["data"].(map[string]interface{})
+	key := data["key"].(string)
+	require.Contains(t, key, data["prefix"].(string))
+	require.Nil(t, data["last_used_at"])
+
+	return uint64(data["id"].(float64)), key
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	t.Parallel()
+	user := createUser(jabufaker.RandomEmail())
+
+	t.Run("Create, list and revoke", func(t *testing.T) {
+		id, key := createAPIKeyHandler(t, user.AccessToken, `"todos:read", "todos:read"`)
+
+		response, _ := requestAuth(http.MethodGet, "/todo-items", "", key)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		// Key is not shown again, last used is recorded
+		response, responseBody := requestAuth(http.MethodGet, "/api-keys", "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		keys := responseBody["data"].([]interface{})
+		require.Equal(t, 1, len(keys))
+		listed := keys[0].(map[string]interface{})
+		require.Nil(t, listed["key"])
+		require.Equal(t, []interface{}{"todos:read"}, listed["scopes"])
+		require.NotNil(t, listed["last_used_at"])
+
+		// Other user cannot revoke it
+		response, _ = requestAuth(http.MethodDelete, fmt.Sprintf("/api-keys/%d", id), "", TestUser.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+
+		response, _ = requestAuth(http.MethodDelete, fmt.Sprintf("/api-keys/%d", id), "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		response, responseBody = requestAuth(http.MethodGet, "/todo-items", "", key)
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		require.Equal(t, "API key is invalid or revoked", responseBody["message"])
+	})
+
+	t.Run("Invalid scope", func(t *testing.T) {
+		body := `{"name": "script", "scopes": ["todos:delete"]}`
+		response, _ := requestAuth(http.MethodPost, "/api-keys", body, user.AccessToken)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		body = `{"name": "script", "scopes": []}`
+		response, _ = requestAuth(http.MethodPost, "/api-keys", body, user.AccessToken)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("Scope is checked by method", func(t *testing.T) {
+		_, readKey := createAPIKeyHandler(t, user.AccessToken, `"todos:read"`)
+		_, adminKey := createAPIKeyHandler(t, user.AccessToken, `"groups:admin", "todos:write"`)
+
+		body := fmt.Sprintf(`{"title": "script", "email": "%s"}`, jabufaker.RandomEmail())
+		response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, readKey)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+		require.Equal(t, "API key require scope groups:admin", responseBody["message"])
+		require.Contains(t, response.Header.Get("WWW-Authenticate"), "insufficient_scope")
+
+		response, _ = requestAuth(http.MethodGet, "/trash", "", readKey)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+		// Data created with key is owned by user of the key
+		response, responseBody = requestAuth(http.MethodPost, "/activity-groups", body, adminKey)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+
+		body = fmt.Sprintf(`{"title": "script", "activity_group_id": %d}`, activityID)
+		response, _ = requestAuth(http.MethodPost, "/todo-items", body, adminKey)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		response, _ = requestAuth(http.MethodGet, fmt.Sprintf("/activity-groups/%d", activityID), "", readKey)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		response, _ = requestAuth(http.MethodGet, fmt.Sprintf("/activity-groups/%d", activityID), "", TestUser.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+
+		// Key cannot manage keys
+		response, _ = requestAuth(http.MethodGet, "/api-keys", "", adminKey)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+	})
+}
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/service"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticateAPIKeyService(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	user, err := repository.NewRepositoryUserMemory(store).Save(domain.User{Email: jabufaker.RandomEmail(), Name: "Test User", PasswordHash: "hash"})
+	helper.ErrLogPanic(err)
+
+	apiKeyService := service.NewServiceAPIKey(repository.NewRepositoryAPIKeyMemory(store), repository.NewRepositoryUserMemory(store))
+	apiKey, key, err := apiKeyService.Create(user.ID, web.APIKeyRequest{Name: "script", Scopes: []string{domain.ScopeTodosRead}})
+	helper.ErrLogPanic(err)
+
+	t.Run("Authenticate success", func(t *testing.T) {
+		authenticated, err := apiKeyService.Authenticate(key)
+		helper.ErrLogPanic(err)
+		require.Equal(t, apiKey.ID, authenticated.ID)
+		require.Equal(t, user.ID, authenticated.UserID)
+	})
+
+	t.Run("Authenticate failed user is deleted", func(t *testing.T) {
+		// User repository of other store has no user of the key
+		withoutUser := service.NewServiceAPIKey(repository.NewRepositoryAPIKeyMemory(store), repository.NewRepositoryUserMemory(repository.NewMemoryStore()))
+
+		authenticated, err := withoutUser.Authenticate(key)
+		require.ErrorIs(t, err, apperror.ErrUnauthorized)
+		require.Equal(t, service.ErrInvalidAPIKey.Error(), err.Error())
+		require.Empty(t, authenticated.ID)
+	})
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letenk/todo-list/service"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+// requestAuth send request with header Authorization, empty token send request without it
+func requestAuth(method string, target string, body string, token string) (*http.Response, map[string]interface{}) {
+	request := httptest.NewRequest(method, "http://localhost:3030"+target, strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	// Route set token of TestUser when the header is not exist
+	request.Header["Authorization"] = nil
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	Route.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	responseBody, _ := io.ReadAll(response.Body)
+	var data map[string]interface{}
+	json.Unmarshal(responseBody, &data)
+
+	return response, data
+}
+
+func TestAuthHandler(t *testing.T) {
+	t.Parallel()
+	email := jabufaker.RandomEmail()
+
+	t.Run("Register, login and get current user", func(t *testing.T) {
+		body := fmt.Sprintf(`{"email": "%s", "name": "Alice", "password": "password123"}`, email)
+		response, responseBody := requestAuth(http.MethodPost, "/auth/register", body, "")
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		data := responseBody["data"].(map[string]interface{})
+		require.NotEmpty(t, data["access_token"])
+		require.Equal(t, "Bearer", data["token_type"])
+		user := data["user"].(map[string]interface{})
+		require.Equal(t, email, user["email"])
+		require.Nil(t, user["password_hash"])
+
+		body = fmt.Sprintf(`{"email": "%s", "password": "password123"}`, email)
+		response, responseBody = requestAuth(http.MethodPost, "/auth/login", body, "")
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		token := responseBody["data"].(map[string]interface{})["access_token"].(string)
+
+		response, responseBody = requestAuth(http.MethodGet, "/auth/me", "", token)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, email, responseBody["data"].(map[string]interface{})["email"])
+	})
+
+	t.Run("Register failed", func(t *testing.T) {
+		body := fmt.Sprintf(`{"email": "%s", "name": "Alice", "password": "short"}`, jabufaker.RandomEmail())
+		response, _ := requestAuth(http.MethodPost, "/auth/register", body, "")
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		body = fmt.Sprintf(`{"email": "%s", "name": "Alice", "password": "password123"}`, TestUser.User.Email)
+		response, responseBody := requestAuth(http.MethodPost, "/auth/register", body, "")
+		require.Equal(t, http.StatusConflict, response.StatusCode)
+		require.Equal(t, "email", responseBody["data"].(map[string]interface{})["field"])
+	})
+
+	t.Run("Login with wrong password", func(t *testing.T) {
+		body := fmt.Sprintf(`{"email": "%s", "password": "wrong-password"}`, TestUser.User.Email)
+		response, responseBody := requestAuth(http.MethodPost, "/auth/login", body, "")
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		require.Equal(t, "email or password is wrong", responseBody["message"])
+	})
+
+	t.Run("Missing or invalid token", func(t *testing.T) {
+		expired, _, err := service.NewServiceToken([]byte("secret"), -time.Minute).Issue(TestUser.User.ID)
+		require.NoError(t, err)
+		otherSecret, _, err := service.NewServiceToken([]byte("other"), time.Hour).Issue(TestUser.User.ID)
+		require.NoError(t, err)
+
+		for _, token := range []string{"", "invalid", expired, otherSecret} {
+			for _, target := range []string{"/activity-groups", "/todo-items", "/trash", "/auth/me"} {
+				response, responseBody := requestAuth(http.MethodGet, target, "", token)
+				require.Equal(t, http.StatusUnauthorized, response.StatusCode, target)
+				require.Equal(t, "Unauthorized", responseBody["status"])
+				require.Contains(t, response.Header.Get("WWW-Authenticate"), "Bearer")
+			}
+		}
+	})
+}
+
+func TestOwnershipHandler(t *testing.T) {
+	t.Parallel()
+	newActivity := createRandomActivityHandler(t)
+	newTodo := createRandomTodoHandler(t)
+	other := createUser(jabufaker.RandomEmail())
+
+	// Activity group and todo of TestUser is not found for the other user
+	for _, target := range []string{
+		fmt.Sprintf("/activity-groups/%d", newActivity.ID),
+		fmt.Sprintf("/todo-items/%d", newTodo.ID),
+	} {
+		response, _ := requestAuth(http.MethodGet, target, "", other.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode, target)
+
+		response, _ = requestAuth(http.MethodPatch, target, `{"title": "changed"}`, other.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode, target)
+
+		response, _ = requestAuth(http.MethodDelete, target, "", other.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode, target)
+	}
+
+	// Todo cannot be created in activity group of other user
+	body := fmt.Sprintf(`{"title": "todo", "activity_group_id": %d}`, newActivity.ID)
+	response, _ := requestAuth(http.MethodPost, "/todo-items", body, other.AccessToken)
+	require.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+
+	response, responseBody := requestAuth(http.MethodGet, "/activity-groups", "", other.AccessToken)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Empty(t, responseBody["data"])
+
+	// Owner still see them unchanged
+	response, responseBody = requestAuth(http.MethodGet, fmt.Sprintf("/activity-groups/%d", newActivity.ID), "", TestUser.AccessToken)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, newActivity.Title, responseBody["data"].(map[string]interface{})["title"])
+}
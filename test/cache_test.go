@@ -0,0 +1,245 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/cache"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/service"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache(t *testing.T) {
+	t.Parallel()
+	memoryCache := cache.NewCacheMemory()
+
+	t.Run("Get not found", func(t *testing.T) {
+		_, err := memoryCache.Get("not-found")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+	})
+
+	t.Run("Set and get", func(t *testing.T) {
+		err := memoryCache.Set("key", []byte("value"), time.Hour)
+		helper.ErrLogPanic(err)
+
+		value, err := memoryCache.Get("key")
+		helper.ErrLogPanic(err)
+		require.Equal(t, "value", string(value))
+	})
+
+	t.Run("Get expired", func(t *testing.T) {
+		err := memoryCache.Set("expired", []byte("value"), time.Millisecond)
+		helper.ErrLogPanic(err)
+
+		time.Sleep(10 * time.Millisecond)
+		_, err = memoryCache.Get("expired")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+	})
+
+	t.Run("Delete and delete prefix", func(t *testing.T) {
+		for _, key := range []string{"todos:1", "todos:2", "todo:1"} {
+			err := memoryCache.Set(key, []byte("value"), time.Hour)
+			helper.ErrLogPanic(err)
+		}
+
+		err := memoryCache.Delete("todo:1", "not-found")
+		helper.ErrLogPanic(err)
+		_, err = memoryCache.Get("todo:1")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+
+		err = memoryCache.DeletePrefix("todos:")
+		helper.ErrLogPanic(err)
+		_, err = memoryCache.Get("todos:1")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+		_, err = memoryCache.Get("todos:2")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+	})
+}
+
+func TestCachedTodoService(t *testing.T) {
+	t.Parallel()
+
+	activityRepository := Repositories.Activity
+	todoRepository := Repositories.Todo
+	todoService := service.NewServiceTodoCached(service.NewServiceTodo(todoRepository, activityRepository, Repositories.Transactor), cache.NewCacheMemory())
+
+	newActivity := createRandomActivityService(t)
+	newTodo, err := todoService.Create(web.TodoCreateRequest{
+		ActivityGroupID: newActivity.ID,
+		Title:           jabufaker.RandomString(20),
+	})
+	helper.ErrLogPanic(err)
+
+	query := web.TodoQuery{ActivityGroupID: newActivity.ID}
+
+	t.Run("Get one after update", func(t *testing.T) {
+		// Cache the todo
+		_, err := todoService.GetOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+
+		title := jabufaker.RandomString(20)
+		_, err = todoService.Update(newTodo.ID, web.TodoUpdateRequest{Title: title})
+		helper.ErrLogPanic(err)
+
+		todo, err := todoService.GetOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, title, todo.Title)
+	})
+
+	t.Run("Get all after create", func(t *testing.T) {
+		todos, err := todoService.GetAll(query)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, len(todos))
+
+		_, err = todoService.Create(web.TodoCreateRequest{
+			ActivityGroupID: newActivity.ID,
+			Title:           jabufaker.RandomString(20),
+		})
+		helper.ErrLogPanic(err)
+
+		todos, err = todoService.GetAll(query)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 2, len(todos))
+	})
+
+	t.Run("Get one after bulk", func(t *testing.T) {
+		_, err := todoService.GetOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+
+		results, err := todoService.Bulk(web.TodoBulkRequest{Actions: []web.TodoBulkAction{
+			{Action: domain.BulkActionUpdate, ID: newTodo.ID, Priority: domain.PriorityVeryLow},
+		}})
+		helper.ErrLogPanic(err)
+		require.NoError(t, results[0].Err)
+
+		todo, err := todoService.GetOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, domain.PriorityVeryLow, todo.Priority)
+	})
+
+	t.Run("Get all after delete", func(t *testing.T) {
+		todos, err := todoService.GetAll(query)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 2, len(todos))
+
+		_, err = todoService.Delete(newTodo.ID, 0)
+		helper.ErrLogPanic(err)
+
+		todos, err = todoService.GetAll(query)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, len(todos))
+
+		todo, err := todoService.GetOne(newTodo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(todo.ID))
+	})
+}
+
+func TestCachedActivityService(t *testing.T) {
+	t.Parallel()
+
+	memoryCache := cache.NewCacheMemory()
+	transactor := Repositories.Transactor
+	activityRepository := Repositories.Activity
+	todoRepository := Repositories.Todo
+	activityService := service.NewServiceActivityCached(service.NewServiceActivity(activityRepository, transactor), memoryCache)
+	todoService := service.NewServiceTodoCached(service.NewServiceTodo(todoRepository, activityRepository, Repositories.Transactor), memoryCache)
+
+	newActivity := createRandomActivityService(t)
+	newTodo, err := todoService.Create(web.TodoCreateRequest{
+		ActivityGroupID: newActivity.ID,
+		Title:           jabufaker.RandomString(20),
+	})
+	helper.ErrLogPanic(err)
+
+	t.Run("Get one after update", func(t *testing.T) {
+		_, err := activityService.GetOne(newActivity.ID)
+		helper.ErrLogPanic(err)
+
+		title := jabufaker.RandomString(20)
+		_, err = activityService.Update(newActivity.ID, web.ActivityUpdateRequest{Title: title})
+		helper.ErrLogPanic(err)
+
+		activity, err := activityService.GetOne(newActivity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, title, activity.Title)
+	})
+
+	t.Run("Todos removed after delete", func(t *testing.T) {
+		// Cache the todo and todos of activity group
+		_, err := todoService.GetOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		_, err = todoService.GetAll(web.TodoQuery{ActivityGroupID: newActivity.ID})
+		helper.ErrLogPanic(err)
+
+		_, err = activityService.Delete(newActivity.ID)
+		helper.ErrLogPanic(err)
+
+		activity, err := activityService.GetOne(newActivity.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(activity.ID))
+
+		todo, err := todoService.GetOne(newTodo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(todo.ID))
+
+		todos, err := todoService.GetAll(web.TodoQuery{ActivityGroupID: newActivity.ID})
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, len(todos))
+	})
+}
+
+func TestCachedMembershipService(t *testing.T) {
+	t.Parallel()
+
+	memoryCache := cache.NewCacheMemory()
+	r := newMemoryRepositories(t)
+	activityService := service.NewServiceActivityCached(service.NewServiceActivity(r.activity, r.transactor), memoryCache)
+	todoService := service.NewServiceTodoCached(service.NewServiceTodo(r.todo, r.activity, r.transactor), memoryCache)
+	membershipService := service.NewServiceMembershipCached(service.NewServiceMembership(r.membership, r.activity, r.user, r.transactor), memoryCache)
+
+	owner, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "owner", PasswordHash: "hash"})
+	helper.ErrLogPanic(err)
+	editor, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "editor", PasswordHash: "hash"})
+	helper.ErrLogPanic(err)
+
+	activity, err := activityService.WithOwner(owner.ID).Create(web.ActivityRequest{Title: "alpha", Email: jabufaker.RandomEmail()})
+	helper.ErrLogPanic(err)
+	todo, err := todoService.WithOwner(owner.ID).Create(web.TodoCreateRequest{ActivityGroupID: activity.ID, Title: "alpha"})
+	helper.ErrLogPanic(err)
+	_, err = membershipService.WithOwner(owner.ID).Invite(activity.ID, web.MemberInviteRequest{Email: editor.Email, Role: domain.RoleEditor})
+	helper.ErrLogPanic(err)
+
+	t.Run("Change by a member is seen by the others", func(t *testing.T) {
+		// Cache the todo for owner
+		_, err := todoService.WithOwner(owner.ID).GetOne(todo.ID)
+		helper.ErrLogPanic(err)
+
+		_, err = todoService.WithOwner(editor.ID).Update(todo.ID, web.TodoUpdateRequest{Title: "bravo"})
+		helper.ErrLogPanic(err)
+
+		found, err := todoService.WithOwner(owner.ID).GetOne(todo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, "bravo", found.Title)
+	})
+
+	t.Run("Removed member cannot read from cache", func(t *testing.T) {
+		_, err := activityService.WithOwner(editor.ID).GetOne(activity.ID)
+		helper.ErrLogPanic(err)
+		_, err = todoService.WithOwner(editor.ID).GetOne(todo.ID)
+		helper.ErrLogPanic(err)
+
+		_, err = membershipService.WithOwner(owner.ID).Remove(activity.ID, editor.ID)
+		helper.ErrLogPanic(err)
+
+		_, err = activityService.WithOwner(editor.ID).GetOne(activity.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		_, err = todoService.WithOwner(editor.ID).GetOne(todo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+	})
+}
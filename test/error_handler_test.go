@@ -0,0 +1,70 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/letenk/todo-list/cache"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/router"
+	"github.com/letenk/todo-list/search"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Duplicate email is conflict", func(t *testing.T) {
+		newActivity := createRandomActivityHandler(t)
+
+		dataBody := fmt.Sprintf(`{"title": "%s", "email": "%s"}`, jabufaker.RandomString(20), newActivity.Email)
+		request := httptest.NewRequest(http.MethodPost, "http://localhost:3030/activity-groups", strings.NewReader(dataBody))
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, http.StatusConflict, response.StatusCode)
+		require.Equal(t, "Conflict", responseBody["status"])
+		require.Equal(t, "Email is already used by other activity group", responseBody["message"])
+		// Activity group of the email can be of other user, so the email is not sent back
+		require.NotContains(t, string(body), newActivity.Email)
+	})
+
+	t.Run("Database down is unavailable, not not found", func(t *testing.T) {
+		db := openSQLite(t)
+		sqlDB, err := db.DB()
+		helper.ErrLogPanic(err)
+		sqlDB.Close()
+
+		route := authenticatedRoute{router.SetupRouter(repository.NewRepositories(db), cache.NewCacheNoop(), Tokens, search.NewIndexMemory(), event.NewBusMemory(event.DefaultKept), nil), TestUser.AccessToken}
+
+		for _, target := range []string{"/activity-groups/1", "/todo-items/1", "/todo-items", "/trash", "/search?q=todo"} {
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:3030"+target, nil)
+			recorder := httptest.NewRecorder()
+			route.ServeHTTP(recorder, request)
+
+			response := recorder.Result()
+			body, _ := io.ReadAll(response.Body)
+			var responseBody map[string]interface{}
+			json.Unmarshal(body, &responseBody)
+
+			require.Equal(t, http.StatusServiceUnavailable, response.StatusCode, target)
+			require.Equal(t, "Service Unavailable", responseBody["status"])
+			require.Equal(t, "Service Unavailable", responseBody["message"])
+		}
+	})
+}
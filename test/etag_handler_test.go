@@ -0,0 +1,105 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+// requestWithHeader send request with header of value, like If-Match
+func requestWithHeader(method string, target string, body string, header string, value string) (*http.Response, map[string]interface{}) {
+	request := httptest.NewRequest(method, "http://localhost:3030"+target, strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	if value != "" {
+		request.Header.Set(header, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	Route.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	responseBody, _ := io.ReadAll(response.Body)
+	var data map[string]interface{}
+	json.Unmarshal(responseBody, &data)
+
+	return response, data
+}
+
+func TestETagHandler(t *testing.T) {
+	t.Parallel()
+
+	body := fmt.Sprintf(`{"title": "etag", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestWithHeader(http.MethodPost, "/activity-groups", body, "", "")
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+	activity := fmt.Sprintf("/activity-groups/%d", activityID)
+
+	body = fmt.Sprintf(`{"title": "todo", "activity_group_id": %d}`, activityID)
+	response, responseBody = requestWithHeader(http.MethodPost, "/todo-items", body, "", "")
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	todo := fmt.Sprintf("/todo-items/%d", uint64(responseBody["data"].(map[string]interface{})["id"].(float64)))
+
+	t.Run("Get with If-None-Match", func(t *testing.T) {
+		response, _ := requestWithHeader(http.MethodGet, todo, "", "", "")
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, `"1"`, response.Header.Get("ETag"))
+
+		response, responseBody := requestWithHeader(http.MethodGet, todo, "", "If-None-Match", `W/"1"`)
+		require.Equal(t, http.StatusNotModified, response.StatusCode)
+		require.Equal(t, `"1"`, response.Header.Get("ETag"))
+		require.Nil(t, responseBody)
+
+		response, _ = requestWithHeader(http.MethodGet, activity, "", "If-None-Match", `"1"`)
+		require.Equal(t, http.StatusNotModified, response.StatusCode)
+
+		response, _ = requestWithHeader(http.MethodGet, activity, "", "If-None-Match", `"7", "8"`)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, `"1"`, response.Header.Get("ETag"))
+	})
+
+	t.Run("Update with If-Match", func(t *testing.T) {
+		response, responseBody := requestWithHeader(http.MethodPatch, todo, `{"title": "stale"}`, "If-Match", `"7"`)
+		require.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+		require.Contains(t, responseBody["message"], "does not match If-Match")
+
+		response, _ = requestWithHeader(http.MethodPatch, todo, `{"title": "first"}`, "If-Match", `"7", "1"`)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, `"2"`, response.Header.Get("ETag"))
+
+		// The first ETag is changed by the update
+		response, _ = requestWithHeader(http.MethodPatch, todo, `{"title": "second"}`, "If-Match", `"1"`)
+		require.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+		response, responseBody = requestWithHeader(http.MethodGet, todo, "", "If-None-Match", `"1"`)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "first", responseBody["data"].(map[string]interface{})["title"])
+
+		// Weak ETag is not matched by If-Match, * match any version
+		response, _ = requestWithHeader(http.MethodPatch, activity, `{"title": "changed"}`, "If-Match", `W/"1"`)
+		require.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+		response, _ = requestWithHeader(http.MethodPatch, activity, `{"title": "changed"}`, "If-Match", "*")
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, `"2"`, response.Header.Get("ETag"))
+	})
+
+	t.Run("Delete with If-Match", func(t *testing.T) {
+		response, _ := requestWithHeader(http.MethodDelete, todo, "", "If-Match", `"1"`)
+		require.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+		response, _ = requestWithHeader(http.MethodDelete, todo, "", "If-Match", `"2"`)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		response, _ = requestWithHeader(http.MethodDelete, activity, "", "If-Match", `"1"`)
+		require.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+		response, _ = requestWithHeader(http.MethodDelete, activity, "", "If-Match", `"2"`)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		response, _ = requestWithHeader(http.MethodGet, activity, "", "", "")
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+}
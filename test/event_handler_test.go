@@ -0,0 +1,166 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is an event read from stream of server-sent events
+type sseEvent struct {
+	ID   string
+	Type string
+	Data map[string]interface{}
+}
+
+// openEvents open stream of events of server, it is closed at the end of the test
+func openEvents(t *testing.T, server *httptest.Server, target string, lastEventID string) *bufio.Reader {
+	return openEventsAs(t, server, target, lastEventID, "")
+}
+
+// openEventsAs open stream of events like openEvents as user of token, empty is TestUser
+func openEventsAs(t *testing.T, server *httptest.Server, target string, lastEventID string, token string) *bufio.Reader {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+target, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := server.Client().Do(request)
+	require.NoError(t, err)
+	t.Cleanup(func() { response.Body.Close() })
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	return bufio.NewReader(response.Body)
+}
+
+// readEvent read the next event of stream, comment is skipped
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var e sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && e.Type != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.Type = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.Data))
+		}
+	}
+}
+
+// readEventOf read the next event of eventType, other event is skipped
+func readEventOf(t *testing.T, reader *bufio.Reader, eventType string) sseEvent {
+	for {
+		e := readEvent(t, reader)
+		if e.Type == eventType {
+			return e
+		}
+	}
+}
+
+func TestEventHandler(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(Route)
+	defer server.Close()
+
+	body := fmt.Sprintf(`{"title": "events", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestWithHeader(http.MethodPost, "/activity-groups", body, "", "")
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+	events := fmt.Sprintf("/events?activity_group_id=%d", activityID)
+
+	var created sseEvent
+
+	t.Run("Stream change of todo", func(t *testing.T) {
+		stream := openEvents(t, server, events, "")
+
+		body := fmt.Sprintf(`{"title": "streamed", "activity_group_id": %d}`, activityID)
+		response, responseBody := requestWithHeader(http.MethodPost, "/todo-items", body, "", "")
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		todoID := responseBody["data"].(map[string]interface{})["id"]
+
+		// Event of the activity group may be relayed after the stream is opened
+		created = readEventOf(t, stream, "todo.created")
+		require.Equal(t, float64(activityID), created.Data["activity_group_id"])
+		require.Equal(t, todoID, created.Data["data"].(map[string]interface{})["id"])
+		require.Equal(t, "streamed", created.Data["data"].(map[string]interface{})["title"])
+
+		response, _ = requestWithHeader(http.MethodPatch, fmt.Sprintf("/todo-items/%.0f", todoID), `{"title": "changed"}`, "", "")
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		updated := readEvent(t, stream)
+		require.Equal(t, "todo.updated", updated.Type)
+		require.Equal(t, "changed", updated.Data["data"].(map[string]interface{})["title"])
+	})
+
+	t.Run("Resume with Last-Event-ID", func(t *testing.T) {
+		stream := openEvents(t, server, events, created.ID)
+		updated := readEvent(t, stream)
+		require.Equal(t, "todo.updated", updated.Type)
+
+		// Event after the latest is missed, client must get the data again
+		stream = openEvents(t, server, events+"&last_event_id=999999999", "")
+		require.Equal(t, "reset", readEvent(t, stream).Type)
+	})
+
+	t.Run("Invited member receive events", func(t *testing.T) {
+		member := createUser(jabufaker.RandomEmail())
+		stream := openEventsAs(t, server, "/events", "", member.AccessToken)
+		ownerStream := openEvents(t, server, events, "")
+
+		// Not visible to the user before it is invited
+		body := fmt.Sprintf(`{"title": "before invite", "activity_group_id": %d}`, activityID)
+		response, _ := requestWithHeader(http.MethodPost, "/todo-items", body, "", "")
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		readEventOf(t, ownerStream, "todo.created")
+
+		body = fmt.Sprintf(`{"email": "%s", "role": "viewer"}`, member.User.Email)
+		response, _ = requestWithHeader(http.MethodPost, fmt.Sprintf("/activity-groups/%d/members", activityID), body, "", "")
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		added := readEvent(t, stream)
+		require.Equal(t, "member.added", added.Type)
+		require.Equal(t, member.User.Email, added.Data["data"].(map[string]interface{})["email"])
+
+		body = fmt.Sprintf(`{"title": "after invite", "activity_group_id": %d}`, activityID)
+		response, _ = requestWithHeader(http.MethodPost, "/todo-items", body, "", "")
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		created := readEvent(t, stream)
+		require.Equal(t, "todo.created", created.Type)
+		require.Equal(t, "after invite", created.Data["data"].(map[string]interface{})["title"])
+	})
+
+	t.Run("Activity group of other user", func(t *testing.T) {
+		other := createUser(jabufaker.RandomEmail())
+		response, _ := requestAuth(http.MethodGet, events, "", other.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+
+		response, _ = requestWithHeader(http.MethodGet, events, "", "Last-Event-ID", "abc")
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/handler"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/service"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyHandler(t *testing.T) {
+	t.Parallel()
+
+	body := fmt.Sprintf(`{"title": "idempotent", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestWithHeader(http.MethodPost, "/activity-groups", body, "", "")
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+
+	t.Run("Retry is replayed", func(t *testing.T) {
+		key := jabufaker.RandomString(20)
+		body := fmt.Sprintf(`{"title": "retried", "activity_group_id": %d}`, activityID)
+		response, first := requestWithHeader(http.MethodPost, "/todo-items", body, "Idempotency-Key", key)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		require.Empty(t, response.Header.Get("Idempotent-Replayed"))
+
+		// Same body with other spaces is the same request
+		body = fmt.Sprintf(`{"title":"retried","activity_group_id":%d}`, activityID)
+		response, replayed := requestWithHeader(http.MethodPost, "/todo-items", body, "Idempotency-Key", key)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		require.Equal(t, "true", response.Header.Get("Idempotent-Replayed"))
+		require.Equal(t, first, replayed)
+
+		response, responseBody := requestWithHeader(http.MethodGet, fmt.Sprintf("/todo-items?activity_group_id=%d", activityID), "", "", "")
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, 1, len(responseBody["data"].([]interface{})))
+	})
+
+	t.Run("Key with other request", func(t *testing.T) {
+		key := jabufaker.RandomString(20)
+		body := fmt.Sprintf(`{"title": "first", "activity_group_id": %d}`, activityID)
+		response, _ := requestWithHeader(http.MethodPost, "/todo-items", body, "Idempotency-Key", key)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		body = fmt.Sprintf(`{"title": "second", "activity_group_id": %d}`, activityID)
+		response, responseBody := requestWithHeader(http.MethodPost, "/todo-items", body, "Idempotency-Key", key)
+		require.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+		require.Equal(t, fmt.Sprintf("Idempotency-Key %s is already used by other request", key), responseBody["message"])
+
+		body = fmt.Sprintf(`{"title": "first", "email": "%s"}`, jabufaker.RandomEmail())
+		response, _ = requestWithHeader(http.MethodPost, "/activity-groups", body, "Idempotency-Key", key)
+		require.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+
+		// Response of other Accept may be of other type
+		body = fmt.Sprintf(`{"title": "first", "activity_group_id": %d}`, activityID)
+		request := httptest.NewRequest(http.MethodPost, "/todo-items", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "application/problem+json")
+		request.Header.Set("Idempotency-Key", key)
+		recorder := httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	})
+
+	t.Run("Failed request is replayed too", func(t *testing.T) {
+		key := jabufaker.RandomString(20)
+		response, _ := requestWithHeader(http.MethodPost, "/todo-items", `{"activity_group_id": 7329323}`, "Idempotency-Key", key)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		response, _ = requestWithHeader(http.MethodPost, "/todo-items", `{"activity_group_id": 7329323}`, "Idempotency-Key", key)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, "true", response.Header.Get("Idempotent-Replayed"))
+	})
+
+	t.Run("Key is too long", func(t *testing.T) {
+		body := fmt.Sprintf(`{"title": "long", "activity_group_id": %d}`, activityID)
+		response, _ := requestWithHeader(http.MethodPost, "/todo-items", body, "Idempotency-Key", jabufaker.RandomString(256))
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+}
+
+func TestIdempotencyServiceExpiredKey(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewMemoryStore()
+	user, err := repository.NewRepositoryUserMemory(store).Save(domain.User{Email: jabufaker.RandomEmail(), Name: "user", PasswordHash: "hash"})
+	helper.ErrLogPanic(err)
+
+	// Every key is expired when it is reserved
+	idempotencyService := service.NewServiceIdempotency(repository.NewRepositoryIdempotencyKeyMemory(store), -time.Second)
+
+	key, replay, err := idempotencyService.Begin(user.ID, "expired", "hash-1")
+	helper.ErrLogPanic(err)
+	require.False(t, replay)
+	helper.ErrLogPanic(idempotencyService.Complete(key, http.StatusCreated, "application/json", []byte(`{}`)))
+
+	// Expired key is used again before expired keys are deleted
+	again, replay, err := idempotencyService.Begin(user.ID, "expired", "hash-2")
+	helper.ErrLogPanic(err)
+	require.False(t, replay)
+	require.NotEqual(t, key.ID, again.ID)
+}
+
+// completeFailedService is IdempotencyService failed to store response
+type completeFailedService struct {
+	service.IdempotencyService
+	released bool
+}
+
+func (s *completeFailedService) Complete(key domain.IdempotencyKey, statusCode int, contentType string, body []byte) error {
+	return apperror.Unavailable(errors.New("database is gone"))
+}
+
+func (s *completeFailedService) Release(key domain.IdempotencyKey) error {
+	s.released = true
+	return s.IdempotencyService.Release(key)
+}
+
+func TestIdempotencyHandlerCompleteFailed(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewMemoryStore()
+	user, err := repository.NewRepositoryUserMemory(store).Save(domain.User{Email: jabufaker.RandomEmail(), Name: "user", PasswordHash: "hash"})
+	helper.ErrLogPanic(err)
+	idempotencyService := &completeFailedService{IdempotencyService: service.NewServiceIdempotency(repository.NewRepositoryIdempotencyKeyMemory(store), time.Hour)}
+	created := 0
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+	})
+	router.POST("/created", handler.NewIdempotencyHandler(idempotencyService).Idempotent, func(c *gin.Context) {
+		created++
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	post := func() int {
+		request := httptest.NewRequest(http.MethodPost, "/created", strings.NewReader(`{}`))
+		request.Header.Set("Idempotency-Key", "complete-failed")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusCreated, post())
+	require.False(t, idempotencyService.released)
+
+	// Retry is not run again while the key is in progress
+	require.Equal(t, http.StatusConflict, post())
+	require.Equal(t, 1, created)
+}
+
+func TestIdempotencyHandlerBodyTooLarge(t *testing.T) {
+	t.Parallel()
+
+	body := fmt.Sprintf(`{"title": "%s", "activity_group_id": 1}`, strings.Repeat("a", 1<<20))
+	response, _ := requestWithHeader(http.MethodPost, "/todo-items", body, "Idempotency-Key", jabufaker.RandomString(20))
+	require.Equal(t, http.StatusRequestEntityTooLarge, response.StatusCode)
+}
@@ -1,17 +1,68 @@
 package test
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
-	"github.com/gin-gonic/gin"
+	"github.com/letenk/todo-list/cache"
 	"github.com/letenk/todo-list/config"
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/migration"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
 	"github.com/letenk/todo-list/router"
+	"github.com/letenk/todo-list/search"
+	"github.com/letenk/todo-list/service"
 	"gorm.io/gorm"
 )
 
+// ConnTest is database of repository and migration tests
 var ConnTest *gorm.DB
-var Route *gin.Engine
+var Route http.Handler
+
+// Repositories are in-memory repositories of Route and service tests
+var Repositories repository.Repositories
+
+// Tokens sign token of test users, TestUser is owner of data created through Route
+var Tokens service.TokenService
+var TestUser web.TokenResponse
+
+// Bus receive events of changes through Route
+var Bus event.Bus
+
+// WebsocketOrigin is the only origin allowed to open websocket of Route
+const WebsocketOrigin = "https://app.example.com"
+
+// authenticatedRoute send request as user of token, unless header Authorization is set
+type authenticatedRoute struct {
+	http.Handler
+	token string
+}
+
+func (r authenticatedRoute) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, ok := req.Header["Authorization"]; !ok {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	r.Handler.ServeHTTP(w, req)
+}
+
+// createUser register random user and return it with its token
+func createUser(email string) web.TokenResponse {
+	auth := service.NewServiceAuth(Repositories.User, Tokens)
+	user, token, expiresAt, err := auth.Register(web.RegisterRequest{
+		Email:    email,
+		Name:     "Test User",
+		Password: "password123",
+	})
+	helper.ErrLogPanic(err)
+
+	return web.FormatToken(user, token, expiresAt)
+}
 
 func DropTable() {
 	// Drop table after test
@@ -19,19 +70,47 @@ func DropTable() {
 }
 
 func TestMain(m *testing.M) {
-	// Set env
-	os.Setenv("MYSQL_USER", "root")
-	os.Setenv("MYSQL_PASSWORD", "root")
-	os.Setenv("MYSQL_HOST", "127.0.0.1")
-	os.Setenv("MYSQL_PORT", "3306")
-	os.Setenv("MYSQL_DBNAME", "todo4")
+	// Set env, test run with a new sqlite file unless DB_DRIVER is set
+	switch os.Getenv("DB_DRIVER") {
+	case "":
+		dir, err := os.MkdirTemp("", "todo-list-test")
+		helper.ErrLogPanic(err)
+		defer os.RemoveAll(dir)
+
+		os.Setenv("DB_DRIVER", "sqlite")
+		os.Setenv("SQLITE_PATH", filepath.Join(dir, "test.db"))
+	case "mysql":
+		os.Setenv("MYSQL_USER", "root")
+		os.Setenv("MYSQL_PASSWORD", "root")
+		os.Setenv("MYSQL_HOST", "127.0.0.1")
+		os.Setenv("MYSQL_PORT", "3306")
+		os.Setenv("MYSQL_DBNAME", "todo4")
+	}
 
 	// Open connection
 	db := config.SetupDB()
 	ConnTest = db
 
-	// Setup router
-	Route = router.SetupRouter(db)
+	// Migrate schema
+	migrator, err := migration.NewMigrator(db)
+	helper.ErrLogPanic(err)
+	_, err = migrator.Up()
+	helper.ErrLogPanic(err)
+
+	// Setup router on memory, data is not cached so every test see the store
+	Repositories = repository.NewRepositoriesMemory(repository.NewMemoryStore())
+	Tokens = service.NewServiceToken([]byte("secret"), time.Hour)
+	TestUser = createUser("test-user@example.com")
+	Bus = event.NewBusMemory(event.DefaultKept)
+	Route = authenticatedRoute{router.SetupRouter(Repositories, cache.NewCacheNoop(), Tokens, search.NewIndexMemory(), Bus, []string{WebsocketOrigin}), TestUser.AccessToken}
+
+	// Relay events of outbox like main, webhook is retried quickly and can post to server of test on loopback
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dispatcher := service.NewServiceWebhookDispatcher(Repositories.Webhook, Repositories.WebhookDelivery,
+		Repositories.Activity, 10*time.Millisecond, 2).WithClient(service.NewWebhookClient(true))
+	go dispatcher.Run(ctx)
+	go service.NewServiceOutboxRelay(Repositories.Outbox, service.OutboxBackoff, service.OutboxMaxAttempts, service.NewBusSink(Bus), dispatcher).Run(ctx)
 
 	m.Run()
 }
@@ -0,0 +1,155 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/letenk/todo-list/models/web"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipHandler(t *testing.T) {
+	t.Parallel()
+	owner := createUser(jabufaker.RandomEmail())
+	editor := createUser(jabufaker.RandomEmail())
+	viewer := createUser(jabufaker.RandomEmail())
+
+	body := fmt.Sprintf(`{"title": "shared", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, owner.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+	members := fmt.Sprintf("/activity-groups/%d/members", activityID)
+
+	t.Run("Invite and list members", func(t *testing.T) {
+		for user, role := range map[string]string{editor.User.Email: "editor", viewer.User.Email: "viewer"} {
+			body := fmt.Sprintf(`{"email": "%s", "role": "%s"}`, user, role)
+			response, responseBody := requestAuth(http.MethodPost, members, body, owner.AccessToken)
+			require.Equal(t, http.StatusCreated, response.StatusCode)
+			require.Equal(t, role, responseBody["data"].(map[string]interface{})["role"])
+		}
+
+		body := fmt.Sprintf(`{"email": "%s", "role": "viewer"}`, viewer.User.Email)
+		response, _ := requestAuth(http.MethodPost, members, body, owner.AccessToken)
+		require.Equal(t, http.StatusConflict, response.StatusCode)
+
+		body = fmt.Sprintf(`{"email": "%s", "role": "viewer"}`, jabufaker.RandomEmail())
+		response, _ = requestAuth(http.MethodPost, members, body, owner.AccessToken)
+		require.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+
+		body = fmt.Sprintf(`{"email": "%s", "role": "admin"}`, jabufaker.RandomEmail())
+		response, _ = requestAuth(http.MethodPost, members, body, owner.AccessToken)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		response, responseBody := requestAuth(http.MethodGet, members, "", viewer.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		list := responseBody["data"].([]interface{})
+		require.Equal(t, 3, len(list))
+		require.Equal(t, "owner", list[0].(map[string]interface{})["role"])
+
+		// Not member cannot see the members
+		response, _ = requestAuth(http.MethodGet, members, "", TestUser.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("Roles are enforced", func(t *testing.T) {
+		// Editor can change todos
+		body := fmt.Sprintf(`{"title": "todo", "activity_group_id": %d}`, activityID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items", body, editor.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		todo := fmt.Sprintf("/todo-items/%d", uint64(responseBody["data"].(map[string]interface{})["id"].(float64)))
+
+		response, _ = requestAuth(http.MethodPatch, todo, `{"title": "changed"}`, editor.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		// Viewer can only read
+		response, responseBody = requestAuth(http.MethodGet, todo, "", viewer.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "changed", responseBody["data"].(map[string]interface{})["title"])
+
+		response, responseBody = requestAuth(http.MethodPatch, todo, `{"title": "viewer"}`, viewer.AccessToken)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+		require.Equal(t, "Role editor of activity group with ID "+fmt.Sprint(activityID)+" is required", responseBody["message"])
+		response, _ = requestAuth(http.MethodDelete, todo, "", viewer.AccessToken)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+		// Only owner can delete activity group or manage members
+		target := fmt.Sprintf("/activity-groups/%d", activityID)
+		response, _ = requestAuth(http.MethodDelete, target, "", editor.AccessToken)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+		body = fmt.Sprintf(`{"email": "%s", "role": "viewer"}`, TestUser.User.Email)
+		response, _ = requestAuth(http.MethodPost, members, body, editor.AccessToken)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+		response, _ = requestAuth(http.MethodDelete, fmt.Sprintf("%s/%d", members, viewer.User.ID), "", editor.AccessToken)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("Remove members", func(t *testing.T) {
+		// The last owner cannot leave
+		response, responseBody := requestAuth(http.MethodDelete, fmt.Sprintf("%s/%d", members, owner.User.ID), "", owner.AccessToken)
+		require.Equal(t, http.StatusConflict, response.StatusCode)
+		require.Equal(t, fmt.Sprintf("Activity with ID %d must have an owner", activityID), responseBody["message"])
+
+		// Member can leave, owner can remove the others
+		response, _ = requestAuth(http.MethodDelete, fmt.Sprintf("%s/%d", members, viewer.User.ID), "", viewer.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		response, _ = requestAuth(http.MethodDelete, fmt.Sprintf("%s/%d", members, editor.User.ID), "", owner.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		response, _ = requestAuth(http.MethodGet, fmt.Sprintf("/activity-groups/%d", activityID), "", editor.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+
+		response, _ = requestAuth(http.MethodDelete, fmt.Sprintf("/activity-groups/%d", activityID), "", owner.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+	})
+}
+
+func TestMembershipHandlerOwnersLeaveTogether(t *testing.T) {
+	t.Parallel()
+	first := createUser(jabufaker.RandomEmail())
+	second := createUser(jabufaker.RandomEmail())
+
+	body := fmt.Sprintf(`{"title": "owners", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, first.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+	members := fmt.Sprintf("/activity-groups/%d/members", activityID)
+
+	body = fmt.Sprintf(`{"email": "%s", "role": "owner"}`, second.User.Email)
+	response, _ = requestAuth(http.MethodPost, members, body, first.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+
+	// Both owners leave at the same time, only one of them can
+	statuses := make(chan int, 2)
+	var wg sync.WaitGroup
+	for _, owner := range []web.TokenResponse{first, second} {
+		wg.Add(1)
+		go func(owner web.TokenResponse) {
+			defer wg.Done()
+			response, _ := requestAuth(http.MethodDelete, fmt.Sprintf("%s/%d", members, owner.User.ID), "", owner.AccessToken)
+			statuses <- response.StatusCode
+		}(owner)
+	}
+	wg.Wait()
+	close(statuses)
+
+	left := 0
+	for status := range statuses {
+		if status == http.StatusOK {
+			left++
+		}
+	}
+	require.Equal(t, 1, left)
+
+	remained := first
+	response, _ = requestAuth(http.MethodGet, members, "", remained.AccessToken)
+	if response.StatusCode == http.StatusNotFound {
+		remained = second
+		response, _ = requestAuth(http.MethodGet, members, "", remained.AccessToken)
+	}
+	require.Equal(t, http.StatusOK, response.StatusCode)
+}
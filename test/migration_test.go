@@ -0,0 +1,137 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/migration"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigration(t *testing.T) {
+	t.Parallel()
+
+	migrations, err := migration.Load(ConnTest.Dialector.Name())
+	helper.ErrLogPanic(err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		require.NotEmpty(t, m.Name)
+		require.NotEmpty(t, m.Up)
+		require.NotEmpty(t, m.Down)
+		if i > 0 {
+			require.Greater(t, m.Version, migrations[i-1].Version)
+		}
+	}
+
+	_, err = migration.Load("unknown")
+	require.Error(t, err)
+}
+
+func TestStatusMigration(t *testing.T) {
+	t.Parallel()
+
+	migrator, err := migration.NewMigrator(ConnTest)
+	helper.ErrLogPanic(err)
+
+	// All migrations is applied in TestMain
+	status, err := migrator.Status()
+	helper.ErrLogPanic(err)
+	for _, s := range status {
+		require.NotNil(t, s.AppliedAt)
+	}
+
+	pending, err := migrator.Pending()
+	helper.ErrLogPanic(err)
+	require.Empty(t, pending)
+
+	// Up again apply nothing
+	applied, err := migrator.Up()
+	helper.ErrLogPanic(err)
+	require.Empty(t, applied)
+}
+
+func TestUpDownMigration(t *testing.T) {
+	t.Parallel()
+
+	// Use other database, roll back drop the tables used by the other tests
+	db := openSQLite(t)
+
+	migrator, err := migration.NewMigrator(db)
+	helper.ErrLogPanic(err)
+
+	migrations, err := migration.Load("sqlite")
+	helper.ErrLogPanic(err)
+
+	applied, err := migrator.Up()
+	helper.ErrLogPanic(err)
+	require.Equal(t, len(migrations), len(applied))
+	require.True(t, db.Migrator().HasTable("todos"))
+
+	// Roll back all in reverse order
+	for i := len(migrations) - 1; i >= 0; i-- {
+		rolledBack, err := migrator.Down()
+		helper.ErrLogPanic(err)
+		require.Equal(t, migrations[i].Version, rolledBack.Version)
+	}
+	require.False(t, db.Migrator().HasTable("todos"))
+	require.False(t, db.Migrator().HasTable("activities"))
+
+	_, err = migrator.Down()
+	require.ErrorIs(t, err, migration.ErrNoMigration)
+
+	pending, err := migrator.Pending()
+	helper.ErrLogPanic(err)
+	require.Equal(t, len(migrations), len(pending))
+
+	// Up again
+	applied, err = migrator.Up()
+	helper.ErrLogPanic(err)
+	require.Equal(t, len(migrations), len(applied))
+}
+
+func TestResumeMigration(t *testing.T) {
+	t.Parallel()
+
+	db := openSQLite(t)
+	migrator, err := migration.NewMigrator(db)
+	helper.ErrLogPanic(err)
+
+	migrations, err := migration.Load("sqlite")
+	helper.ErrLogPanic(err)
+	_, err = migrator.Up()
+	helper.ErrLogPanic(err)
+
+	// The latest migration of more than one statement, like MySQL failed on its second one
+	i := len(migrations) - 1
+	for len(strings.Split(strings.TrimSpace(migrations[i].Up), ";\n")) < 2 {
+		i--
+	}
+	for j := len(migrations) - 1; j >= i; j-- {
+		_, err = migrator.Down()
+		helper.ErrLogPanic(err)
+	}
+	failed := migrations[i]
+	helper.ErrLogPanic(db.Exec(strings.Split(failed.Up, ";\n")[0]).Error)
+	helper.ErrLogPanic(db.Exec("INSERT INTO schema_migrations (version, name, applied_at, statements) VALUES (?, ?, ?, ?)", failed.Version, failed.Name, time.Now(), 1).Error)
+
+	status, err := migrator.Status()
+	helper.ErrLogPanic(err)
+	require.Nil(t, status[i].AppliedAt)
+	require.Equal(t, 1, status[i].Statements)
+
+	_, err = migrator.Down()
+	require.Error(t, err)
+
+	// The first statement is not run again
+	applied, err := migrator.Up()
+	helper.ErrLogPanic(err)
+	require.Equal(t, len(migrations)-i, len(applied))
+	require.Equal(t, failed.Version, applied[0].Version)
+
+	pending, err := migrator.Pending()
+	helper.ErrLogPanic(err)
+	require.Empty(t, pending)
+}
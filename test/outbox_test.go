@@ -0,0 +1,203 @@
+package test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/service"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedSink record events it received, event of ID in failures fail that many sends
+type recordedSink struct {
+	events   []event.Event
+	failures map[uint64]int
+}
+
+func (s *recordedSink) Send(e event.Event) error {
+	if s.failures[e.ID] > 0 {
+		s.failures[e.ID]--
+		return errors.New("sink is not available")
+	}
+	s.events = append(s.events, e)
+	return nil
+}
+
+func TestOutboxRelay(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewMemoryStore()
+	activityRepository := repository.NewRepositoryActivityMemory(store)
+	outboxRepository := repository.NewRepositoryOutboxMemory(store)
+	todoService := service.NewServiceTodo(repository.NewRepositoryTodoMemory(store), activityRepository, repository.NewTransactorMemory(store))
+
+	activity, err := activityRepository.Save(domain.Activity{Title: "outbox", Email: "outbox@example.com"})
+	helper.ErrLogPanic(err)
+
+	first, err := todoService.Create(web.TodoCreateRequest{ActivityGroupID: activity.ID, Title: "first"})
+	helper.ErrLogPanic(err)
+	_, err = todoService.Update(first.ID, web.TodoUpdateRequest{Title: "first edited"})
+	helper.ErrLogPanic(err)
+	second, err := todoService.Create(web.TodoCreateRequest{ActivityGroupID: activity.ID, Title: "second"})
+	helper.ErrLogPanic(err)
+
+	// Event of rolled back bulk is not written
+	_, err = todoService.Bulk(web.TodoBulkRequest{Atomic: true, Actions: []web.TodoBulkAction{
+		{Action: domain.BulkActionDelete, ID: second.ID},
+		{Action: domain.BulkActionDelete, ID: second.ID + 1000},
+	}})
+	require.Error(t, err)
+
+	now := time.Now()
+	due, err := outboxRepository.FindDue(now, 10)
+	helper.ErrLogPanic(err)
+	require.Len(t, due, 2)
+
+	t.Run("Failed event hold back later events of its todo", func(t *testing.T) {
+		sink := &recordedSink{failures: map[uint64]int{due[0].ID: 1}}
+		relay := service.NewServiceOutboxRelay(outboxRepository, time.Minute, 2, sink)
+
+		published, err := relay.Relay(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, published)
+		require.Len(t, sink.events, 1)
+		require.Equal(t, second.ID, sink.events[0].Data.(domain.Todo).ID)
+
+		// Failed event is retried after backoff
+		published, err = relay.Relay(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, published)
+
+		// Events of the todo are sent in order
+		now = now.Add(time.Minute)
+		for _, eventType := range []string{event.TodoCreated, event.TodoUpdated} {
+			published, err = relay.Relay(now)
+			helper.ErrLogPanic(err)
+			require.Equal(t, 1, published)
+			require.Equal(t, eventType, sink.events[len(sink.events)-1].Type)
+		}
+		require.Equal(t, "first edited", sink.events[2].Data.(domain.Todo).Title)
+		require.Equal(t, activity.ID, sink.events[2].ActivityGroupID)
+
+		published, err = relay.Relay(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, published)
+	})
+
+	t.Run("Event is dead after max attempts", func(t *testing.T) {
+		_, err := todoService.Update(second.ID, web.TodoUpdateRequest{Title: "poison"})
+		helper.ErrLogPanic(err)
+		_, err = todoService.Update(second.ID, web.TodoUpdateRequest{Title: "after poison"})
+		helper.ErrLogPanic(err)
+
+		due, err := outboxRepository.FindDue(now, 10)
+		helper.ErrLogPanic(err)
+		require.Len(t, due, 1)
+
+		sink := &recordedSink{failures: map[uint64]int{due[0].ID: 2}}
+		relay := service.NewServiceOutboxRelay(outboxRepository, time.Minute, 2, sink)
+		_, err = relay.Relay(now)
+		helper.ErrLogPanic(err)
+		now = now.Add(time.Minute)
+		published, err := relay.Relay(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, published)
+
+		// The next event of the todo is not held back by the dead one
+		published, err = relay.Relay(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, published)
+		require.Equal(t, "after poison", sink.events[0].Data.(domain.Todo).Title)
+	})
+
+	t.Run("Sink received event is not sent it again", func(t *testing.T) {
+		_, err := todoService.Update(second.ID, web.TodoUpdateRequest{Title: "once"})
+		helper.ErrLogPanic(err)
+
+		due, err := outboxRepository.FindDue(now, 10)
+		helper.ErrLogPanic(err)
+		require.Len(t, due, 1)
+
+		received := &recordedSink{}
+		failing := &recordedSink{failures: map[uint64]int{due[0].ID: 1}}
+		relay := service.NewServiceOutboxRelay(outboxRepository, time.Minute, 2, received, failing)
+		published, err := relay.Relay(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, published)
+
+		now = now.Add(time.Minute)
+		published, err = relay.Relay(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, published)
+		require.Len(t, received.events, 1)
+		require.Len(t, failing.events, 1)
+	})
+}
+
+func TestOutboxActivityGroupTodos(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewMemoryStore()
+	activityRepository := repository.NewRepositoryActivityMemory(store)
+	outboxRepository := repository.NewRepositoryOutboxMemory(store)
+	transactor := repository.NewTransactorMemory(store)
+	todoService := service.NewServiceTodo(repository.NewRepositoryTodoMemory(store), activityRepository, transactor)
+	activityService := service.NewServiceActivity(activityRepository, transactor)
+
+	from, err := activityService.Create(web.ActivityRequest{Title: "from", Email: "from@example.com"})
+	helper.ErrLogPanic(err)
+	to, err := activityService.Create(web.ActivityRequest{Title: "to", Email: "to@example.com"})
+	helper.ErrLogPanic(err)
+	for _, title := range []string{"first", "second"} {
+		_, err = todoService.Create(web.TodoCreateRequest{ActivityGroupID: from.ID, Title: title})
+		helper.ErrLogPanic(err)
+	}
+
+	sink := &recordedSink{}
+	relay := service.NewServiceOutboxRelay(outboxRepository, time.Minute, 2, sink)
+	// relayed return types of events of todos relayed since the last call
+	relayed := func() []string {
+		before := len(sink.events)
+		for {
+			published, err := relay.Relay(time.Now())
+			helper.ErrLogPanic(err)
+			if published == 0 {
+				break
+			}
+		}
+
+		types := []string{}
+		for _, e := range sink.events[before:] {
+			if todo, ok := e.Data.(domain.Todo); ok {
+				types = append(types, e.Type)
+				require.Equal(t, todo.ActivityGroupID, e.ActivityGroupID)
+			}
+		}
+		return types
+	}
+	relayed()
+
+	_, err = activityService.DeleteWithPolicy(from.ID, web.ActivityDeleteQuery{MoveTo: to.ID})
+	helper.ErrLogPanic(err)
+	moved := len(sink.events)
+	require.Equal(t, []string{event.TodoUpdated, event.TodoUpdated}, relayed())
+	for _, e := range sink.events[moved:] {
+		if e.Type == event.TodoUpdated {
+			require.Equal(t, to.ID, e.ActivityGroupID)
+		}
+	}
+
+	_, err = activityService.DeleteWithPolicy(to.ID, web.ActivityDeleteQuery{Policy: web.DeletePolicyCascade})
+	helper.ErrLogPanic(err)
+	require.Equal(t, []string{event.TodoDeleted, event.TodoDeleted}, relayed())
+
+	_, err = activityService.Restore(to.ID)
+	helper.ErrLogPanic(err)
+	require.Equal(t, []string{event.TodoCreated, event.TodoCreated}, relayed())
+}
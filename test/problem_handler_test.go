@@ -0,0 +1,113 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/letenk/todo-list/models/web"
+	"github.com/stretchr/testify/require"
+)
+
+// requestProblem send request accept problem+json, and decode the problem of response
+func requestProblem(t *testing.T, method string, target string, body string) (*http.Response, web.Problem) {
+	request := httptest.NewRequest(method, "http://localhost:3030"+target, strings.NewReader(body))
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add("Accept", web.ProblemContentType)
+
+	recorder := httptest.NewRecorder()
+	Route.ServeHTTP(recorder, request)
+
+	response := recorder.Result()
+	responseBody, _ := io.ReadAll(response.Body)
+	var problem web.Problem
+	json.Unmarshal(responseBody, &problem)
+
+	return response, problem
+}
+
+func problemFields(problem web.Problem) map[string]string {
+	fields := map[string]string{}
+	for _, fieldError := range problem.Errors {
+		fields[fieldError.Field] = fieldError.Code
+	}
+	return fields
+}
+
+func TestProblemHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Validation of binding has error of each field", func(t *testing.T) {
+		response, problem := requestProblem(t, http.MethodPost, "/todo-items", `{}`)
+
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, web.ProblemContentType, response.Header.Get("Content-Type"))
+		require.Equal(t, "/problems/bad_request", problem.Type)
+		require.Equal(t, "bad_request", problem.Code)
+		require.Equal(t, "Bad Request", problem.Title)
+		require.Equal(t, http.StatusBadRequest, problem.Status)
+		require.Equal(t, "title cannot be null", problem.Detail)
+		require.Equal(t, "/todo-items", problem.Instance)
+		require.Equal(t, map[string]string{"title": web.FieldRequired, "activity_group_id": web.FieldRequired}, problemFields(problem))
+	})
+
+	t.Run("Invalid type of json field", func(t *testing.T) {
+		response, problem := requestProblem(t, http.MethodPost, "/activity-groups", `{"title": 1, "email": "a@b.c"}`)
+
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, map[string]string{"title": web.FieldInvalidType}, problemFields(problem))
+	})
+
+	t.Run("Invalid query string and uri", func(t *testing.T) {
+		response, problem := requestProblem(t, http.MethodGet, "/todo-items?activity_group_id=abc&limit=x&sort=title", "")
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, map[string]string{"activity_group_id": web.FieldInvalid, "limit": web.FieldInvalid}, problemFields(problem))
+
+		response, problem = requestProblem(t, http.MethodGet, "/activity-groups/abc", "")
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, map[string]string{"id": web.FieldInvalid}, problemFields(problem))
+
+		response, problem = requestProblem(t, http.MethodGet, "/todo-items?sort=email", "")
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, map[string]string{"sort": web.FieldOneOf}, problemFields(problem))
+	})
+
+	t.Run("Error of service", func(t *testing.T) {
+		response, problem := requestProblem(t, http.MethodGet, "/todo-items/99999999", "")
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+		require.Equal(t, "not_found", problem.Code)
+		require.Equal(t, "Todo with ID 99999999 Not Found", problem.Detail)
+
+		response, problem = requestProblem(t, http.MethodPost, "/todo-items", `{"title": "a", "activity_group_id": 99999999}`)
+		require.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+		require.Equal(t, "activity_group_not_found", problem.Code)
+		require.Equal(t, "/problems/activity_group_not_found", problem.Type)
+		require.Equal(t, map[string]string{"activity_group_id": "activity_group_not_found"}, problemFields(problem))
+	})
+
+	t.Run("Envelope is responded unless problem is preferred", func(t *testing.T) {
+		for _, accept := range []string{"", "*/*", "application/json", "application/json, application/problem+json"} {
+			request := httptest.NewRequest(http.MethodGet, "http://localhost:3030/todo-items/99999999", nil)
+			if accept != "" {
+				request.Header.Add("Accept", accept)
+			}
+
+			recorder := httptest.NewRecorder()
+			Route.ServeHTTP(recorder, request)
+
+			response := recorder.Result()
+			body, _ := io.ReadAll(response.Body)
+			var responseBody map[string]interface{}
+			json.Unmarshal(body, &responseBody)
+
+			require.Equal(t, http.StatusNotFound, response.StatusCode, accept)
+			require.True(t, strings.HasPrefix(response.Header.Get("Content-Type"), "application/json"), accept)
+			require.Equal(t, "Not Found", responseBody["status"])
+			require.Equal(t, fmt.Sprintf("Todo with ID %d Not Found", 99999999), responseBody["message"])
+		}
+	})
+}
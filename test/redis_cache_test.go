@@ -0,0 +1,159 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/letenk/todo-list/cache"
+	"github.com/letenk/todo-list/helper"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// newRedisCache create redis cache connected to server, like one instance of this app
+func newRedisCache(t *testing.T, server *miniredis.Miniredis) cache.Cache {
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	redisCache, err := cache.NewCacheRedis(client)
+	helper.ErrLogPanic(err)
+
+	t.Cleanup(func() {
+		redisCache.Close()
+		client.Close()
+	})
+
+	return redisCache
+}
+
+func TestRedisCache(t *testing.T) {
+	t.Parallel()
+	server := miniredis.RunT(t)
+	redisCache := newRedisCache(t, server)
+
+	t.Run("Get not found", func(t *testing.T) {
+		_, err := redisCache.Get("not-found")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+	})
+
+	t.Run("Set and get", func(t *testing.T) {
+		err := redisCache.Set("key", []byte("value"), time.Hour)
+		helper.ErrLogPanic(err)
+
+		value, err := redisCache.Get("key")
+		helper.ErrLogPanic(err)
+		require.Equal(t, "value", string(value))
+
+		// Stored in redis with ttl
+		stored, err := server.Get("key")
+		helper.ErrLogPanic(err)
+		require.Equal(t, "value", stored)
+		require.Equal(t, time.Hour, server.TTL("key"))
+	})
+
+	t.Run("Delete and delete prefix", func(t *testing.T) {
+		for _, key := range []string{"todos:1", "todos:2", "todo:1"} {
+			err := redisCache.Set(key, []byte("value"), time.Hour)
+			helper.ErrLogPanic(err)
+		}
+
+		err := redisCache.Delete("todo:1", "not-found")
+		helper.ErrLogPanic(err)
+		_, err = redisCache.Get("todo:1")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+
+		err = redisCache.DeletePrefix("todos:")
+		helper.ErrLogPanic(err)
+		_, err = redisCache.Get("todos:1")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+		_, err = redisCache.Get("todos:2")
+		require.ErrorIs(t, err, cache.ErrNotFound)
+		require.False(t, server.Exists("todos:1"))
+		require.False(t, server.Exists("todos:2"))
+		// Keys are deleted from set of the prefix, not found by scan
+		require.False(t, server.Exists("todo-list:cache:prefix:todos:"))
+	})
+
+	t.Run("Delete prefix without separator", func(t *testing.T) {
+		for _, key := range []string{"activity:1", "activities"} {
+			err := redisCache.Set(key, []byte("value"), time.Hour)
+			helper.ErrLogPanic(err)
+		}
+
+		err := redisCache.DeletePrefix("activit")
+		helper.ErrLogPanic(err)
+		require.False(t, server.Exists("activity:1"))
+		require.False(t, server.Exists("activities"))
+	})
+
+	t.Run("Redis is down", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		redisCache := newRedisCache(t, server)
+		server.Close()
+
+		err := redisCache.Set("key", []byte("value"), time.Hour)
+		require.Error(t, err)
+	})
+}
+
+func TestRedisCacheInvalidation(t *testing.T) {
+	t.Parallel()
+	server := miniredis.RunT(t)
+	// Two instances of this app share one redis
+	first := newRedisCache(t, server)
+	second := newRedisCache(t, server)
+
+	t.Run("Get value set by other instance", func(t *testing.T) {
+		err := first.Set("key", []byte("value"), time.Hour)
+		helper.ErrLogPanic(err)
+
+		value, err := second.Get("key")
+		helper.ErrLogPanic(err)
+		require.Equal(t, "value", string(value))
+	})
+
+	t.Run("Delete in other instance", func(t *testing.T) {
+		err := first.Set("todo:1", []byte("value"), time.Hour)
+		helper.ErrLogPanic(err)
+		// Keep in memory of second instance
+		_, err = second.Get("todo:1")
+		helper.ErrLogPanic(err)
+
+		err = first.Delete("todo:1")
+		helper.ErrLogPanic(err)
+
+		require.Eventually(t, func() bool {
+			_, err := second.Get("todo:1")
+			return err == cache.ErrNotFound
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Delete prefix in other instance", func(t *testing.T) {
+		err := first.Set("todos:1", []byte("value"), time.Hour)
+		helper.ErrLogPanic(err)
+		_, err = second.Get("todos:1")
+		helper.ErrLogPanic(err)
+
+		err = first.DeletePrefix("todos:")
+		helper.ErrLogPanic(err)
+
+		require.Eventually(t, func() bool {
+			_, err := second.Get("todos:1")
+			return err == cache.ErrNotFound
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Set in other instance", func(t *testing.T) {
+		err := first.Set("todo:2", []byte("old"), time.Hour)
+		helper.ErrLogPanic(err)
+		_, err = second.Get("todo:2")
+		helper.ErrLogPanic(err)
+
+		err = first.Set("todo:2", []byte("new"), time.Hour)
+		helper.ErrLogPanic(err)
+
+		require.Eventually(t, func() bool {
+			value, err := second.Get("todo:2")
+			return err == nil && string(value) == "new"
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,1092 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/letenk/todo-list/apperror"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/migration"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/models/web"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/service"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// repositories share one empty database, created for each contract test
+type repositories struct {
+	user           repository.UserRepository
+	apiKey         repository.APIKeyRepository
+	activity       repository.ActivityRepository
+	membership     repository.MembershipRepository
+	todo           repository.TodoRepository
+	transactor     repository.Transactor
+	idempotencyKey repository.IdempotencyKeyRepository
+	webhook        repository.WebhookRepository
+	delivery       repository.WebhookDeliveryRepository
+	outbox         repository.OutboxRepository
+}
+
+// openSQLite open a new sqlite file without any table
+func openSQLite(t *testing.T) *gorm.DB {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(sqlite.Open(path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), &gorm.Config{})
+	helper.ErrLogPanic(err)
+
+	sqlDB, err := db.DB()
+	helper.ErrLogPanic(err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func newGormRepositories(t *testing.T) repositories {
+	db := openSQLite(t)
+	migrator, err := migration.NewMigrator(db)
+	helper.ErrLogPanic(err)
+	_, err = migrator.Up()
+	helper.ErrLogPanic(err)
+
+	return repositories{
+		user:           repository.NewRepositoryUser(db),
+		apiKey:         repository.NewRepositoryAPIKey(db),
+		activity:       repository.NewRepositoryActivity(db),
+		membership:     repository.NewRepositoryMembership(db),
+		todo:           repository.NewRepositoryTodo(db),
+		transactor:     repository.NewTransactor(db),
+		idempotencyKey: repository.NewRepositoryIdempotencyKey(db),
+		webhook:        repository.NewRepositoryWebhook(db),
+		delivery:       repository.NewRepositoryWebhookDelivery(db),
+		outbox:         repository.NewRepositoryOutbox(db),
+	}
+}
+
+func newMemoryRepositories(t *testing.T) repositories {
+	store := repository.NewMemoryStore()
+	return repositories{
+		user:           repository.NewRepositoryUserMemory(store),
+		apiKey:         repository.NewRepositoryAPIKeyMemory(store),
+		activity:       repository.NewRepositoryActivityMemory(store),
+		membership:     repository.NewRepositoryMembershipMemory(store),
+		todo:           repository.NewRepositoryTodoMemory(store),
+		transactor:     repository.NewTransactorMemory(store),
+		idempotencyKey: repository.NewRepositoryIdempotencyKeyMemory(store),
+		webhook:        repository.NewRepositoryWebhookMemory(store),
+		delivery:       repository.NewRepositoryWebhookDeliveryMemory(store),
+		outbox:         repository.NewRepositoryOutboxMemory(store),
+	}
+}
+
+func saveActivityContract(t *testing.T, r repositories, title string) domain.Activity {
+	activity, err := r.activity.Save(domain.Activity{Title: title, Email: jabufaker.RandomEmail()})
+	helper.ErrLogPanic(err)
+	return activity
+}
+
+func saveTodoContract(t *testing.T, r repositories, todo domain.Todo) domain.Todo {
+	if todo.Title == "" {
+		todo.Title = jabufaker.RandomString(20)
+	}
+	todo, err := r.todo.Save(todo)
+	helper.ErrLogPanic(err)
+	return todo
+}
+
+func parseFilterContract(expression string) repository.FilterExpression {
+	filter, err := repository.ParseTodoFilter(expression)
+	helper.ErrLogPanic(err)
+	return filter
+}
+
+func todoIDs(todos []domain.Todo) []uint64 {
+	ids := []uint64{}
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+func activityIDs(activities []domain.Activity) []uint64 {
+	ids := []uint64{}
+	for _, activity := range activities {
+		ids = append(ids, activity.ID)
+	}
+	return ids
+}
+
+// TestRepositoryContract run the same tests to every implementation of repositories,
+// so they behave the same
+func TestRepositoryContract(t *testing.T) {
+	t.Parallel()
+
+	implementations := map[string]func(t *testing.T) repositories{
+		"gorm":   newGormRepositories,
+		"memory": newMemoryRepositories,
+	}
+
+	for name, newRepositories := range implementations {
+		newRepositories := newRepositories
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			testActivityRepositoryContract(t, newRepositories)
+			testTodoRepositoryContract(t, newRepositories)
+			testTransactorContract(t, newRepositories)
+			testOwnerContract(t, newRepositories)
+		})
+	}
+}
+
+func testActivityRepositoryContract(t *testing.T, newRepositories func(t *testing.T) repositories) {
+	t.Run("activity save and find", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		require.NotEmpty(t, activity.ID)
+		require.NotEmpty(t, activity.CreatedAt)
+		require.NotEmpty(t, activity.UpdatedAt)
+
+		found, err := r.activity.FindOne(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, activity.ID, found.ID)
+		require.Equal(t, activity.Title, found.Title)
+		require.Equal(t, activity.Email, found.Email)
+		require.WithinDuration(t, *activity.CreatedAt, *found.CreatedAt, time.Millisecond)
+		require.False(t, found.DeletedAt.Valid)
+
+		notFound, err := r.activity.FindOne(activity.ID + 100)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(notFound.ID))
+
+		all, err := r.activity.FindAll()
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{activity.ID}, activityIDs(all))
+
+		count, err := r.activity.Count()
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, int(count))
+	})
+
+	t.Run("activity email is unique", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		_, err := r.activity.Save(domain.Activity{Title: "bravo", Email: activity.Email})
+		require.ErrorIs(t, err, apperror.ErrConflict)
+	})
+
+	t.Run("activity update", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		activity.Title = "bravo"
+		updated, err := r.activity.Update(activity)
+		helper.ErrLogPanic(err)
+		require.Equal(t, "bravo", updated.Title)
+		require.False(t, updated.UpdatedAt.Before(activity.UpdatedAt))
+
+		found, err := r.activity.FindOne(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, "bravo", found.Title)
+		require.WithinDuration(t, *activity.CreatedAt, *found.CreatedAt, time.Millisecond)
+	})
+
+	t.Run("activity delete, trash and restore", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		other := saveActivityContract(t, r, "bravo")
+
+		ok, err := r.activity.Delete(activity)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+
+		found, err := r.activity.FindOne(activity.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(found.ID))
+
+		count, err := r.activity.Count()
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, int(count))
+
+		trashed, err := r.activity.FindTrashed()
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{activity.ID}, activityIDs(trashed))
+		require.True(t, trashed[0].DeletedAt.Valid)
+
+		trashedOne, err := r.activity.FindTrashedOne(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, activity.ID, trashedOne.ID)
+
+		notTrashed, err := r.activity.FindTrashedOne(other.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(notTrashed.ID))
+
+		restored, err := r.activity.Restore(trashedOne)
+		helper.ErrLogPanic(err)
+		require.False(t, restored.DeletedAt.Valid)
+
+		found, err = r.activity.FindOne(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, activity.ID, found.ID)
+	})
+
+	t.Run("activity purge with todos", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		other := saveActivityContract(t, r, "bravo")
+		todo := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+		otherTodo := saveTodoContract(t, r, domain.Todo{ActivityGroupID: other.ID})
+
+		ok, err := r.activity.Purge(activity)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+
+		trashed, err := r.activity.FindTrashedOne(activity.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(trashed.ID))
+
+		// Todos is deleted by cascade
+		found, err := r.todo.FindOne(todo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(found.ID))
+
+		// Trashed activity is purged with its todos
+		_, err = r.activity.Delete(other)
+		helper.ErrLogPanic(err)
+		saveActivityContract(t, r, "charlie")
+
+		ok, err = r.activity.Purge(other)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+
+		count, err := r.activity.Count()
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, int(count))
+
+		found, err = r.todo.FindOne(otherTodo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(found.ID))
+	})
+
+	t.Run("activity find by filter", func(t *testing.T) {
+		r := newRepositories(t)
+
+		charlie := saveActivityContract(t, r, "charlie")
+		alpha := saveActivityContract(t, r, "alpha")
+		bravo := saveActivityContract(t, r, "bravo")
+
+		activities, err := r.activity.FindByFilter(repository.ActivityFilter{})
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{charlie.ID, alpha.ID, bravo.ID}, activityIDs(activities))
+
+		activities, err = r.activity.FindByFilter(repository.ActivityFilter{Sort: "title"})
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{alpha.ID, bravo.ID, charlie.ID}, activityIDs(activities))
+
+		activities, err = r.activity.FindByFilter(repository.ActivityFilter{Sort: "-title", Page: repository.Page{Limit: 2, Offset: 1}})
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{bravo.ID, alpha.ID}, activityIDs(activities))
+
+		// Next page of cursor
+		cursor := repository.ActivityCursor(alpha, "title")
+		activities, err = r.activity.FindByFilter(repository.ActivityFilter{Sort: "title", Page: repository.Page{Limit: 1, After: &cursor}})
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{bravo.ID}, activityIDs(activities))
+
+		cursor = repository.ActivityCursor(alpha, "-id")
+		activities, err = r.activity.FindByFilter(repository.ActivityFilter{Sort: "-id", Page: repository.Page{After: &cursor}})
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{charlie.ID}, activityIDs(activities))
+
+		_, err = r.activity.FindByFilter(repository.ActivityFilter{Sort: "title", Page: repository.Page{After: &cursor}})
+		require.ErrorIs(t, err, repository.ErrInvalidCursor)
+
+		_, err = r.activity.FindByFilter(repository.ActivityFilter{Sort: "email"})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+	})
+}
+
+func testTodoRepositoryContract(t *testing.T, newRepositories func(t *testing.T) repositories) {
+	t.Run("todo save and find", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		todo := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+
+		// Default value
+		require.NotEmpty(t, todo.ID)
+		require.True(t, todo.IsActive)
+		require.Equal(t, domain.PriorityVeryHigh, todo.Priority)
+		require.NotEmpty(t, todo.CreatedAt)
+
+		found, err := r.todo.FindOne(todo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, todo.ID, found.ID)
+		require.Equal(t, todo.Title, found.Title)
+		require.Equal(t, activity.ID, found.ActivityGroupID)
+		require.True(t, found.IsActive)
+		require.Equal(t, domain.PriorityVeryHigh, found.Priority)
+		require.Nil(t, found.DueAt)
+
+		notFound, err := r.todo.FindOne(todo.ID + 100)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(notFound.ID))
+
+		all, err := r.todo.FindAll()
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{todo.ID}, todoIDs(all))
+
+		byActivity, err := r.todo.FindByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{todo.ID}, todoIDs(byActivity))
+	})
+
+	t.Run("todo constraint", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+
+		_, err := r.todo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: "alpha", Priority: "urgent"})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+
+		_, err = r.todo.Save(domain.Todo{ActivityGroupID: activity.ID + 100, Title: "alpha"})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+
+		todo := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+		todo.ActivityGroupID = activity.ID + 100
+		_, err = r.todo.Update(todo)
+		require.ErrorIs(t, err, apperror.ErrValidation)
+	})
+
+	t.Run("todo update", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		other := saveActivityContract(t, r, "bravo")
+		todo := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+
+		dueAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+		todo.Title = "bravo"
+		todo.IsActive = false
+		todo.Priority = domain.PriorityLow
+		todo.DueAt = &dueAt
+		todo.ActivityGroupID = other.ID
+		_, err := r.todo.Update(todo)
+		helper.ErrLogPanic(err)
+
+		found, err := r.todo.FindOne(todo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, "bravo", found.Title)
+		require.False(t, found.IsActive)
+		require.Equal(t, domain.PriorityLow, found.Priority)
+		require.True(t, dueAt.Equal(*found.DueAt))
+		require.Equal(t, other.ID, found.ActivityGroupID)
+	})
+
+	t.Run("update and delete of other version", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		todo := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+		require.Equal(t, 1, int(activity.Version))
+		require.Equal(t, 1, int(todo.Version))
+
+		updated, err := r.todo.Update(todo)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 2, int(updated.Version))
+		updatedActivity, err := r.activity.Update(activity)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 2, int(updatedActivity.Version))
+
+		// The version read before is changed by other update
+		todo.Title = "stale"
+		_, err = r.todo.Update(todo)
+		require.ErrorIs(t, err, apperror.ErrConflict)
+		require.ErrorIs(t, err, repository.ErrVersionConflict)
+		_, err = r.todo.Delete(todo)
+		require.ErrorIs(t, err, repository.ErrVersionConflict)
+		_, err = r.activity.Update(activity)
+		require.ErrorIs(t, err, repository.ErrVersionConflict)
+		_, err = r.activity.Delete(activity)
+		require.ErrorIs(t, err, repository.ErrVersionConflict)
+
+		found, err := r.todo.FindOne(todo.ID)
+		helper.ErrLogPanic(err)
+		require.NotEqual(t, "stale", found.Title)
+		require.Equal(t, 2, int(found.Version))
+
+		// Version 0 update whatever version is stored
+		found.Version = 0
+		found.Title = "latest"
+		updated, err = r.todo.Update(found)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 3, int(updated.Version))
+
+		// Moved todo is changed too
+		other := saveActivityContract(t, r, "bravo")
+		_, err = r.todo.MoveActivity(activity.ID, other.ID)
+		helper.ErrLogPanic(err)
+		found, err = r.todo.FindOne(todo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 4, int(found.Version))
+
+		ok, err := r.todo.Delete(found)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+	})
+
+	t.Run("todo find by filter", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		other := saveActivityContract(t, r, "bravo")
+		now := time.Now().Truncate(time.Millisecond)
+		yesterday, tomorrow := now.Add(-24*time.Hour), now.Add(24*time.Hour)
+
+		low := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID, Title: "Write weekly REPORT", Priority: domain.PriorityLow, DueAt: &tomorrow})
+		high := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID, Title: "Report 100%_done", Priority: domain.PriorityHigh, DueAt: &yesterday})
+		medium := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID, Priority: domain.PriorityMedium})
+		veryHigh := saveTodoContract(t, r, domain.Todo{ActivityGroupID: other.ID, DueAt: &yesterday})
+
+		// Done todo is not overdue
+		done := saveTodoContract(t, r, domain.Todo{ActivityGroupID: other.ID, Priority: domain.PriorityVeryLow, DueAt: &yesterday})
+		done.IsActive = false
+		_, err := r.todo.Update(done)
+		helper.ErrLogPanic(err)
+
+		cases := []struct {
+			name   string
+			filter repository.TodoFilter
+			ids    []uint64
+		}{
+			{"all", repository.TodoFilter{}, []uint64{low.ID, high.ID, medium.ID, veryHigh.ID, done.ID}},
+			{"activity group", repository.TodoFilter{ActivityGroupID: activity.ID}, []uint64{low.ID, high.ID, medium.ID}},
+			{"ids", repository.TodoFilter{IDs: []uint64{done.ID, low.ID}}, []uint64{low.ID, done.ID}},
+			{"priorities", repository.TodoFilter{Priorities: []string{domain.PriorityLow, domain.PriorityHigh}}, []uint64{low.ID, high.ID}},
+			{"due before", repository.TodoFilter{DueBefore: now}, []uint64{high.ID, veryHigh.ID, done.ID}},
+			{"due after", repository.TodoFilter{DueAfter: now}, []uint64{low.ID}},
+			{"overdue", repository.TodoFilter{OverdueAt: now}, []uint64{high.ID, veryHigh.ID}},
+			{"sort priority", repository.TodoFilter{ActivityGroupID: activity.ID, Sort: "priority"}, []uint64{high.ID, medium.ID, low.ID}},
+			{"sort priority descending", repository.TodoFilter{Sort: "-priority"}, []uint64{done.ID, low.ID, medium.ID, high.ID, veryHigh.ID}},
+			{"sort due at", repository.TodoFilter{ActivityGroupID: activity.ID, Sort: "due_at"}, []uint64{medium.ID, high.ID, low.ID}},
+			{"sort due at descending", repository.TodoFilter{ActivityGroupID: activity.ID, Sort: "-due_at"}, []uint64{low.ID, high.ID, medium.ID}},
+			{"limit and offset", repository.TodoFilter{Page: repository.Page{Limit: 2, Offset: 1}}, []uint64{high.ID, medium.ID}},
+			{"is active", repository.TodoFilter{IsActive: &done.IsActive}, []uint64{done.ID}},
+			{"created after", repository.TodoFilter{CreatedAfter: *medium.CreatedAt}, []uint64{veryHigh.ID, done.ID}},
+			{"title contains", repository.TodoFilter{TitleContains: "report"}, []uint64{low.ID, high.ID}},
+			{"title contains wildcard", repository.TodoFilter{TitleContains: "%_d"}, []uint64{high.ID}},
+			{"expression", repository.TodoFilter{Expression: parseFilterContract(`is_active = false or priority in (low, "high")`)}, []uint64{low.ID, high.ID, done.ID}},
+			{"expression null", repository.TodoFilter{Expression: parseFilterContract(`due_at = null OR title ~ 'weekly'`)}, []uint64{low.ID, medium.ID}},
+			{"expression not nullable", repository.TodoFilter{Expression: parseFilterContract("not due_at < " + now.Format(time.RFC3339Nano))}, []uint64{low.ID, medium.ID}},
+			{"expression nested", repository.TodoFilter{Expression: parseFilterContract(fmt.Sprintf(
+				"activity_group_id = %d and not (priority = high or (due_at != null and due_at > %s))", activity.ID, now.Format(time.RFC3339Nano)))}, []uint64{medium.ID}},
+			{"expression with sort and page", repository.TodoFilter{Expression: parseFilterContract("id >= 1"), Sort: "-priority", Page: repository.Page{Limit: 2}}, []uint64{done.ID, low.ID}},
+		}
+
+		for _, c := range cases {
+			todos, err := r.todo.FindByFilter(c.filter)
+			helper.ErrLogPanic(err)
+			require.Equal(t, c.ids, todoIDs(todos), c.name)
+
+			countFilter := c.filter
+			countFilter.Page = repository.Page{}
+			count, err := r.todo.CountByFilter(countFilter)
+			helper.ErrLogPanic(err)
+			if c.filter.Page.Limit == 0 {
+				require.Equal(t, len(c.ids), int(count), c.name)
+			}
+		}
+
+		// Walk all pages by cursor of priority
+		var ids []uint64
+		filter := repository.TodoFilter{Sort: "priority", Page: repository.Page{Limit: 2}}
+		for {
+			todos, err := r.todo.FindByFilter(filter)
+			helper.ErrLogPanic(err)
+			if len(todos) == 0 {
+				break
+			}
+			ids = append(ids, todoIDs(todos)...)
+			cursor := repository.TodoCursor(todos[len(todos)-1], filter.Sort)
+			filter.Page.After = &cursor
+		}
+		require.Equal(t, []uint64{veryHigh.ID, high.ID, medium.ID, low.ID, done.ID}, ids)
+
+	})
+
+	t.Run("todo delete, trash and restore", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		todo := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+		other := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+
+		ok, err := r.todo.Delete(todo)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+
+		found, err := r.todo.FindOne(todo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(found.ID))
+
+		count, err := r.todo.CountByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, int(count))
+
+		trashed, err := r.todo.FindTrashed()
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{todo.ID}, todoIDs(trashed))
+
+		trashedOne, err := r.todo.FindTrashedOne(todo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, todo.ID, trashedOne.ID)
+
+		restored, err := r.todo.Restore(trashedOne)
+		helper.ErrLogPanic(err)
+		require.False(t, restored.DeletedAt.Valid)
+
+		found, err = r.todo.FindOne(todo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, todo.ID, found.ID)
+
+		// Purge
+		_, err = r.todo.Delete(todo)
+		helper.ErrLogPanic(err)
+		ok, err = r.todo.Purge(todo)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+
+		trashedOne, err = r.todo.FindTrashedOne(todo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		require.Equal(t, 0, int(trashedOne.ID))
+
+		found, err = r.todo.FindOne(other.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, other.ID, found.ID)
+	})
+
+	t.Run("todos by activity group", func(t *testing.T) {
+		r := newRepositories(t)
+
+		activity := saveActivityContract(t, r, "alpha")
+		other := saveActivityContract(t, r, "bravo")
+		first := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+		second := saveTodoContract(t, r, domain.Todo{ActivityGroupID: activity.ID})
+
+		// Todo deleted before is not restored with the activity group
+		_, err := r.todo.Delete(first)
+		helper.ErrLogPanic(err)
+		time.Sleep(10 * time.Millisecond)
+		deletedSince := time.Now()
+
+		deleted, err := r.todo.DeleteByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Len(t, deleted, 1)
+		require.Equal(t, second.ID, deleted[0].ID)
+
+		count, err := r.todo.CountByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, int(count))
+
+		restored, err := r.todo.RestoreByActivityID(activity.ID, deletedSince.Add(-time.Millisecond))
+		helper.ErrLogPanic(err)
+		require.Len(t, restored, 1)
+		require.Equal(t, second.ID, restored[0].ID)
+		require.False(t, restored[0].DeletedAt.Valid)
+
+		found, err := r.todo.FindOne(second.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, second.ID, found.ID)
+
+		moved, err := r.todo.MoveActivity(activity.ID, other.ID)
+		helper.ErrLogPanic(err)
+		require.Len(t, moved, 1)
+		require.Equal(t, other.ID, moved[0].ActivityGroupID)
+		require.Equal(t, second.Version+1, moved[0].Version)
+
+		found, err = r.todo.FindOne(second.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, other.ID, found.ActivityGroupID)
+
+		_, err = r.todo.MoveActivity(other.ID, other.ID+100)
+		require.ErrorIs(t, err, apperror.ErrValidation)
+	})
+}
+
+func testTransactorContract(t *testing.T, newRepositories func(t *testing.T) repositories) {
+	t.Run("transaction commit and roll back", func(t *testing.T) {
+		r := newRepositories(t)
+		activity := saveActivityContract(t, r, "alpha")
+
+		errRollback := errors.New("roll back")
+		err := r.transactor.WithinTransaction(func(tx repository.Transaction) error {
+			_, err := tx.Todo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: "alpha"})
+			helper.ErrLogPanic(err)
+			_, err = tx.Activity.Delete(activity)
+			helper.ErrLogPanic(err)
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		found, err := r.activity.FindOne(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, activity.ID, found.ID)
+		count, err := r.todo.CountByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, int(count))
+
+		err = r.transactor.WithinTransaction(func(tx repository.Transaction) error {
+			_, err := tx.Todo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: "alpha"})
+			return err
+		})
+		helper.ErrLogPanic(err)
+
+		count, err = r.todo.CountByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, int(count))
+	})
+
+	t.Run("nested transaction roll back alone", func(t *testing.T) {
+		r := newRepositories(t)
+		activity := saveActivityContract(t, r, "alpha")
+
+		errRollback := errors.New("roll back")
+		err := r.transactor.WithinTransaction(func(tx repository.Transaction) error {
+			err := tx.Nested(func(tx repository.Transaction) error {
+				_, err := tx.Todo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: "kept"})
+				return err
+			})
+			helper.ErrLogPanic(err)
+
+			err = tx.Nested(func(tx repository.Transaction) error {
+				_, err := tx.Todo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: "rolled back"})
+				helper.ErrLogPanic(err)
+				// Constraint error inside savepoint does not abort the transaction
+				_, err = tx.Todo.Save(domain.Todo{ActivityGroupID: activity.ID + 1000, Title: "no activity"})
+				require.Error(t, err)
+				return errRollback
+			})
+			require.ErrorIs(t, err, errRollback)
+
+			count, err := tx.Todo.CountByActivityID(activity.ID)
+			helper.ErrLogPanic(err)
+			require.Equal(t, 1, int(count))
+			return nil
+		})
+		helper.ErrLogPanic(err)
+
+		todos, err := r.todo.FindByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, len(todos))
+		require.Equal(t, "kept", todos[0].Title)
+	})
+
+	t.Run("outbox event committed with transaction", func(t *testing.T) {
+		r := newRepositories(t)
+		activity := saveActivityContract(t, r, "alpha")
+
+		errRollback := errors.New("roll back")
+		for _, title := range []string{"rolled back", "committed", "next"} {
+			title := title
+			err := r.transactor.WithinTransaction(func(tx repository.Transaction) error {
+				todo, err := tx.Todo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: title})
+				helper.ErrLogPanic(err)
+				_, err = tx.Outbox.Save(domain.OutboxEvent{AggregateType: domain.AggregateTodo, AggregateID: todo.ID, EventType: "todo.created",
+					ActivityGroupID: activity.ID, Payload: []byte(title)})
+				helper.ErrLogPanic(err)
+				if title == "rolled back" {
+					return errRollback
+				}
+				return nil
+			})
+			if title == "rolled back" {
+				require.ErrorIs(t, err, errRollback)
+			}
+		}
+
+		// Oldest first
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		due, err := r.outbox.FindDue(now, 10)
+		helper.ErrLogPanic(err)
+		require.Len(t, due, 2)
+		committed, next := due[0], due[1]
+		require.Equal(t, "committed", string(committed.Payload))
+		require.Equal(t, "next", string(next.Payload))
+		require.Equal(t, domain.OutboxPending, committed.Status)
+
+		// Later event of the same todo wait for the oldest one
+		later, err := r.outbox.Save(domain.OutboxEvent{AggregateType: domain.AggregateTodo, AggregateID: committed.AggregateID, EventType: "todo.updated",
+			ActivityGroupID: activity.ID, Payload: []byte("later")})
+		helper.ErrLogPanic(err)
+
+		nextAttemptAt := now.Add(time.Minute)
+		committed.Attempts, committed.SentSinks, committed.LastError, committed.NextAttemptAt = 1, 1, "failed", &nextAttemptAt
+		_, err = r.outbox.Update(committed)
+		helper.ErrLogPanic(err)
+		publishedAt := now.Add(-time.Hour)
+		next.Status, next.PublishedAt = domain.OutboxPublished, &publishedAt
+		_, err = r.outbox.Update(next)
+		helper.ErrLogPanic(err)
+
+		due, err = r.outbox.FindDue(now, 10)
+		helper.ErrLogPanic(err)
+		require.Empty(t, due)
+
+		due, err = r.outbox.FindDue(nextAttemptAt, 10)
+		helper.ErrLogPanic(err)
+		require.Len(t, due, 1)
+		require.Equal(t, committed.ID, due[0].ID)
+		require.Equal(t, 1, due[0].Attempts)
+		require.Equal(t, 1, due[0].SentSinks)
+		require.Equal(t, "failed", due[0].LastError)
+
+		// Dead event does not hold back later events
+		committed.Status = domain.OutboxDead
+		_, err = r.outbox.Update(committed)
+		helper.ErrLogPanic(err)
+		due, err = r.outbox.FindDue(nextAttemptAt, 10)
+		helper.ErrLogPanic(err)
+		require.Len(t, due, 1)
+		require.Equal(t, later.ID, due[0].ID)
+
+		// Only published events are deleted
+		count, err := r.outbox.DeletePublished(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, int64(1), count)
+	})
+
+	t.Run("concurrent save", func(t *testing.T) {
+		r := newRepositories(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				saveActivityContract(t, r, jabufaker.RandomString(20))
+			}()
+		}
+		wg.Wait()
+
+		activities, err := r.activity.FindAll()
+		helper.ErrLogPanic(err)
+		require.Equal(t, 20, len(activities))
+
+		ids := map[uint64]bool{}
+		for _, activity := range activities {
+			ids[activity.ID] = true
+		}
+		require.Equal(t, 20, len(ids))
+	})
+}
+
+func testOwnerContract(t *testing.T, newRepositories func(t *testing.T) repositories) {
+	t.Run("user save and find", func(t *testing.T) {
+		r := newRepositories(t)
+		user, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "alpha", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+		require.NotZero(t, user.ID)
+		require.NotNil(t, user.CreatedAt)
+
+		found, err := r.user.FindByEmail(user.Email)
+		helper.ErrLogPanic(err)
+		require.Equal(t, user.ID, found.ID)
+		require.Equal(t, "hash", found.PasswordHash)
+
+		_, err = r.user.Save(domain.User{Email: user.Email, Name: "bravo", PasswordHash: "hash"})
+		require.ErrorIs(t, err, apperror.ErrConflict)
+
+		_, err = r.user.FindOne(user.ID + 100)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		_, err = r.user.FindByEmail(jabufaker.RandomEmail())
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("api key of user", func(t *testing.T) {
+		r := newRepositories(t)
+		alice, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "alice", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+		bob, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "bob", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+
+		key, err := r.apiKey.Save(domain.APIKey{UserID: alice.ID, Name: "alpha", Prefix: "tdl_1", KeyHash: "hash-1", Scopes: domain.ScopeTodosRead})
+		helper.ErrLogPanic(err)
+		require.NotZero(t, key.ID)
+		require.Nil(t, key.LastUsedAt)
+
+		_, err = r.apiKey.Save(domain.APIKey{UserID: bob.ID, Name: "bravo", Prefix: "tdl_1", KeyHash: "hash-1", Scopes: domain.ScopeTodosRead})
+		require.ErrorIs(t, err, apperror.ErrConflict)
+		_, err = r.apiKey.Save(domain.APIKey{UserID: bob.ID + 100, Name: "bravo", Prefix: "tdl_2", KeyHash: "hash-2", Scopes: domain.ScopeTodosRead})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+
+		usedAt := time.Now().Truncate(time.Second)
+		helper.ErrLogPanic(r.apiKey.Touch(key.ID, usedAt))
+		found, err := r.apiKey.FindByHash("hash-1")
+		helper.ErrLogPanic(err)
+		require.Equal(t, key.ID, found.ID)
+		require.True(t, usedAt.Equal(*found.LastUsedAt))
+
+		keys, err := r.apiKey.FindByUserID(bob.ID)
+		helper.ErrLogPanic(err)
+		require.Empty(t, keys)
+
+		// Key is revoked by its user only
+		_, err = r.apiKey.Delete(bob.ID, key.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		ok, err := r.apiKey.Delete(alice.ID, key.ID)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+		_, err = r.apiKey.FindByHash("hash-1")
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("idempotency key of user", func(t *testing.T) {
+		r := newRepositories(t)
+		alice, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "alice", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+		bob, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "bob", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+
+		now := time.Now()
+		key, err := r.idempotencyKey.Save(domain.IdempotencyKey{UserID: alice.ID, Key: "alpha", Fingerprint: "hash-1", ExpiresAt: now.Add(time.Hour)})
+		helper.ErrLogPanic(err)
+		require.False(t, key.IsCompleted())
+
+		// Key is unique for each user
+		_, err = r.idempotencyKey.Save(domain.IdempotencyKey{UserID: alice.ID, Key: "alpha", Fingerprint: "hash-2", ExpiresAt: now.Add(time.Hour)})
+		require.ErrorIs(t, err, apperror.ErrConflict)
+		_, err = r.idempotencyKey.Save(domain.IdempotencyKey{UserID: bob.ID, Key: "alpha", Fingerprint: "hash-2", ExpiresAt: now.Add(-time.Hour)})
+		helper.ErrLogPanic(err)
+
+		_, err = r.idempotencyKey.Update(domain.IdempotencyKey{ID: key.ID, StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":1}`)})
+		helper.ErrLogPanic(err)
+		found, err := r.idempotencyKey.FindByKey(alice.ID, "alpha")
+		helper.ErrLogPanic(err)
+		require.Equal(t, "hash-1", found.Fingerprint)
+		require.Equal(t, 201, found.StatusCode)
+		require.Equal(t, `{"id":1}`, string(found.Body))
+
+		deleted, err := r.idempotencyKey.DeleteExpired(now)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, int(deleted))
+		_, err = r.idempotencyKey.FindByKey(bob.ID, "alpha")
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+
+		ok, err := r.idempotencyKey.Delete(key.ID)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+		_, err = r.idempotencyKey.FindByKey(alice.ID, "alpha")
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("webhook and its deliveries", func(t *testing.T) {
+		r := newRepositories(t)
+		alice, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "alice", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+		bob, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "bob", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+
+		webhook, err := r.webhook.Save(domain.Webhook{UserID: alice.ID, URL: "https://example.com/hook", Secret: "secret", EventTypes: "todo.created todo.updated"})
+		helper.ErrLogPanic(err)
+		_, err = r.webhook.Save(domain.Webhook{UserID: bob.ID, URL: "https://example.com/bob", Secret: "secret", EventTypes: "todo.deleted"})
+		helper.ErrLogPanic(err)
+
+		// Webhook of other user is not found, 0 is any user
+		_, err = r.webhook.FindOne(bob.ID, webhook.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		found, err := r.webhook.FindOne(0, webhook.ID)
+		helper.ErrLogPanic(err)
+		require.True(t, found.IsSubscribed("todo.updated", 7))
+		webhooks, err := r.webhook.FindAll()
+		helper.ErrLogPanic(err)
+		require.Len(t, webhooks, 2)
+
+		activityGroupID := uint64(7)
+		webhook.ActivityGroupID = &activityGroupID
+		webhook.EventTypes = "todo.updated"
+		_, err = r.webhook.Update(webhook)
+		helper.ErrLogPanic(err)
+		webhooks, err = r.webhook.FindByUserID(alice.ID)
+		helper.ErrLogPanic(err)
+		require.Len(t, webhooks, 1)
+		require.False(t, webhooks[0].IsSubscribed("todo.updated", 8))
+		require.False(t, webhooks[0].IsSubscribed("todo.created", 7))
+
+		// Due delivery is claimed only once
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		first, err := r.delivery.Save(domain.WebhookDelivery{WebhookID: webhook.ID, EventID: 1, EventType: "todo.updated", Payload: []byte(`{"id":1}`), Status: domain.DeliveryPending, NextAttemptAt: now.Add(-time.Minute)})
+		helper.ErrLogPanic(err)
+		_, err = r.delivery.Save(domain.WebhookDelivery{WebhookID: webhook.ID, EventID: 2, EventType: "todo.updated", Payload: []byte(`{"id":2}`), Status: domain.DeliveryPending, NextAttemptAt: now.Add(time.Minute)})
+		helper.ErrLogPanic(err)
+
+		due, err := r.delivery.FindDue(now, 10)
+		helper.ErrLogPanic(err)
+		require.Len(t, due, 1)
+		require.Equal(t, first.ID, due[0].ID)
+		claimed, err := r.delivery.Claim(first.ID, now, now.Add(time.Minute))
+		helper.ErrLogPanic(err)
+		require.True(t, claimed)
+		claimed, err = r.delivery.Claim(first.ID, now, now.Add(time.Minute))
+		helper.ErrLogPanic(err)
+		require.False(t, claimed)
+
+		first.Status, first.Attempts, first.LastStatusCode, first.LastError = domain.DeliveryDead, 3, 500, "failed"
+		_, err = r.delivery.Update(first)
+		helper.ErrLogPanic(err)
+		dead, err := r.delivery.FindByWebhookID(webhook.ID, domain.DeliveryDead, 10)
+		helper.ErrLogPanic(err)
+		require.Len(t, dead, 1)
+		require.Equal(t, 3, dead[0].Attempts)
+		require.Equal(t, "failed", dead[0].LastError)
+		require.Equal(t, `{"id":1}`, string(dead[0].Payload))
+
+		// Newest first
+		deliveries, err := r.delivery.FindByWebhookID(webhook.ID, "", 1)
+		helper.ErrLogPanic(err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, uint64(2), deliveries[0].EventID)
+
+		// Deliveries are deleted with the webhook
+		_, err = r.webhook.Delete(bob.ID, webhook.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		ok, err := r.webhook.Delete(alice.ID, webhook.ID)
+		helper.ErrLogPanic(err)
+		require.True(t, ok)
+		_, err = r.delivery.FindOne(webhook.ID, first.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+	})
+
+	t.Run("repositories scoped to owner", func(t *testing.T) {
+		r := newRepositories(t)
+		alice, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "alice", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+		bob, err := r.user.Save(domain.User{Email: jabufaker.RandomEmail(), Name: "bob", PasswordHash: "hash"})
+		helper.ErrLogPanic(err)
+
+		aliceActivity, aliceTodo := r.activity.WithOwner(alice.ID), r.todo.WithOwner(alice.ID)
+		bobActivity, bobTodo := r.activity.WithOwner(bob.ID), r.todo.WithOwner(bob.ID)
+
+		activity, err := aliceActivity.Save(domain.Activity{Title: "alpha", Email: jabufaker.RandomEmail()})
+		helper.ErrLogPanic(err)
+		require.Equal(t, alice.ID, *activity.UserID)
+		todo, err := aliceTodo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: "alpha"})
+		helper.ErrLogPanic(err)
+
+		// Bob cannot see, change or use activity group and todo of alice
+		_, err = bobActivity.FindOne(activity.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		_, err = bobTodo.FindOne(todo.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		activities, err := bobActivity.FindAll()
+		helper.ErrLogPanic(err)
+		require.Empty(t, activities)
+		count, err := bobTodo.CountByFilter(repository.TodoFilter{})
+		helper.ErrLogPanic(err)
+		require.Equal(t, 0, int(count))
+
+		activity.Title = "bravo"
+		_, err = bobActivity.Update(activity)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		_, err = bobTodo.Update(todo)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		_, err = bobTodo.Save(domain.Todo{ActivityGroupID: activity.ID, Title: "bravo"})
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+		_, err = bobActivity.Delete(activity)
+		helper.ErrLogPanic(err)
+
+		// Alice still see them unchanged, not scoped repository see all
+		found, err := aliceActivity.FindOne(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, "alpha", found.Title)
+		todos, err := aliceTodo.FindAll()
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{todo.ID}, todoIDs(todos))
+		activities, err = r.activity.FindAll()
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{activity.ID}, activityIDs(activities))
+
+		// Member see activity group and its todos with its role
+		role, err := aliceActivity.FindRole(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, domain.RoleOwner, role)
+		_, err = bobActivity.FindRole(activity.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+
+		membership, err := r.membership.Save(domain.Membership{ActivityGroupID: activity.ID, UserID: bob.ID, Role: domain.RoleViewer})
+		helper.ErrLogPanic(err)
+		_, err = r.membership.Save(domain.Membership{ActivityGroupID: activity.ID, UserID: bob.ID, Role: domain.RoleEditor})
+		require.ErrorIs(t, err, apperror.ErrConflict)
+		_, err = r.membership.Save(domain.Membership{ActivityGroupID: activity.ID, UserID: alice.ID + 100, Role: domain.RoleEditor})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+
+		role, err = bobActivity.FindRole(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, domain.RoleViewer, role)
+		found, err = bobActivity.FindOne(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, activity.ID, found.ID)
+		todos, err = bobTodo.FindAll()
+		helper.ErrLogPanic(err)
+		require.Equal(t, []uint64{todo.ID}, todoIDs(todos))
+
+		members, err := r.membership.FindByActivityID(activity.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 2, len(members))
+		require.Equal(t, alice.Email, members[0].User.Email)
+		require.Equal(t, domain.RoleOwner, members[0].Role)
+		count, err = r.membership.CountByRole(activity.ID, domain.RoleOwner)
+		helper.ErrLogPanic(err)
+		require.Equal(t, 1, int(count))
+
+		_, err = r.membership.Delete(membership)
+		helper.ErrLogPanic(err)
+		_, err = r.membership.FindOne(activity.ID, bob.ID)
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+
+		// Transaction keep the owner
+		err = r.transactor.WithOwner(bob.ID).WithinTransaction(func(tx repository.Transaction) error {
+			_, err := tx.Activity.FindOne(activity.ID)
+			return err
+		})
+		require.ErrorIs(t, err, apperror.ErrNotFound)
+	})
+}
+
+// TestMemoryRepositoryService run service with memory repositories, no database needed
+func TestMemoryRepositoryService(t *testing.T) {
+	t.Parallel()
+	r := newMemoryRepositories(t)
+	activityService := service.NewServiceActivity(r.activity, r.transactor)
+	todoService := service.NewServiceTodo(r.todo, r.activity, r.transactor)
+
+	activity, err := activityService.Create(web.ActivityRequest{Title: "alpha", Email: jabufaker.RandomEmail()})
+	helper.ErrLogPanic(err)
+	todo, err := todoService.Create(web.TodoCreateRequest{ActivityGroupID: activity.ID, Title: "alpha"})
+	helper.ErrLogPanic(err)
+
+	// Delete with cascade move todos to trash together
+	_, err = activityService.DeleteWithPolicy(activity.ID, web.ActivityDeleteQuery{Policy: web.DeletePolicyCascade})
+	helper.ErrLogPanic(err)
+	found, err := todoService.GetOne(todo.ID)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+	require.Equal(t, 0, int(found.ID))
+
+	_, err = todoService.Create(web.TodoCreateRequest{ActivityGroupID: activity.ID, Title: "bravo"})
+	require.ErrorIs(t, err, service.ErrActivityGroupNotFound)
+
+	_, err = activityService.Restore(activity.ID)
+	helper.ErrLogPanic(err)
+	found, err = todoService.GetOne(todo.ID)
+	helper.ErrLogPanic(err)
+	require.Equal(t, todo.ID, found.ID)
+}
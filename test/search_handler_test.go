@@ -0,0 +1,120 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchHandler(t *testing.T) {
+	t.Parallel()
+	user := createUser(jabufaker.RandomEmail())
+
+	body := fmt.Sprintf(`{"title": "Weekend groceries", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, user.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+
+	todoIDs := map[string]uint64{}
+	for _, title := range []string{"Buy oat milk", "Clean the kitchen", "Buy milk and oat"} {
+		body := fmt.Sprintf(`{"title": "%s", "activity_group_id": %d}`, title, activityID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items", body, user.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		todoIDs[title] = uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+	}
+
+	search := func(query string, token string) []interface{} {
+		response, responseBody := requestAuth(http.MethodGet, "/search?"+query, "", token)
+		require.Equal(t, http.StatusOK, response.StatusCode, query)
+		return responseBody["data"].([]interface{})
+	}
+
+	t.Run("Ranked with highlight", func(t *testing.T) {
+		results := search("q="+url.QueryEscape("oat milk"), user.AccessToken)
+		require.Equal(t, 2, len(results))
+
+		first := results[0].(map[string]interface{})
+		require.Equal(t, "todo", first["type"])
+		require.Equal(t, todoIDs["Buy oat milk"], uint64(first["id"].(float64)))
+		require.Equal(t, activityID, uint64(first["activity_group_id"].(float64)))
+		require.Equal(t, "Buy <mark>oat</mark> <mark>milk</mark>", first["highlight"])
+		require.Greater(t, first["score"], results[1].(map[string]interface{})["score"])
+	})
+
+	t.Run("Prefix, typo and type", func(t *testing.T) {
+		results := search("q=grocer", user.AccessToken)
+		require.Equal(t, 1, len(results))
+		require.Equal(t, "activity_group", results[0].(map[string]interface{})["type"])
+		require.Equal(t, "Weekend <mark>groceries</mark>", results[0].(map[string]interface{})["highlight"])
+
+		results = search("q=kitchn", user.AccessToken)
+		require.Equal(t, 1, len(results))
+		require.Equal(t, todoIDs["Clean the kitchen"], uint64(results[0].(map[string]interface{})["id"].(float64)))
+
+		require.Empty(t, search("q=grocer&type=todo", user.AccessToken))
+		require.Equal(t, 1, len(search("q=milk&limit=1", user.AccessToken)))
+	})
+
+	t.Run("Updated and deleted todo", func(t *testing.T) {
+		target := fmt.Sprintf("/todo-items/%d", todoIDs["Clean the kitchen"])
+		response, _ := requestAuth(http.MethodPatch, target, `{"title": "Clean the garage"}`, user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Empty(t, search("q=kitchen", user.AccessToken))
+		require.Equal(t, 1, len(search("q=garage", user.AccessToken)))
+
+		response, _ = requestAuth(http.MethodDelete, target, "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Empty(t, search("q=garage", user.AccessToken))
+
+		response, _ = requestAuth(http.MethodPost, target+"/restore", "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, 1, len(search("q=garage", user.AccessToken)))
+	})
+
+	t.Run("Moved todo", func(t *testing.T) {
+		// Other user find only todos moved to its activity group
+		other := createUser(jabufaker.RandomEmail())
+		body := fmt.Sprintf(`{"title": "Shared", "email": "%s"}`, jabufaker.RandomEmail())
+		response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, other.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		sharedID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+		body = fmt.Sprintf(`{"email": "%s", "role": "editor"}`, user.User.Email)
+		response, _ = requestAuth(http.MethodPost, fmt.Sprintf("/activity-groups/%d/members", sharedID), body, other.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		body = fmt.Sprintf(`{"actions": [{"action": "update", "id": %d, "activity_group_id": %d}]}`, todoIDs["Buy milk and oat"], sharedID)
+		response, _ = requestAuth(http.MethodPost, "/todo-items/bulk", body, user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		results := search("q=oat", other.AccessToken)
+		require.Equal(t, 1, len(results))
+		require.Equal(t, todoIDs["Buy milk and oat"], uint64(results[0].(map[string]interface{})["id"].(float64)))
+
+		body = fmt.Sprintf(`{"title": "Chores", "email": "%s"}`, jabufaker.RandomEmail())
+		response, responseBody = requestAuth(http.MethodPost, "/activity-groups", body, user.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		choresID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+		body = fmt.Sprintf(`{"title": "Paint the fence", "activity_group_id": %d}`, choresID)
+		response, _ = requestAuth(http.MethodPost, "/todo-items", body, user.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+
+		response, _ = requestAuth(http.MethodDelete, fmt.Sprintf("/activity-groups/%d?move_to=%d", choresID, sharedID), "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, 1, len(search("q=fence", other.AccessToken)))
+	})
+
+	t.Run("Other user find nothing", func(t *testing.T) {
+		other := createUser(jabufaker.RandomEmail())
+		require.Empty(t, search("q=milk", other.AccessToken))
+	})
+
+	t.Run("Invalid query", func(t *testing.T) {
+		for _, query := range []string{"", "q=%20-", "q=milk&type=user", "q=milk&limit=101"} {
+			response, _ := requestAuth(http.MethodGet, "/search?"+query, "", user.AccessToken)
+			require.Equal(t, http.StatusBadRequest, response.StatusCode, query)
+		}
+	})
+}
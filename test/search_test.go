@@ -0,0 +1,129 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/search"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchRank(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		text      string
+		query     string
+		highlight string
+	}{
+		{"Exact", "Buy oat milk", "milk", "Buy oat <mark>milk</mark>"},
+		{"Prefix", "Weekend groceries", "groc", "Weekend <mark>groceries</mark>"},
+		{"Typo", "Clean the kitchen", "kitchn", "Clean the <mark>kitchen</mark>"},
+		{"Transposed", "Clean the kitchen", "kitchne", "Clean the <mark>kitchen</mark>"},
+		{"Every term", "Buy oat milk", "MILK oat", "Buy <mark>oat</mark> <mark>milk</mark>"},
+		{"Escaped", "<b>milk</b> & tea", "milk", "&lt;b&gt;<mark>milk</mark>&lt;/b&gt; &amp; tea"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			score, highlight, ok := search.Rank(tc.text, search.Tokenize(tc.query))
+			require.True(t, ok)
+			require.Greater(t, score, 0.0)
+			require.Equal(t, tc.highlight, highlight)
+		})
+	}
+
+	t.Run("Not match", func(t *testing.T) {
+		for _, query := range []string{"tea", "milk tea", "mlk", ""} {
+			_, _, ok := search.Rank("Buy oat milk", search.Tokenize(query))
+			require.False(t, ok, query)
+		}
+	})
+
+	t.Run("Better match rank higher", func(t *testing.T) {
+		terms := search.Tokenize("oat milk")
+		phrase, _, _ := search.Rank("Buy oat milk", terms)
+		apart, _, _ := search.Rank("Buy milk and oat", terms)
+		prefix, _, _ := search.Rank("Buy oatmeal milkshake", terms)
+		require.Greater(t, phrase, apart)
+		require.Greater(t, apart, prefix)
+	})
+}
+
+func TestMemoryIndex(t *testing.T) {
+	t.Parallel()
+	index := search.NewIndexMemory()
+
+	err := index.Put(
+		search.Document{Kind: search.KindTodo, ID: 1, Text: "Buy oat milk"},
+		search.Document{Kind: search.KindTodo, ID: 2, Text: "Buy bread"},
+		search.Document{Kind: search.KindActivityGroup, ID: 1, Text: "Milk and bread"},
+	)
+	helper.ErrLogPanic(err)
+
+	find := func(query search.Query) []search.Candidate {
+		candidates, err := index.Find(query)
+		helper.ErrLogPanic(err)
+		return candidates
+	}
+
+	t.Run("Find every term", func(t *testing.T) {
+		require.Equal(t, []search.Candidate{{Kind: search.KindActivityGroup, ID: 1}, {Kind: search.KindTodo, ID: 1}},
+			find(search.Query{Terms: search.Tokenize("mil")}))
+		require.Equal(t, []search.Candidate{{Kind: search.KindTodo, ID: 2}},
+			find(search.Query{Terms: search.Tokenize("buy bred"), Kind: search.KindTodo}))
+		require.Empty(t, find(search.Query{Terms: search.Tokenize("tea")}))
+	})
+
+	t.Run("Only documents of activity groups", func(t *testing.T) {
+		err := index.Put(search.Document{Kind: search.KindTodo, ID: 3, ActivityGroupID: 2, Text: "Bake bread"})
+		helper.ErrLogPanic(err)
+		require.Equal(t, []search.Candidate{{Kind: search.KindTodo, ID: 3}},
+			find(search.Query{Terms: []string{"bread"}, ActivityGroupIDs: []uint64{2}}))
+		require.Empty(t, find(search.Query{Terms: []string{"bread"}, ActivityGroupIDs: []uint64{}}))
+
+		err = index.Delete(search.KindTodo, 3)
+		helper.ErrLogPanic(err)
+	})
+
+	t.Run("Put replace and delete remove", func(t *testing.T) {
+		err := index.Put(search.Document{Kind: search.KindTodo, ID: 2, Text: "Buy green tea"})
+		helper.ErrLogPanic(err)
+		require.Equal(t, []search.Candidate{{Kind: search.KindTodo, ID: 2}}, find(search.Query{Terms: []string{"tea"}}))
+		require.Empty(t, find(search.Query{Terms: []string{"bread"}, Kind: search.KindTodo}))
+
+		err = index.Delete(search.KindTodo, 2)
+		helper.ErrLogPanic(err)
+		require.Empty(t, find(search.Query{Terms: []string{"tea"}}))
+	})
+}
+
+func TestScanIndex(t *testing.T) {
+	t.Parallel()
+	index := search.NewIndexScan(ConnTest)
+
+	activity, err := repository.NewRepositoryActivity(ConnTest).Save(domain.Activity{Title: "Household chores", Email: jabufaker.RandomEmail()})
+	helper.ErrLogPanic(err)
+	todo, err := repository.NewRepositoryTodo(ConnTest).Save(domain.Todo{ActivityGroupID: activity.ID, Title: "Clean the kitchen", Priority: domain.PriorityHigh})
+	helper.ErrLogPanic(err)
+
+	find := func(query search.Query) []search.Candidate {
+		query.ActivityGroupIDs = []uint64{activity.ID}
+		candidates, err := index.Find(query)
+		helper.ErrLogPanic(err)
+		return candidates
+	}
+
+	t.Run("Typo in the first 3 letters", func(t *testing.T) {
+		require.Equal(t, []search.Candidate{{Kind: search.KindTodo, ID: todo.ID}}, find(search.Query{Terms: search.Tokenize("ktichen")}))
+		require.Equal(t, []search.Candidate{{Kind: search.KindActivityGroup, ID: activity.ID}},
+			find(search.Query{Terms: search.Tokenize("huosehold"), Kind: search.KindActivityGroup}))
+	})
+
+	t.Run("Not match", func(t *testing.T) {
+		require.Empty(t, find(search.Query{Terms: search.Tokenize("bathroom")}))
+		require.Empty(t, find(search.Query{Terms: search.Tokenize("ktichen"), Kind: search.KindActivityGroup}))
+	})
+}
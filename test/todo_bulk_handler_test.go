@@ -0,0 +1,137 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkTodoHandler(t *testing.T) {
+	t.Parallel()
+	user := createUser(jabufaker.RandomEmail())
+
+	createActivity := func() uint64 {
+		body := fmt.Sprintf(`{"title": "bulk", "email": "%s"}`, jabufaker.RandomEmail())
+		response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, user.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		return uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+	}
+	createTodo := func(activityID uint64) uint64 {
+		body := fmt.Sprintf(`{"title": "bulk", "activity_group_id": %d}`, activityID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items", body, user.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		return uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+	}
+	getTodo := func(id uint64) (int, map[string]interface{}) {
+		response, responseBody := requestAuth(http.MethodGet, fmt.Sprintf("/todo-items/%d", id), "", user.AccessToken)
+		data, _ := responseBody["data"].(map[string]interface{})
+		return response.StatusCode, data
+	}
+
+	activityID, otherActivityID := createActivity(), createActivity()
+
+	t.Run("Failed action is rolled back alone", func(t *testing.T) {
+		done, moved, deleted := createTodo(activityID), createTodo(activityID), createTodo(activityID)
+
+		body := fmt.Sprintf(`{"actions": [
+			{"action": "update", "id": %d, "is_active": false},
+			{"action": "update", "id": %d, "priority": "low", "activity_group_id": %d},
+			{"action": "delete", "id": %d},
+			{"action": "update", "id": %d, "is_active": false}
+		]}`, done, moved, otherActivityID, deleted, deleted)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items/bulk", body, user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		data := responseBody["data"].(map[string]interface{})
+		require.Equal(t, 3, int(data["succeeded"].(float64)))
+		require.Equal(t, 1, int(data["failed"].(float64)))
+
+		results := data["results"].([]interface{})
+		require.Equal(t, "0", results[0].(map[string]interface{})["data"].(map[string]interface{})["is_active"])
+		require.Nil(t, results[2].(map[string]interface{})["data"])
+		failed := results[3].(map[string]interface{})
+		require.Equal(t, http.StatusNotFound, int(failed["status"].(float64)))
+		require.Equal(t, "not_found", failed["code"])
+		require.Equal(t, fmt.Sprintf("Todo with ID %d Not Found", deleted), failed["message"])
+
+		_, todo := getTodo(done)
+		require.Equal(t, "0", todo["is_active"])
+		_, todo = getTodo(moved)
+		require.Equal(t, "low", todo["priority"])
+		require.Equal(t, otherActivityID, uint64(todo["activity_group_id"].(float64)))
+		status, _ := getTodo(deleted)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Atomic is all or nothing", func(t *testing.T) {
+		todoID := createTodo(activityID)
+
+		body := fmt.Sprintf(`{"atomic": true, "actions": [
+			{"action": "update", "id": %d, "priority": "low"},
+			{"action": "update", "id": %d, "activity_group_id": 999999999}
+		]}`, todoID, todoID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items/bulk", body, user.AccessToken)
+		require.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+		require.Equal(t, "Action 1 failed, no action is applied: Activity with ID 999999999 Not Found", responseBody["message"])
+
+		results := responseBody["data"].(map[string]interface{})["results"].([]interface{})
+		require.Equal(t, http.StatusFailedDependency, int(results[0].(map[string]interface{})["status"].(float64)))
+		require.Equal(t, "not_applied", results[0].(map[string]interface{})["code"])
+		require.Equal(t, "activity_group_not_found", results[1].(map[string]interface{})["code"])
+
+		_, todo := getTodo(todoID)
+		require.Equal(t, "very-high", todo["priority"])
+
+		body = fmt.Sprintf(`{"atomic": true, "actions": [{"action": "update", "id": %d, "priority": "low"}, {"action": "delete", "id": %d}]}`, todoID, todoID)
+		response, _ = requestAuth(http.MethodPost, "/todo-items/bulk", body, user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		status, _ := getTodo(todoID)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Todo of other user is not found", func(t *testing.T) {
+		todoID := createTodo(activityID)
+		other := createUser(jabufaker.RandomEmail())
+
+		body := fmt.Sprintf(`{"actions": [{"action": "delete", "id": %d}]}`, todoID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items/bulk", body, other.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		result := responseBody["data"].(map[string]interface{})["results"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, http.StatusNotFound, int(result["status"].(float64)))
+
+		status, _ := getTodo(todoID)
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Invalid priority is failed action", func(t *testing.T) {
+		todoID := createTodo(activityID)
+
+		body := fmt.Sprintf(`{"actions": [{"action": "update", "id": %d, "priority": "urgent"}]}`, todoID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items/bulk", body, user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		result := responseBody["data"].(map[string]interface{})["results"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, http.StatusUnprocessableEntity, int(result["status"].(float64)))
+		require.Equal(t, "invalid_priority", result["code"])
+		require.Equal(t, "priority must be one of very-high, high, medium, low, very-low", result["message"])
+
+		_, todo := getTodo(todoID)
+		require.Equal(t, "very-high", todo["priority"])
+	})
+
+	t.Run("Invalid actions", func(t *testing.T) {
+		for body, message := range map[string]string{
+			`{}`:              "actions cannot be null",
+			`{"actions": []}`: "actions must have between 1 and 100 action",
+			`{"actions": [{"action": "archive", "id": 1}]}`: "action must be one of update, delete",
+			`{"actions": [{"action": "delete"}]}`:           "actions[0].id cannot be null",
+			`{"actions": [{"action": "update", "id": 1}]}`:  "actions[0] must change is_active, priority or activity_group_id",
+		} {
+			response, responseBody := requestAuth(http.MethodPost, "/todo-items/bulk", body, user.AccessToken)
+			require.Equal(t, http.StatusBadRequest, response.StatusCode, body)
+			require.Equal(t, message, responseBody["message"], body)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/letenk/todo-list/repository"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTodoFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Valid", func(t *testing.T) {
+		for _, expression := range []string{
+			`is_active = true`,
+			`IS_ACTIVE != false AND Priority IN (high, very-high)`,
+			`not (title ~ "say \"hi\"" or title = 'it\'s') and id in (1)`,
+			`due_at = null or due_at >= 2024-01-31 or created_at < 2024-01-31T10:00:00+07:00`,
+		} {
+			_, err := repository.ParseTodoFilter(expression)
+			require.NoError(t, err, expression)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, tc := range []struct {
+			expression string
+			position   int
+			message    string
+		}{
+			{``, 1, "expected field but expression ended"},
+			{`owner = 1`, 1, `unknown field "owner", field must be one of id, activity_group_id, title, is_active, priority, start_at, due_at, created_at, updated_at`},
+			{`is_active ~ true`, 11, "operator of is_active must be one of =, !="},
+			{`is_active = yes`, 13, `value "yes" of is_active must be true or false`},
+			{`priority in (high, urgent)`, 20, `value "urgent" of priority must be one of very-high, high, medium, low, very-low`},
+			{`created_at = null`, 14, "created_at cannot be compared with null"},
+			{`due_at > 31-01-2024`, 10, `value "31-01-2024" of due_at must be RFC3339 time or date`},
+			{`title = "report`, 9, "string is not closed"},
+			{`(id = 1`, 8, `expected ")" but expression ended`},
+			{`id = 1 id = 2`, 8, `expected "and", "or" or end of expression but found "id"`},
+			{`id ! 1`, 4, `unexpected "!", use "!=" or not`},
+			{`id in 1`, 7, `expected "(" but found "1"`},
+		} {
+			_, err := repository.ParseTodoFilter(tc.expression)
+			var filterErr *repository.FilterError
+			require.ErrorAs(t, err, &filterErr, tc.expression)
+			require.Equal(t, tc.position, filterErr.Position, tc.expression)
+			require.Equal(t, tc.message, filterErr.Message, tc.expression)
+		}
+	})
+}
+
+func TestGetAllTodoFilterHandler(t *testing.T) {
+	t.Parallel()
+	user := createUser(jabufaker.RandomEmail())
+
+	body := fmt.Sprintf(`{"title": "work", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, user.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+
+	ids := map[string]float64{}
+	for _, todo := range []struct{ title, priority string }{
+		{"Monthly report", "high"},
+		{"Weekly report", "low"},
+		{"Call the bank", "very-high"},
+	} {
+		body := fmt.Sprintf(`{"title": "%s", "priority": "%s", "activity_group_id": %d}`, todo.title, todo.priority, activityID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items", body, user.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		ids[todo.title] = responseBody["data"].(map[string]interface{})["id"].(float64)
+	}
+
+	target := fmt.Sprintf("/todo-items/%d", uint64(ids["Weekly report"]))
+	response, _ = requestAuth(http.MethodPatch, target, `{"is_active": false}`, user.AccessToken)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	find := func(query string) []float64 {
+		response, responseBody := requestAuth(http.MethodGet, "/todo-items?"+query, "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode, query)
+
+		found := []float64{}
+		for _, todo := range responseBody["data"].([]interface{}) {
+			found = append(found, todo.(map[string]interface{})["id"].(float64))
+		}
+		return found
+	}
+
+	t.Run("Query", func(t *testing.T) {
+		require.Equal(t, []float64{ids["Monthly report"], ids["Call the bank"]}, find("is_active=true"))
+		require.Equal(t, []float64{ids["Monthly report"]}, find("is_active=true&priority=high,low&title~=REPORT"))
+		require.Equal(t, []float64{}, find("created_after="+url.QueryEscape("2999-01-01T00:00:00Z")))
+	})
+
+	t.Run("Filter expression", func(t *testing.T) {
+		filter := url.QueryEscape(`title ~ report and (is_active = false or priority = high)`)
+		require.Equal(t, []float64{ids["Monthly report"], ids["Weekly report"]}, find("filter="+filter+"&sort=id"))
+
+		response, _ := requestAuth(http.MethodGet, "/todo-items?limit=1&filter="+url.QueryEscape("not title ~ bank"), "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "2", response.Header.Get("X-Total-Count"))
+	})
+
+	t.Run("Bad filter", func(t *testing.T) {
+		for query, message := range map[string]string{
+			"filter=" + url.QueryEscape("title ~"):   "filter is invalid, expected value but expression ended at position 8",
+			"filter=" + url.QueryEscape("owner = 1"): `filter is invalid, unknown field "owner", field must be one of id, activity_group_id, title, is_active, priority, start_at, due_at, created_at, updated_at at position 1`,
+			"is_active=maybe":                        "activity_group_id must be a number, is_active must be true or false, due_before, due_after, created_before and created_after must be RFC3339 time",
+		} {
+			response, responseBody := requestAuth(http.MethodGet, "/todo-items?"+query, "", user.AccessToken)
+			require.Equal(t, http.StatusBadRequest, response.StatusCode, query)
+			require.Equal(t, message, responseBody["message"], query)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/letenk/todo-list/models/web"
 	"github.com/rizkydarmawan-letenk/jabufaker"
@@ -376,11 +377,12 @@ func TestUpdateTodo(t *testing.T) {
 
 	t.Run("Success update todo with field is_active", func(t *testing.T) {
 		newTodo := createRandomTodoHandler(t)
+		isActive := false
 		data := web.TodoUpdateRequest{
-			IsActive: false,
+			IsActive: &isActive,
 		}
 
-		dataBody := fmt.Sprintf(`{"is_active": %t}`, data.IsActive)
+		dataBody := fmt.Sprintf(`{"is_active": %t}`, *data.IsActive)
 		requestBody := strings.NewReader(dataBody)
 		id := fmt.Sprintf("%d", newTodo.ID)
 		request := httptest.NewRequest(http.MethodPatch, "http://localhost:3030/todo-items/"+id, requestBody)
@@ -493,3 +495,304 @@ func TestDeleteTodo(t *testing.T) {
 		require.Empty(t, responseBody["data"])
 	})
 }
+
+func TestPriorityTodoHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create new todo with priority", func(t *testing.T) {
+		newActivityGroup := createRandomActivityHandler(t)
+		dataBody := fmt.Sprintf(`{"title": "%s", "activity_group_id": %d, "priority": "low"}`, jabufaker.RandomString(20), newActivityGroup.ID)
+		requestBody := strings.NewReader(dataBody)
+
+		request := httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items", requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 201, response.StatusCode)
+		var contextData = responseBody["data"].(map[string]interface{})
+		require.Equal(t, "low", contextData["priority"])
+	})
+
+	t.Run("create new todo with invalid priority", func(t *testing.T) {
+		newActivityGroup := createRandomActivityHandler(t)
+		dataBody := fmt.Sprintf(`{"title": "%s", "activity_group_id": %d, "priority": "urgent"}`, jabufaker.RandomString(20), newActivityGroup.ID)
+		requestBody := strings.NewReader(dataBody)
+
+		request := httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items", requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 422, response.StatusCode)
+		require.Equal(t, "Unprocessable Entity", responseBody["status"])
+		require.Equal(t, "priority must be one of very-high, high, medium, low, very-low", responseBody["message"])
+	})
+
+	t.Run("update todo priority", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+		requestBody := strings.NewReader(`{"priority": "medium"}`)
+
+		id := fmt.Sprintf("%d", newTodo.ID)
+		request := httptest.NewRequest(http.MethodPatch, "http://localhost:3030/todo-items/"+id, requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		var contextData = responseBody["data"].(map[string]interface{})
+		require.Equal(t, "medium", contextData["priority"])
+		require.Equal(t, newTodo.Title, contextData["title"])
+		require.Equal(t, "1", contextData["is_active"])
+	})
+
+	t.Run("get all todo filter and sort by priority", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+		url := fmt.Sprintf("http://localhost:3030/todo-items?activity_group_id=%d&priority=very-high,high&sort=-priority", newTodo.ActivityID)
+		request := httptest.NewRequest(http.MethodGet, url, nil)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		contextBody := responseBody["data"].([]interface{})
+		require.Equal(t, 1, len(contextBody))
+	})
+
+	t.Run("get all todo with invalid sort", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3030/todo-items?sort=email", nil)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 400, response.StatusCode)
+		require.Equal(t, "Bad Request", responseBody["status"])
+	})
+}
+
+func TestDueDateTodoHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create new todo with due date and get overdue", func(t *testing.T) {
+		newActivityGroup := createRandomActivityHandler(t)
+		dueAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		dataBody := fmt.Sprintf(`{"title": "%s", "activity_group_id": %d, "due_at": "%s"}`, jabufaker.RandomString(20), newActivityGroup.ID, dueAt)
+		requestBody := strings.NewReader(dataBody)
+
+		request := httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items", requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 201, response.StatusCode)
+		var contextData = responseBody["data"].(map[string]interface{})
+		require.NotEmpty(t, contextData["due_at"])
+		require.Nil(t, contextData["start_at"])
+
+		url := fmt.Sprintf("http://localhost:3030/todo-items?activity_group_id=%d&overdue=true", newActivityGroup.ID)
+		request = httptest.NewRequest(http.MethodGet, url, nil)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder = httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response = recorder.Result()
+
+		body, _ = io.ReadAll(response.Body)
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		contextBody := responseBody["data"].([]interface{})
+		require.Equal(t, 1, len(contextBody))
+	})
+
+	t.Run("create new todo with start after due", func(t *testing.T) {
+		newActivityGroup := createRandomActivityHandler(t)
+		dataBody := fmt.Sprintf(`{"title": "%s", "activity_group_id": %d, "start_at": "2022-11-02T00:00:00Z", "due_at": "2022-11-01T00:00:00Z"}`, jabufaker.RandomString(20), newActivityGroup.ID)
+		requestBody := strings.NewReader(dataBody)
+
+		request := httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items", requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 422, response.StatusCode)
+		require.Equal(t, "start_at cannot be after due_at", responseBody["message"])
+	})
+
+	t.Run("get all todo with invalid due_before", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3030/todo-items?due_before=tomorrow", nil)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		require.Equal(t, 400, response.StatusCode)
+	})
+}
+
+func TestActivityGroupTodoHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create new todo with activity group not found", func(t *testing.T) {
+		dataBody := fmt.Sprintf(`{"title": "%s", "activity_group_id": %d}`, jabufaker.RandomString(20), 7329323)
+		requestBody := strings.NewReader(dataBody)
+
+		request := httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items", requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 422, response.StatusCode)
+		require.Equal(t, "Unprocessable Entity", responseBody["status"])
+		require.Equal(t, "Activity with ID 7329323 Not Found", responseBody["message"])
+
+		var contextData = responseBody["data"].(map[string]interface{})
+		require.Equal(t, "activity_group_id", contextData["field"])
+		require.Equal(t, 7329323, int(contextData["value"].(float64)))
+	})
+
+	t.Run("move todo to other activity group", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+		newActivityGroup := createRandomActivityHandler(t)
+
+		dataBody := fmt.Sprintf(`{"activity_group_id": %d}`, newActivityGroup.ID)
+		requestBody := strings.NewReader(dataBody)
+
+		url := fmt.Sprintf("http://localhost:3030/todo-items/%d", newTodo.ID)
+		request := httptest.NewRequest(http.MethodPatch, url, requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		var contextData = responseBody["data"].(map[string]interface{})
+		require.Equal(t, newActivityGroup.ID, uint64(contextData["activity_group_id"].(float64)))
+		require.Equal(t, newTodo.Title, contextData["title"])
+	})
+
+	t.Run("move todo to activity group not found", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+
+		dataBody := fmt.Sprintf(`{"activity_group_id": %d}`, 7329323)
+		requestBody := strings.NewReader(dataBody)
+
+		url := fmt.Sprintf("http://localhost:3030/todo-items/%d", newTodo.ID)
+		request := httptest.NewRequest(http.MethodPatch, url, requestBody)
+		request.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 422, response.StatusCode)
+		require.Equal(t, "Activity with ID 7329323 Not Found", responseBody["message"])
+	})
+}
+
+func TestGetAllPageTodoHandler(t *testing.T) {
+	t.Parallel()
+	user := createUser(jabufaker.RandomEmail())
+
+	body := fmt.Sprintf(`{"title": "page", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, user.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := uint64(responseBody["data"].(map[string]interface{})["id"].(float64))
+
+	for i := 0; i <= web.MaxLimit; i++ {
+		body := fmt.Sprintf(`{"title": "todo %d", "activity_group_id": %d}`, i, activityID)
+		response, _ := requestAuth(http.MethodPost, "/todo-items", body, user.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+	}
+
+	t.Run("Get without limit is the first page", func(t *testing.T) {
+		response, responseBody := requestAuth(http.MethodGet, fmt.Sprintf("/todo-items?activity_group_id=%d", activityID), "", user.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, web.MaxLimit, len(responseBody["data"].([]interface{})))
+
+		require.Equal(t, fmt.Sprintf("%d", web.MaxLimit+1), response.Header.Get("X-Total-Count"))
+		require.Contains(t, response.Header.Get("Link"), `rel="next"`)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/letenk/todo-list/apperror"
 	"github.com/letenk/todo-list/helper"
 	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/repository"
@@ -186,7 +187,6 @@ func TestUpdateTodoRepository(t *testing.T) {
 		Priority:        helper.RandomPriority(),
 		CreatedAt:       todos[0].CreatedAt,
 		UpdatedAt:       time.Now(),
-		DeletedAt:       nil,
 	}
 
 	// Update
@@ -223,7 +223,210 @@ func TestDeleteTodoRepository(t *testing.T) {
 	require.True(t, ok)
 
 	todo, err := todoRepository.FindOne(newTodo.ID)
-	helper.ErrLogPanic(err)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
 	nullId := uint64(0)
 	require.Equal(t, nullId, todo.ID)
 }
+
+func TestFindDueTodoRepository(t *testing.T) {
+	t.Parallel()
+	newActivity := createRandomActivityRepository(t)
+	todoRepository := repository.NewRepositoryTodo(ConnTest)
+
+	now := time.Now()
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	// Overdue todo, due date has passed and still active
+	overdueTodo, err := todoRepository.Save(domain.Todo{
+		ActivityGroupID: newActivity.ID,
+		Title:           jabufaker.RandomString(20),
+		IsActive:        true,
+		Priority:        helper.RandomPriority(),
+		DueAt:           &yesterday,
+	})
+	helper.ErrLogPanic(err)
+
+	// Done todo, due date has passed but not active
+	doneTodo, err := todoRepository.Save(domain.Todo{
+		ActivityGroupID: newActivity.ID,
+		Title:           jabufaker.RandomString(20),
+		IsActive:        true,
+		Priority:        helper.RandomPriority(),
+		DueAt:           &yesterday,
+	})
+	helper.ErrLogPanic(err)
+	doneTodo.IsActive = false
+	doneTodo, err = todoRepository.Update(doneTodo)
+	helper.ErrLogPanic(err)
+
+	// Upcoming todo
+	upcomingTodo, err := todoRepository.Save(domain.Todo{
+		ActivityGroupID: newActivity.ID,
+		Title:           jabufaker.RandomString(20),
+		IsActive:        true,
+		Priority:        helper.RandomPriority(),
+		StartAt:         &now,
+		DueAt:           &tomorrow,
+	})
+	helper.ErrLogPanic(err)
+
+	ids := func(todos []domain.Todo) []uint64 {
+		var ids []uint64
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	t.Run("Find overdue", func(t *testing.T) {
+		todos, err := todoRepository.FindByFilter(repository.TodoFilter{OverdueAt: now})
+		require.NoError(t, err)
+
+		require.Contains(t, ids(todos), overdueTodo.ID)
+		require.NotContains(t, ids(todos), doneTodo.ID)
+		require.NotContains(t, ids(todos), upcomingTodo.ID)
+
+		for _, data := range todos {
+			require.True(t, data.IsOverdue(now))
+		}
+	})
+
+	t.Run("Find due between", func(t *testing.T) {
+		todos, err := todoRepository.FindByFilter(repository.TodoFilter{DueAfter: now, DueBefore: tomorrow.Add(time.Minute)})
+		require.NoError(t, err)
+
+		require.Contains(t, ids(todos), upcomingTodo.ID)
+		require.NotContains(t, ids(todos), overdueTodo.ID)
+	})
+
+	t.Run("Find by filter due before in activity group", func(t *testing.T) {
+		todos, err := todoRepository.FindByFilter(repository.TodoFilter{
+			ActivityGroupID: newActivity.ID,
+			DueBefore:       now,
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, 2, len(todos))
+		require.NotContains(t, ids(todos), upcomingTodo.ID)
+	})
+}
+
+func TestFindByFilterPageTodoRepository(t *testing.T) {
+	t.Parallel()
+	newActivity := createRandomActivityRepository(t)
+	todoRepository := repository.NewRepositoryTodo(ConnTest)
+
+	// Create some todo in one activity group
+	for i := 0; i < 5; i++ {
+		_, err := todoRepository.Save(domain.Todo{
+			ActivityGroupID: newActivity.ID,
+			Title:           jabufaker.RandomString(20),
+			IsActive:        true,
+			Priority:        helper.RandomPriority(),
+		})
+		helper.ErrLogPanic(err)
+	}
+
+	for _, sort := range []string{"", "-id", "title", "-priority", "created_at"} {
+		filter := repository.TodoFilter{
+			ActivityGroupID: newActivity.ID,
+			Sort:            sort,
+			Page:            repository.Page{Limit: 2},
+		}
+
+		// Walk all page with cursor
+		seen := map[uint64]bool{}
+		for pages := 0; pages < 5; pages++ {
+			todos, err := todoRepository.FindByFilter(filter)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(todos), 2)
+
+			for _, todo := range todos {
+				require.False(t, seen[todo.ID], "todo %d found twice with sort %s", todo.ID, sort)
+				seen[todo.ID] = true
+			}
+
+			if len(todos) < 2 {
+				break
+			}
+			cursor := repository.TodoCursor(todos[len(todos)-1], sort)
+			filter.Page.After = &cursor
+		}
+		require.Equal(t, 5, len(seen), "sort %s", sort)
+	}
+
+	count, err := todoRepository.CountByFilter(repository.TodoFilter{ActivityGroupID: newActivity.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), count)
+
+	// Offset
+	todos, err := todoRepository.FindByFilter(repository.TodoFilter{
+		ActivityGroupID: newActivity.ID,
+		Page:            repository.Page{Limit: 2, Offset: 4},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(todos))
+
+	// Cursor from other sort
+	cursor := repository.TodoCursor(todos[0], "title")
+	_, err = todoRepository.FindByFilter(repository.TodoFilter{
+		ActivityGroupID: newActivity.ID,
+		Sort:            "-title",
+		Page:            repository.Page{Limit: 2, After: &cursor},
+	})
+	require.ErrorIs(t, err, repository.ErrInvalidCursor)
+}
+
+func TestTrashTodoRepository(t *testing.T) {
+	t.Parallel()
+	newTodo := createRandomTodoRepository(t)
+
+	todoRepository := repository.NewRepositoryTodo(ConnTest)
+
+	// Soft delete
+	ok, err := todoRepository.Delete(newTodo)
+	helper.ErrLogPanic(err)
+	require.True(t, ok)
+
+	// Hidden from normal find
+	todo, err := todoRepository.FindOne(newTodo.ID)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+	require.Equal(t, uint64(0), todo.ID)
+
+	todos, err := todoRepository.FindByActivityID(newTodo.ActivityGroupID)
+	helper.ErrLogPanic(err)
+	require.Equal(t, 0, len(todos))
+
+	// Found in trash
+	trashedTodo, err := todoRepository.FindTrashedOne(newTodo.ID)
+	helper.ErrLogPanic(err)
+	require.Equal(t, newTodo.ID, trashedTodo.ID)
+	require.True(t, trashedTodo.DeletedAt.Valid)
+
+	trashedTodos, err := todoRepository.FindTrashed()
+	helper.ErrLogPanic(err)
+	require.NotEqual(t, 0, len(trashedTodos))
+
+	// Restore
+	restoredTodo, err := todoRepository.Restore(trashedTodo)
+	helper.ErrLogPanic(err)
+	require.False(t, restoredTodo.DeletedAt.Valid)
+
+	todo, err = todoRepository.FindOne(newTodo.ID)
+	helper.ErrLogPanic(err)
+	require.Equal(t, newTodo.ID, todo.ID)
+
+	// Delete permanently
+	ok, err = todoRepository.Delete(todo)
+	helper.ErrLogPanic(err)
+	require.True(t, ok)
+
+	ok, err = todoRepository.Purge(todo)
+	helper.ErrLogPanic(err)
+	require.True(t, ok)
+
+	trashedTodo, err = todoRepository.FindTrashedOne(newTodo.ID)
+	require.ErrorIs(t, err, apperror.ErrNotFound)
+	require.Equal(t, uint64(0), trashedTodo.ID)
+}
@@ -4,21 +4,24 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/letenk/todo-list/apperror"
 	"github.com/letenk/todo-list/helper"
 	"github.com/letenk/todo-list/models/domain"
 	"github.com/letenk/todo-list/models/web"
-	"github.com/letenk/todo-list/repository"
 	"github.com/letenk/todo-list/service"
 	"github.com/rizkydarmawan-letenk/jabufaker"
 	"github.com/stretchr/testify/require"
 )
 
 func createRandomTodoService(t *testing.T) domain.Todo {
-	repository := repository.NewRepositoryTodo(ConnTest)
-	service := service.NewServiceTodo(repository)
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
 
-	newActivity := createRandomActivityRepository(t)
+	newActivity := createRandomActivityService(t)
 
 	data := web.TodoCreateRequest{
 		ActivityGroupID: newActivity.ID,
@@ -71,13 +74,14 @@ func TestGetAllTodoServices(t *testing.T) {
 		newTodos = append(newTodos, <-channel)
 	}
 
-	repository := repository.NewRepositoryTodo(ConnTest)
-	service := service.NewServiceTodo(repository)
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
 
 	t.Run("Get all todos without query activity_group_id", func(t *testing.T) {
 		// Get activity groups
-		ActivityId := int64(0)
-		todos, err := service.GetAll(uint64(ActivityId))
+		todos, err := service.GetAll(web.TodoQuery{})
 		helper.ErrLogPanic(err)
 
 		// Length todos must be greater than 0
@@ -94,14 +98,14 @@ func TestGetAllTodoServices(t *testing.T) {
 			require.NotEmpty(t, data.CreatedAt)
 			require.NotEmpty(t, data.UpdatedAt)
 
-			require.Nil(t, data.DeletedAt)
+			require.False(t, data.DeletedAt.Valid)
 		}
 	})
 
 	t.Run("Get all todos with query activity_group_id", func(t *testing.T) {
 		// Get activity groups
-		ActivityId := newTodos[0].ActivityGroupID
-		todos, err := service.GetAll(uint64(ActivityId))
+		query := web.TodoQuery{ActivityGroupID: newTodos[0].ActivityGroupID}
+		todos, err := service.GetAll(query)
 		helper.ErrLogPanic(err)
 
 		// Length todos must be 1
@@ -117,7 +121,7 @@ func TestGetAllTodoServices(t *testing.T) {
 			require.Equal(t, todos[0].CreatedAt, data.CreatedAt)
 			require.Equal(t, todos[0].UpdatedAt, data.UpdatedAt)
 
-			require.Nil(t, data.DeletedAt)
+			require.False(t, data.DeletedAt.Valid)
 		}
 	})
 
@@ -127,8 +131,10 @@ func TestGetOneTodoServices(t *testing.T) {
 	t.Parallel()
 	newTodo := createRandomTodoService(t)
 
-	repository := repository.NewRepositoryTodo(ConnTest)
-	service := service.NewServiceTodo(repository)
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
 
 	// Get activity groups
 	todo, err := service.GetOne(newTodo.ID)
@@ -148,15 +154,18 @@ func TestGetOneTodoServices(t *testing.T) {
 func TestUpdateTodoService(t *testing.T) {
 	t.Parallel()
 
-	repository := repository.NewRepositoryTodo(ConnTest)
-	service := service.NewServiceTodo(repository)
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
 
 	t.Run("Update success", func(t *testing.T) {
 		// Create random data
 		newTodo := createRandomTodoService(t)
+		isActive := false
 		dataUpdated := web.TodoUpdateRequest{
 			Title:    jabufaker.RandomString(20),
-			IsActive: false,
+			IsActive: &isActive,
 		}
 
 		updatedTodo, err := service.Update(newTodo.ID, dataUpdated)
@@ -164,23 +173,24 @@ func TestUpdateTodoService(t *testing.T) {
 
 		require.Equal(t, newTodo.ID, updatedTodo.ID)
 		require.Equal(t, newTodo.ActivityGroupID, updatedTodo.ActivityGroupID)
-		require.Equal(t, newTodo.CreatedAt, updatedTodo.CreatedAt)
+		require.WithinDuration(t, *newTodo.CreatedAt, *updatedTodo.CreatedAt, time.Millisecond)
 
 		require.NotEqual(t, newTodo.Title, updatedTodo.Title)
 		require.NotEqual(t, newTodo.IsActive, updatedTodo.IsActive)
 
 		require.NotEqual(t, newTodo.UpdatedAt, updatedTodo.UpdatedAt)
 
-		require.Nil(t, updatedTodo.DeletedAt)
+		require.False(t, updatedTodo.DeletedAt.Valid)
 
 	})
 
 	t.Run("Update success without field is_active", func(t *testing.T) {
 		// Create random data
 		newTodo := createRandomTodoService(t)
+		isActive := true
 		dataUpdated := web.TodoUpdateRequest{
 			Title:    jabufaker.RandomString(20),
-			IsActive: true, // this sample and change type do it in handler, when checking field is false or true do in handler
+			IsActive: &isActive, // this sample and change type do it in handler, when checking field is false or true do in handler
 		}
 
 		updatedTodo, err := service.Update(newTodo.ID, dataUpdated)
@@ -188,21 +198,22 @@ func TestUpdateTodoService(t *testing.T) {
 
 		require.Equal(t, newTodo.ID, updatedTodo.ID)
 		require.Equal(t, newTodo.ActivityGroupID, updatedTodo.ActivityGroupID)
-		require.Equal(t, newTodo.CreatedAt, updatedTodo.CreatedAt)
+		require.WithinDuration(t, *newTodo.CreatedAt, *updatedTodo.CreatedAt, time.Millisecond)
 		require.Equal(t, newTodo.IsActive, updatedTodo.IsActive)
 
 		require.NotEqual(t, newTodo.Title, updatedTodo.Title)
 
 		require.NotEqual(t, newTodo.UpdatedAt, updatedTodo.UpdatedAt)
 
-		require.Nil(t, updatedTodo.DeletedAt)
+		require.False(t, updatedTodo.DeletedAt.Valid)
 	})
 
 	t.Run("Update success without field title", func(t *testing.T) {
 		// Create random data
 		newTodo := createRandomTodoService(t)
+		isActive := false
 		dataUpdated := web.TodoUpdateRequest{
-			IsActive: false,
+			IsActive: &isActive,
 		}
 
 		updatedTodo, err := service.Update(newTodo.ID, dataUpdated)
@@ -210,20 +221,21 @@ func TestUpdateTodoService(t *testing.T) {
 
 		require.Equal(t, newTodo.ID, updatedTodo.ID)
 		require.Equal(t, newTodo.ActivityGroupID, updatedTodo.ActivityGroupID)
-		require.Equal(t, newTodo.CreatedAt, updatedTodo.CreatedAt)
+		require.WithinDuration(t, *newTodo.CreatedAt, *updatedTodo.CreatedAt, time.Millisecond)
 		require.Equal(t, newTodo.Title, updatedTodo.Title)
 
 		require.NotEqual(t, newTodo.IsActive, updatedTodo.IsActive)
 
 		require.NotEqual(t, newTodo.UpdatedAt, updatedTodo.UpdatedAt)
 
-		require.Nil(t, updatedTodo.DeletedAt)
+		require.False(t, updatedTodo.DeletedAt.Valid)
 	})
 
 	t.Run("Update failed todo not found", func(t *testing.T) {
+		isActive := false
 		dataUpdated := web.TodoUpdateRequest{
 			Title:    jabufaker.RandomString(20),
-			IsActive: false,
+			IsActive: &isActive,
 		}
 
 		_, err := service.Update(7329323, dataUpdated)
@@ -240,19 +252,21 @@ func TestDeleteTodoService(t *testing.T) {
 	// Create random data
 	newTodo := createRandomTodoService(t)
 
-	repository := repository.NewRepositoryTodo(ConnTest)
-	service := service.NewServiceTodo(repository)
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
 
 	t.Run("Delete success", func(t *testing.T) {
 
-		ok, err := service.Delete(newTodo.ID)
+		ok, err := service.Delete(newTodo.ID, 0)
 		helper.ErrLogPanic(err)
 
 		require.True(t, ok)
 	})
 
 	t.Run("Delete failed todo not found", func(t *testing.T) {
-		ok, err := service.Delete(7329323)
+		ok, err := service.Delete(7329323, 0)
 		require.Error(t, err)
 		require.False(t, ok)
 
@@ -261,3 +275,208 @@ func TestDeleteTodoService(t *testing.T) {
 
 	})
 }
+
+func TestPriorityTodoService(t *testing.T) {
+	t.Parallel()
+
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
+
+	newActivity := createRandomActivityService(t)
+
+	// Create todo for each priority in one activity group
+	for _, priority := range []string{"low", "very-high", "medium"} {
+		data := web.TodoCreateRequest{
+			ActivityGroupID: newActivity.ID,
+			Title:           jabufaker.RandomString(20),
+			Priority:        priority,
+		}
+
+		newTodo, err := service.Create(data)
+		helper.ErrLogPanic(err)
+		require.Equal(t, priority, newTodo.Priority)
+	}
+
+	t.Run("Get all todos filter by priority", func(t *testing.T) {
+		query := web.TodoQuery{
+			ActivityGroupID: newActivity.ID,
+			Priority:        "low,medium",
+		}
+		todos, err := service.GetAll(query)
+		helper.ErrLogPanic(err)
+
+		require.Equal(t, 2, len(todos))
+		for _, data := range todos {
+			require.NotEqual(t, "very-high", data.Priority)
+		}
+	})
+
+	t.Run("Get all todos sort by priority", func(t *testing.T) {
+		query := web.TodoQuery{
+			ActivityGroupID: newActivity.ID,
+			Sort:            "priority",
+		}
+		todos, err := service.GetAll(query)
+		helper.ErrLogPanic(err)
+
+		require.Equal(t, 3, len(todos))
+		require.Equal(t, "very-high", todos[0].Priority)
+		require.Equal(t, "medium", todos[1].Priority)
+		require.Equal(t, "low", todos[2].Priority)
+
+		query.Sort = "-priority"
+		todos, err = service.GetAll(query)
+		helper.ErrLogPanic(err)
+
+		require.Equal(t, "low", todos[0].Priority)
+		require.Equal(t, "very-high", todos[2].Priority)
+	})
+
+	t.Run("Update priority keep is_active", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+		dataUpdated := web.TodoUpdateRequest{
+			Priority: "very-low",
+		}
+
+		updatedTodo, err := service.Update(newTodo.ID, dataUpdated)
+		helper.ErrLogPanic(err)
+
+		require.Equal(t, "very-low", updatedTodo.Priority)
+		require.Equal(t, newTodo.Title, updatedTodo.Title)
+		require.True(t, updatedTodo.IsActive)
+	})
+}
+
+func TestActivityGroupTodoService(t *testing.T) {
+	t.Parallel()
+
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	todoService := service.NewServiceTodo(repository, activityRepository, transactor)
+
+	t.Run("Create failed activity group not found", func(t *testing.T) {
+		data := web.TodoCreateRequest{
+			ActivityGroupID: 7329323,
+			Title:           jabufaker.RandomString(20),
+		}
+
+		_, err := todoService.Create(data)
+		require.ErrorIs(t, err, service.ErrActivityGroupNotFound)
+	})
+
+	t.Run("Move todo to other activity group", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+		newActivity := createRandomActivityService(t)
+
+		dataUpdated := web.TodoUpdateRequest{
+			ActivityGroupID: newActivity.ID,
+		}
+		updatedTodo, err := todoService.Update(newTodo.ID, dataUpdated)
+		helper.ErrLogPanic(err)
+
+		require.Equal(t, newActivity.ID, updatedTodo.ActivityGroupID)
+		require.Equal(t, newTodo.Title, updatedTodo.Title)
+	})
+
+	t.Run("Move todo failed activity group not found", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+
+		dataUpdated := web.TodoUpdateRequest{
+			ActivityGroupID: 7329323,
+		}
+		_, err := todoService.Update(newTodo.ID, dataUpdated)
+		require.ErrorIs(t, err, service.ErrActivityGroupNotFound)
+
+		todo, err := todoService.GetOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		require.Equal(t, newTodo.ActivityGroupID, todo.ActivityGroupID)
+	})
+}
+
+func TestIsActiveTodoService(t *testing.T) {
+	t.Parallel()
+
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
+
+	newTodo := createRandomTodoService(t)
+
+	t.Run("Update is_active to false", func(t *testing.T) {
+		isActive := false
+		updatedTodo, err := service.Update(newTodo.ID, web.TodoUpdateRequest{IsActive: &isActive})
+		helper.ErrLogPanic(err)
+
+		require.False(t, updatedTodo.IsActive)
+	})
+
+	t.Run("Update without is_active keep false", func(t *testing.T) {
+		updatedTodo, err := service.Update(newTodo.ID, web.TodoUpdateRequest{Title: jabufaker.RandomString(20)})
+		helper.ErrLogPanic(err)
+
+		require.False(t, updatedTodo.IsActive)
+	})
+
+	t.Run("Update is_active back to true", func(t *testing.T) {
+		isActive := true
+		updatedTodo, err := service.Update(newTodo.ID, web.TodoUpdateRequest{IsActive: &isActive})
+		helper.ErrLogPanic(err)
+
+		require.True(t, updatedTodo.IsActive)
+	})
+}
+
+func TestValidationTodoService(t *testing.T) {
+	t.Parallel()
+
+	activityRepository := Repositories.Activity
+	transactor := Repositories.Transactor
+	repository := Repositories.Todo
+	service := service.NewServiceTodo(repository, activityRepository, transactor)
+
+	dueAt := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	startAt := dueAt.Add(24 * time.Hour)
+
+	t.Run("Create failed invalid priority", func(t *testing.T) {
+		newActivity := createRandomActivityService(t)
+
+		_, err := service.Create(web.TodoCreateRequest{ActivityGroupID: newActivity.ID, Title: jabufaker.RandomString(20), Priority: "urgent"})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+
+		var appErr *apperror.Error
+		require.ErrorAs(t, err, &appErr)
+		require.Equal(t, "priority", appErr.Field)
+	})
+
+	t.Run("Create failed start_at after due_at", func(t *testing.T) {
+		newActivity := createRandomActivityService(t)
+
+		_, err := service.Create(web.TodoCreateRequest{ActivityGroupID: newActivity.ID, Title: jabufaker.RandomString(20), StartAt: &startAt, DueAt: &dueAt})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+		require.Equal(t, "start_at cannot be after due_at", err.Error())
+	})
+
+	t.Run("Update failed start_at after current due_at", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+		_, err := service.Update(newTodo.ID, web.TodoUpdateRequest{DueAt: &dueAt})
+		helper.ErrLogPanic(err)
+
+		_, err = service.Update(newTodo.ID, web.TodoUpdateRequest{StartAt: &startAt})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+
+		todo, err := service.GetOne(newTodo.ID)
+		helper.ErrLogPanic(err)
+		require.Nil(t, todo.StartAt)
+	})
+
+	t.Run("Update failed invalid priority", func(t *testing.T) {
+		newTodo := createRandomTodoService(t)
+
+		_, err := service.Update(newTodo.ID, web.TodoUpdateRequest{Priority: "urgent"})
+		require.ErrorIs(t, err, apperror.ErrValidation)
+	})
+}
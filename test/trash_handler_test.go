@@ -0,0 +1,131 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Delete, restore and purge todo", func(t *testing.T) {
+		newTodo := createRandomTodoHandler(t)
+		id := fmt.Sprintf("%d", newTodo.ID)
+
+		// Delete
+		request := httptest.NewRequest(http.MethodDelete, "http://localhost:3030/todo-items/"+id, nil)
+		recorder := httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, 200, recorder.Result().StatusCode)
+
+		// Todo is in trash
+		request = httptest.NewRequest(http.MethodGet, "http://localhost:3030/trash", nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		contextData := responseBody["data"].(map[string]interface{})
+		var found bool
+		for _, data := range contextData["todo_items"].([]interface{}) {
+			todo := data.(map[string]interface{})
+			if uint64(todo["id"].(float64)) == newTodo.ID {
+				found = true
+				require.NotNil(t, todo["deleted_at"])
+			}
+		}
+		require.True(t, found)
+
+		// Restore
+		request = httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items/"+id+"/restore", nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+
+		response = recorder.Result()
+		body, _ = io.ReadAll(response.Body)
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		contextData = responseBody["data"].(map[string]interface{})
+		require.Equal(t, newTodo.ID, uint64(contextData["id"].(float64)))
+		require.Nil(t, contextData["deleted_at"])
+
+		// Restore again is not found
+		request = httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items/"+id+"/restore", nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, 404, recorder.Result().StatusCode)
+
+		// Delete then purge
+		request = httptest.NewRequest(http.MethodDelete, "http://localhost:3030/todo-items/"+id, nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, 200, recorder.Result().StatusCode)
+
+		request = httptest.NewRequest(http.MethodDelete, "http://localhost:3030/trash/todo-items/"+id, nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, 200, recorder.Result().StatusCode)
+
+		request = httptest.NewRequest(http.MethodPost, "http://localhost:3030/todo-items/"+id+"/restore", nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, 404, recorder.Result().StatusCode)
+	})
+
+	t.Run("Delete and restore activity group", func(t *testing.T) {
+		newActivity := createRandomActivityHandler(t)
+		id := fmt.Sprintf("%d", newActivity.ID)
+
+		request := httptest.NewRequest(http.MethodDelete, "http://localhost:3030/activity-groups/"+id, nil)
+		recorder := httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, 200, recorder.Result().StatusCode)
+
+		request = httptest.NewRequest(http.MethodPost, "http://localhost:3030/activity-groups/"+id+"/restore", nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 200, response.StatusCode)
+		contextData := responseBody["data"].(map[string]interface{})
+		require.Equal(t, newActivity.Title, contextData["title"])
+
+		request = httptest.NewRequest(http.MethodGet, "http://localhost:3030/activity-groups/"+id, nil)
+		recorder = httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, 200, recorder.Result().StatusCode)
+	})
+
+	t.Run("Purge activity group not in trash", func(t *testing.T) {
+		newActivity := createRandomActivityHandler(t)
+		id := fmt.Sprintf("%d", newActivity.ID)
+
+		request := httptest.NewRequest(http.MethodDelete, "http://localhost:3030/trash/activity-groups/"+id, nil)
+		recorder := httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+
+		response := recorder.Result()
+		body, _ := io.ReadAll(response.Body)
+		var responseBody map[string]interface{}
+		json.Unmarshal(body, &responseBody)
+
+		require.Equal(t, 404, response.StatusCode)
+		message := fmt.Sprintf("Activity with ID %d Not Found in trash", newActivity.ID)
+		require.Equal(t, message, responseBody["message"])
+	})
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/letenk/todo-list/event"
+	"github.com/letenk/todo-list/helper"
+	"github.com/letenk/todo-list/models/domain"
+	"github.com/letenk/todo-list/repository"
+	"github.com/letenk/todo-list/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDispatcherInternalAddress(t *testing.T) {
+	t.Parallel()
+
+	okServer, okReceived := webhookServer(t, http.StatusNoContent)
+	redirectServer := httptest.NewServer(http.RedirectHandler(okServer.URL, http.StatusFound))
+	defer redirectServer.Close()
+
+	store := repository.NewMemoryStore()
+	webhookRepository := repository.NewRepositoryWebhookMemory(store)
+	deliveryRepository := repository.NewRepositoryWebhookDeliveryMemory(store)
+	dispatcher := service.NewServiceWebhookDispatcher(webhookRepository, deliveryRepository, repository.NewRepositoryActivityMemory(store), time.Millisecond, 1)
+
+	user, err := repository.NewRepositoryUserMemory(store).Save(domain.User{Email: "dispatch@example.com", Name: "dispatch", PasswordHash: "hash"})
+	helper.ErrLogPanic(err)
+
+	deliver := func(dispatcher *service.WebhookDispatcher, url string) domain.WebhookDelivery {
+		webhook, err := webhookRepository.Save(domain.Webhook{UserID: user.ID, URL: url, Secret: "secret", EventTypes: "todo.created"})
+		helper.ErrLogPanic(err)
+		delivery, err := deliveryRepository.Save(domain.WebhookDelivery{WebhookID: webhook.ID, EventID: 1, EventType: "todo.created",
+			Payload: []byte(`{}`), Status: domain.DeliveryPending, NextAttemptAt: time.Now()})
+		helper.ErrLogPanic(err)
+
+		_, err = dispatcher.DeliverDue(time.Now())
+		helper.ErrLogPanic(err)
+		delivery, err = deliveryRepository.FindOne(webhook.ID, delivery.ID)
+		helper.ErrLogPanic(err)
+		return delivery
+	}
+
+	// Server of test is on loopback
+	delivery := deliver(dispatcher, okServer.URL)
+	require.Equal(t, domain.DeliveryDead, delivery.Status)
+	require.Contains(t, delivery.LastError, service.ErrWebhookAddressNotAllowed.Error())
+
+	// Redirect is not followed
+	delivery = deliver(dispatcher.WithClient(service.NewWebhookClient(true)), redirectServer.URL)
+	require.Equal(t, domain.DeliveryDead, delivery.Status)
+	require.Equal(t, http.StatusFound, delivery.LastStatusCode)
+	require.Empty(t, okReceived)
+}
+
+func TestWebhookDispatcherSendAgain(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewMemoryStore()
+	webhookRepository := repository.NewRepositoryWebhookMemory(store)
+	deliveryRepository := repository.NewRepositoryWebhookDeliveryMemory(store)
+	activityRepository := repository.NewRepositoryActivityMemory(store)
+	dispatcher := service.NewServiceWebhookDispatcher(webhookRepository, deliveryRepository, activityRepository, time.Millisecond, 1)
+
+	user, err := repository.NewRepositoryUserMemory(store).Save(domain.User{Email: "send-again@example.com", Name: "send again", PasswordHash: "hash"})
+	helper.ErrLogPanic(err)
+	activity, err := activityRepository.WithOwner(user.ID).Save(domain.Activity{Title: "send again", Email: "send-again@example.com"})
+	helper.ErrLogPanic(err)
+	webhook, err := webhookRepository.Save(domain.Webhook{UserID: user.ID, URL: "http://example.com", Secret: "secret", EventTypes: event.TodoCreated})
+	helper.ErrLogPanic(err)
+
+	// Outbox send the event again when other sink failed
+	e := event.Event{ID: 1, Type: event.TodoCreated, ActivityGroupID: activity.ID, Data: domain.Todo{ID: 1, ActivityGroupID: activity.ID, Title: "todo"}}
+	require.NoError(t, dispatcher.Send(e))
+	require.NoError(t, dispatcher.Send(e))
+
+	deliveries, err := deliveryRepository.FindByWebhookID(webhook.ID, "", 10)
+	helper.ErrLogPanic(err)
+	require.Len(t, deliveries, 1)
+}
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letenk/todo-list/service"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedWebhook is request received by server of webhook
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookServer start server of webhook, it response status and send each request to the channel
+func webhookServer(t *testing.T, status int) (*httptest.Server, chan receivedWebhook) {
+	received := make(chan receivedWebhook, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedWebhook{r.Header, body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestWebhookHandler(t *testing.T) {
+	t.Parallel()
+
+	owner := createUser(jabufaker.RandomEmail())
+	other := createUser(jabufaker.RandomEmail())
+
+	body := fmt.Sprintf(`{"title": "webhook", "email": "%s"}`, jabufaker.RandomEmail())
+	response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, owner.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := responseBody["data"].(map[string]interface{})["id"].(float64)
+
+	okServer, okReceived := webhookServer(t, http.StatusNoContent)
+	failedServer, failedReceived := webhookServer(t, http.StatusInternalServerError)
+
+	var completed, failed map[string]interface{}
+
+	t.Run("Create webhook", func(t *testing.T) {
+		response, responseBody := requestAuth(http.MethodPost, "/webhooks", `{"url": "ftp://example.com", "event_types": ["todo.updated"]}`, owner.AccessToken)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+		require.Equal(t, "url must be http or https URL", responseBody["message"])
+
+		response, _ = requestAuth(http.MethodPost, "/webhooks", `{"url": "https://example.com", "event_types": ["todo.moved"]}`, owner.AccessToken)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		// Activity group of other user cannot be the filter
+		body := fmt.Sprintf(`{"url": "https://example.com", "event_types": ["todo.updated"], "activity_group_id": %.0f}`, activityID)
+		response, _ = requestAuth(http.MethodPost, "/webhooks", body, other.AccessToken)
+		require.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+
+		body = fmt.Sprintf(`{"url": "%s", "event_types": ["todo.updated", "todo.updated"], "activity_group_id": %.0f}`, okServer.URL, activityID)
+		response, responseBody = requestAuth(http.MethodPost, "/webhooks", body, owner.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		completed = responseBody["data"].(map[string]interface{})
+		require.True(t, strings.HasPrefix(completed["secret"].(string), service.WebhookSecretPrefix))
+		require.Equal(t, []interface{}{"todo.updated"}, completed["event_types"])
+		require.Equal(t, activityID, completed["activity_group_id"])
+
+		body = fmt.Sprintf(`{"url": "%s", "secret": "0123456789abcdef", "event_types": ["todo.created"]}`, failedServer.URL)
+		response, responseBody = requestAuth(http.MethodPost, "/webhooks", body, owner.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		failed = responseBody["data"].(map[string]interface{})
+
+		// Secret is only shown when it is created
+		response, responseBody = requestAuth(http.MethodGet, "/webhooks", "", owner.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Len(t, responseBody["data"], 2)
+		require.NotContains(t, responseBody["data"].([]interface{})[0], "secret")
+
+		response, _ = requestAuth(http.MethodGet, fmt.Sprintf("/webhooks/%.0f", completed["id"]), "", other.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("Deliver signed event with retry", func(t *testing.T) {
+		// Dispatcher of TestMain retry after 10ms, 2 attempts
+		body := fmt.Sprintf(`{"title": "deploy", "activity_group_id": %.0f}`, activityID)
+		response, responseBody := requestAuth(http.MethodPost, "/todo-items", body, owner.AccessToken)
+		require.Equal(t, http.StatusCreated, response.StatusCode)
+		todoID := responseBody["data"].(map[string]interface{})["id"].(float64)
+
+		response, _ = requestAuth(http.MethodPatch, fmt.Sprintf("/todo-items/%.0f", todoID), `{"is_active": false}`, owner.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var delivered receivedWebhook
+		select {
+		case delivered = <-okReceived:
+		case <-time.After(10 * time.Second):
+			t.Fatal("webhook is not delivered")
+		}
+		require.Equal(t, "todo.updated", delivered.header.Get("X-Webhook-Event"))
+		timestamp, err := strconv.ParseInt(delivered.header.Get("X-Webhook-Timestamp"), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, service.SignWebhook(completed["secret"].(string), timestamp, delivered.body), delivered.header.Get("X-Webhook-Signature"))
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(delivered.body, &payload))
+		require.Equal(t, "todo.updated", payload["type"])
+		require.Equal(t, "deploy", payload["data"].(map[string]interface{})["title"])
+
+		// Failed delivery is retried, then it is in dead-letter list
+		for i := 0; i < 2; i++ {
+			select {
+			case <-failedReceived:
+			case <-time.After(10 * time.Second):
+				t.Fatal("webhook is not retried")
+			}
+		}
+
+		deadLetters := fmt.Sprintf("/webhooks/%.0f/deliveries?status=dead", failed["id"])
+		var deliveries []interface{}
+		require.Eventually(t, func() bool {
+			_, responseBody := requestAuth(http.MethodGet, deadLetters, "", owner.AccessToken)
+			deliveries = responseBody["data"].([]interface{})
+			return len(deliveries) == 1
+		}, 5*time.Second, 50*time.Millisecond)
+		dead := deliveries[0].(map[string]interface{})
+		require.Equal(t, "todo.created", dead["event_type"])
+		require.Equal(t, float64(2), dead["attempts"])
+		require.Equal(t, float64(http.StatusInternalServerError), dead["last_status_code"])
+
+		response, responseBody = requestAuth(http.MethodPost, fmt.Sprintf("/webhooks/%.0f/deliveries/%.0f/redeliver", failed["id"], dead["id"]), "", owner.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "pending", responseBody["data"].(map[string]interface{})["status"])
+		require.Equal(t, float64(0), responseBody["data"].(map[string]interface{})["attempts"])
+	})
+
+	t.Run("Update and delete webhook", func(t *testing.T) {
+		target := fmt.Sprintf("/webhooks/%.0f", completed["id"])
+		response, responseBody := requestAuth(http.MethodPatch, target, `{"event_types": ["todo.deleted"], "activity_group_id": 0}`, owner.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, []interface{}{"todo.deleted"}, responseBody["data"].(map[string]interface{})["event_types"])
+		require.Nil(t, responseBody["data"].(map[string]interface{})["activity_group_id"])
+
+		response, _ = requestAuth(http.MethodDelete, target, "", other.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+		response, _ = requestAuth(http.MethodDelete, target, "", owner.AccessToken)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		response, _ = requestAuth(http.MethodGet, target+"/deliveries", "", owner.AccessToken)
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+}
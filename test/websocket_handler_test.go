@@ -0,0 +1,177 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letenk/todo-list/models/web"
+	"github.com/rizkydarmawan-letenk/jabufaker"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// dialWebsocket connect to websocket of server as user of token, it is closed at the end of the test
+func dialWebsocket(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	config, err := websocket.NewConfig(strings.Replace(server.URL, "http", "ws", 1)+"/ws", WebsocketOrigin)
+	require.NoError(t, err)
+	config.Header.Set("Authorization", "Bearer "+token)
+
+	conn, err := websocket.DialConfig(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receiveWebsocket receive the next message of type, other type is skipped
+func receiveWebsocket(t *testing.T, conn *websocket.Conn, messageType string) map[string]interface{} {
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	for {
+		var message map[string]interface{}
+		require.NoError(t, websocket.JSON.Receive(conn, &message))
+		if message["type"] == messageType {
+			return message
+		}
+	}
+}
+
+// receiveEvent receive the next event of eventType, other event is skipped. Event of
+// activity group created before subscribe may be relayed after it
+func receiveEvent(t *testing.T, conn *websocket.Conn, eventType string) map[string]interface{} {
+	for {
+		event := receiveWebsocket(t, conn, web.WebsocketEvent)["data"].(map[string]interface{})
+		if event["type"] == eventType {
+			return event
+		}
+	}
+}
+
+func TestWebsocketHandler(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(Route)
+	defer server.Close()
+
+	owner := createUser(jabufaker.RandomEmail())
+	conn := dialWebsocket(t, server, owner.AccessToken)
+	other := dialWebsocket(t, server, TestUser.AccessToken)
+
+	body := `{"title": "websocket", "email": "websocket@example.com"}`
+	response, responseBody := requestAuth(http.MethodPost, "/activity-groups", body, owner.AccessToken)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	activityID := responseBody["data"].(map[string]interface{})["id"].(float64)
+
+	t.Run("Subscribe", func(t *testing.T) {
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "1", Type: web.WebsocketSubscribe, ActivityGroupID: uint64(activityID)}))
+		ack := receiveWebsocket(t, conn, web.WebsocketAck)
+		require.Equal(t, "1", ack["id"])
+		require.Equal(t, activityID, ack["activity_group_id"])
+
+		// Activity group of other user is not found
+		require.NoError(t, websocket.JSON.Send(other, web.WebsocketRequest{ID: "2", Type: web.WebsocketSubscribe, ActivityGroupID: uint64(activityID)}))
+		failed := receiveWebsocket(t, other, web.WebsocketError)
+		require.Equal(t, "2", failed["id"])
+		require.Equal(t, float64(http.StatusNotFound), failed["status"])
+		require.Equal(t, "not_found", failed["code"])
+	})
+
+	var todoID float64
+
+	t.Run("Create, update and delete todo", func(t *testing.T) {
+		data := fmt.Sprintf(`{"title": "live", "activity_group_id": %.0f}`, activityID)
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "create", Type: web.WebsocketTodoCreate, Data: []byte(data)}))
+		ack := receiveWebsocket(t, conn, web.WebsocketAck)
+		require.Equal(t, "create", ack["id"])
+		todo := ack["data"].(map[string]interface{})
+		require.Equal(t, "live", todo["title"])
+		todoID = todo["id"].(float64)
+
+		event := receiveEvent(t, conn, "todo.created")
+		require.Equal(t, todoID, event["data"].(map[string]interface{})["id"])
+
+		// Version of other update is refused
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "stale", Type: web.WebsocketTodoUpdate, TodoID: uint64(todoID), Version: 7, Data: []byte(`{"title": "stale"}`)}))
+		failed := receiveWebsocket(t, conn, web.WebsocketError)
+		require.Equal(t, "stale", failed["id"])
+		require.Equal(t, float64(http.StatusPreconditionFailed), failed["status"])
+
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "update", Type: web.WebsocketTodoUpdate, TodoID: uint64(todoID), Version: 1, Data: []byte(`{"title": "edited"}`)}))
+		ack = receiveWebsocket(t, conn, web.WebsocketAck)
+		require.Equal(t, "update", ack["id"])
+		require.Equal(t, "edited", ack["data"].(map[string]interface{})["title"])
+		receiveEvent(t, conn, "todo.updated")
+
+		// Todo of other user is not found
+		require.NoError(t, websocket.JSON.Send(other, web.WebsocketRequest{ID: "delete", Type: web.WebsocketTodoDelete, TodoID: uint64(todoID)}))
+		require.Equal(t, "not_found", receiveWebsocket(t, other, web.WebsocketError)["code"])
+
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "delete", Type: web.WebsocketTodoDelete, TodoID: uint64(todoID)}))
+		require.Equal(t, "delete", receiveWebsocket(t, conn, web.WebsocketAck)["id"])
+		receiveEvent(t, conn, "todo.deleted")
+	})
+
+	t.Run("Invalid message", func(t *testing.T) {
+		require.NoError(t, websocket.Message.Send(conn, `not json`))
+		require.Equal(t, "bad_request", receiveWebsocket(t, conn, web.WebsocketError)["code"])
+
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "3", Type: "todo.move"}))
+		failed := receiveWebsocket(t, conn, web.WebsocketError)
+		require.Equal(t, "3", failed["id"])
+		require.Equal(t, "type", failed["errors"].([]interface{})[0].(map[string]interface{})["field"])
+
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "4", Type: web.WebsocketTodoCreate, Data: []byte(`{"title": "no group"}`)}))
+		failed = receiveWebsocket(t, conn, web.WebsocketError)
+		require.Equal(t, float64(http.StatusBadRequest), failed["status"])
+		require.Equal(t, "activity_group_id", failed["errors"].([]interface{})[0].(map[string]interface{})["field"])
+	})
+
+	t.Run("Token of query", func(t *testing.T) {
+		// Route set token of TestUser when the header is not exist
+		request := httptest.NewRequest(http.MethodGet, "http://localhost:3030/ws?access_token=invalid", nil)
+		request.Header["Authorization"] = nil
+		recorder := httptest.NewRecorder()
+		Route.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		// Token is removed from URL before it is logged
+		require.NotContains(t, request.URL.RawQuery, "access_token")
+	})
+
+	t.Run("Token of subprotocol", func(t *testing.T) {
+		// Router without token of TestUser
+		server := httptest.NewServer(Route.(authenticatedRoute).Handler)
+		defer server.Close()
+
+		config, err := websocket.NewConfig(strings.Replace(server.URL, "http", "ws", 1)+"/ws", WebsocketOrigin)
+		require.NoError(t, err)
+		config.Protocol = []string{"bearer", owner.AccessToken}
+		conn, err := websocket.DialConfig(config)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, websocket.JSON.Send(conn, web.WebsocketRequest{ID: "1", Type: web.WebsocketSubscribe, ActivityGroupID: uint64(activityID)}))
+		require.Equal(t, "1", receiveWebsocket(t, conn, web.WebsocketAck)["id"])
+
+		config.Protocol = []string{"bearer", "invalid"}
+		_, err = websocket.DialConfig(config)
+		require.Error(t, err)
+	})
+
+	t.Run("Origin not allowed", func(t *testing.T) {
+		// Origin of the same host is not checked by CORS, but it is not allowed either
+		config, err := websocket.NewConfig(strings.Replace(server.URL, "http", "ws", 1)+"/ws", server.URL)
+		require.NoError(t, err)
+		config.Header.Set("Authorization", "Bearer "+owner.AccessToken)
+		_, err = websocket.DialConfig(config)
+		require.Error(t, err)
+
+		// Every origin pass CORS, only origins of websocket can open it
+		config, err = websocket.NewConfig(strings.Replace(server.URL, "http", "ws", 1)+"/ws", "https://other.example.com")
+		require.NoError(t, err)
+		config.Header.Set("Authorization", "Bearer "+owner.AccessToken)
+		_, err = websocket.DialConfig(config)
+		require.Error(t, err)
+	})
+}